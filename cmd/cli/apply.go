@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrSpecEmpty used when spec file isn't supplied
+	ErrSpecEmpty = errors.New("spec file can't be empty, use --file flag")
+
+	// ErrPlanFailed used when plan can't be computed
+	ErrPlanFailed = errors.New("computing plan failed")
+)
+
+var cmdPlan = &cobra.Command{
+	Use:   "plan",
+	Short: "Use Acroplia API to show changes needed to reach a spec",
+	Long: `Use Acroplia API to compare a spec of workspaces, members with roles, library folders and seed textpads with current state
+and show what apply would create, update or delete. Nothing is changed in Acroplia.
+
+Spec is a yaml or toml file set by --file (-f), format is chosen by file extension.
+
+You have to perform login before using this command as it needs user information from login.
+
+Example:
+  ./acroplia plan --file space.yaml --format table
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, plan, err := buildPlan()
+		if err != nil {
+			return err
+		}
+
+		return writeOutput(plan, func(w io.Writer) {
+			writePlanTable(w, plan)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var cmdApply = &cobra.Command{
+	Use:   "apply",
+	Short: "Use Acroplia API to bring workspaces and library to the state described by a spec",
+	Long: `Use Acroplia API to create or update workspaces, members with roles, library folders and seed textpads described by a spec.
+
+Spec is compared with current state first, so applying the same spec again changes nothing.
+Apply stops at the first failed change, run it again to continue after fixing the cause.
+Members are removed only from workspaces with prune: true, library items are never deleted.
+
+You have to perform login before using this command as it needs user information from login.
+
+Example:
+  ./acroplia apply -f space.yaml
+  ./acroplia apply --file space.toml --format table
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provisioner, plan, err := buildPlan()
+		if err != nil {
+			return err
+		}
+
+		if plan.Empty() {
+			log.Logger.Info().Msg("nothing to apply, workspaces are up to date")
+		}
+
+		applyErr := provisioner.Apply(plan, func(action *crud.PlanAction) {
+			log.Logger.Info().Msgf("%s %s %s: %s", action.Action, action.Kind, action.Target, action.Status)
+		})
+		if applyErr != nil {
+			log.Logger.Debug().Msgf("provisioner.Apply: %v", applyErr)
+		}
+
+		err = writeOutput(plan, func(w io.Writer) {
+			writePlanTable(w, plan)
+		})
+		if err != nil {
+			return err
+		}
+
+		return applyErr
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// buildPlan loads spec from flags and computes it's plan for logged in user
+func buildPlan() (*crud.Provisioner, *crud.Plan, error) {
+	if conf.SpecFile == "" {
+		return nil, nil, ErrSpecEmpty
+	}
+
+	spec, err := crud.LoadSpec(conf.SpecFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authResponse, err := readLoginResponse()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provisioner := crud.NewProvisioner(authResponse.Data.User, authResponse.Data.AccessToken)
+
+	log.Logger.Debug().Msgf("computing plan for %s ...", conf.SpecFile)
+	plan, err := provisioner.Plan(spec)
+	if err != nil {
+		log.Logger.Debug().Msgf("provisioner.Plan: %v", err)
+
+		return nil, nil, ErrPlanFailed
+	}
+	log.Logger.Debug().Msgf("computing plan was done successfully, %d changes", len(plan.Actions))
+
+	return provisioner, plan, nil
+}
+
+// writePlanTable writes plan actions as table rows
+func writePlanTable(w io.Writer, plan *crud.Plan) {
+	fmt.Fprintln(w, "ACTION\tKIND\tTARGET\tDETAILS\tSTATUS")
+	for _, a := range plan.Actions {
+		details, status := a.Details, a.Status
+		if details == "" {
+			details = "-"
+		}
+		if status == "" {
+			status = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.Action, a.Kind, a.Target, details, status)
+	}
+}
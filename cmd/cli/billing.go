@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrBillingFailed used when billing request to Acroplia fails
+	ErrBillingFailed = errors.New("billing request failed")
+
+	// ErrBillingForbidden used when user isn't an admin of workspace or community
+	ErrBillingForbidden = errors.New("only admins of workspace or community can see it's billing")
+
+	// ErrBillingNotFound used when workspace or community doesn't exist
+	ErrBillingNotFound = errors.New("workspace or community not found")
+)
+
+var cmdBilling = &cobra.Command{
+	Use:   "billing",
+	Short: "Get paid memberships and purchases of workspace or community in Acroplia",
+	Long: `Get paid memberships and purchases of paid content of workspace or community in Acroplia for reconciliation, billing is read only.
+
+You have to use it's subcommands: members or purchases.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia billing members {workspace_uuid} --status active --format table
+  ./acroplia billing members {workspace_uuid} --since 2020-07-01 --until 2020-08-01 --format csv --output memberships.csv
+  ./acroplia billing purchases {workspace_uuid} --since 720h --format csv --output purchases.csv
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var billingMembers = &cobra.Command{
+	Use:   "members <workspace-uuid>",
+	Short: "Use Acroplia API to list paid memberships",
+	Long: `Use Acroplia API to list paid memberships of workspace or community, only admins can list them.
+
+Memberships started between --since and --until are listed, both accept either a duration back from now, ex: 720h,
+or a date, ex: 2020-07-01 or 2020-07-01T09:00:00Z. --status is one of: active, canceled or expired.
+
+All pages are listed, unless --page is set. Amounts are written in major units of currency, ex: 12.50 EUR or 1250 JPY.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationFormats: strings.Join([]string{formatJSON, formatTable, formatCSV}, ","),
+	},
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := buildBillingFilter()
+		if err != nil {
+			return err
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("listing paid memberships of %s ...", args[0])
+		resp, err := crud.ListPaidMemberships(args[0], authResponse.Data.AccessToken, filter)
+		if errors.Is(err, crud.ErrMembershipStatus) || errors.Is(err, crud.ErrBillingRange) {
+			return err
+		} else if err != nil {
+			log.Logger.Debug().Msgf("crud.ListPaidMemberships: %v", err)
+
+			return apiError(err, ErrBillingForbidden, ErrBillingNotFound, ErrBillingFailed)
+		}
+		log.Logger.Debug().Msgf("listing paid memberships was done successfully, found %d of %d memberships", len(resp.Data), resp.Total)
+
+		if conf.OutputFormat == formatCSV {
+			log.Logger.Info().Msgf("writing memberships to: %s", conf.PathToOutputFile)
+			if err := crud.WriteMembershipsCSV(outputFile, resp.Data); err != nil {
+				log.Logger.Debug().Msgf("crud.WriteMembershipsCSV: %v", err)
+
+				return errors.New("couldn't write memberships")
+			}
+
+			return nil
+		}
+
+		return writeOutput(resp, func(w io.Writer) {
+			fmt.Fprintln(w, "UUID\tMEMBER\tPLAN\tSTATUS\tAMOUNT\tSTARTED AT\tEXPIRES AT")
+			for _, m := range resp.Data {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s %s\t%s\t%s\n", m.UUID, formatUser(m.User), m.Plan, m.Status, crud.FormatAmount(m.Amount, m.Currency), m.Currency, formatTimestamp(m.StartedAt), formatTimestamp(m.ExpiresAt))
+			}
+			writeBillingTotals(w, crud.TotalAmounts(resp.Data, nil))
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var billingPurchases = &cobra.Command{
+	Use:   "purchases <workspace-uuid>",
+	Short: "Use Acroplia API to list purchases of paid content",
+	Long: `Use Acroplia API to list purchases of paid content in workspace or community, only admins can list them.
+
+Purchases made between --since and --until are listed, both accept either a duration back from now, ex: 720h,
+or a date, ex: 2020-07-01 or 2020-07-01T09:00:00Z.
+
+All pages are listed, unless --page is set. Amounts are written in major units of currency, ex: 12.50 EUR or 1250 JPY,
+refunded purchases aren't counted in totals.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationFormats: strings.Join([]string{formatJSON, formatTable, formatCSV}, ","),
+	},
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := buildBillingFilter()
+		if err != nil {
+			return err
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("listing purchases of %s ...", args[0])
+		resp, err := crud.ListPurchases(args[0], authResponse.Data.AccessToken, filter)
+		if errors.Is(err, crud.ErrBillingRange) {
+			return err
+		} else if err != nil {
+			log.Logger.Debug().Msgf("crud.ListPurchases: %v", err)
+
+			return apiError(err, ErrBillingForbidden, ErrBillingNotFound, ErrBillingFailed)
+		}
+		log.Logger.Debug().Msgf("listing purchases was done successfully, found %d of %d purchases", len(resp.Data), resp.Total)
+
+		if conf.OutputFormat == formatCSV {
+			log.Logger.Info().Msgf("writing purchases to: %s", conf.PathToOutputFile)
+			if err := crud.WritePurchasesCSV(outputFile, resp.Data); err != nil {
+				log.Logger.Debug().Msgf("crud.WritePurchasesCSV: %v", err)
+
+				return errors.New("couldn't write purchases")
+			}
+
+			return nil
+		}
+
+		return writeOutput(resp, func(w io.Writer) {
+			fmt.Fprintln(w, "UUID\tCONTENT\tBUYER\tOWNER\tAMOUNT\tPURCHASED AT\tREFUNDED AT")
+			for _, p := range resp.Data {
+				content := p.NodeTitle
+				if content == "" {
+					content = p.Node
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s %s\t%s\t%s\n", p.UUID, content, formatUser(p.Buyer), formatUser(p.Owner), crud.FormatAmount(p.Amount, p.Currency), p.Currency, formatTimestamp(p.PurchasedAt), formatTimestamp(p.RefundedAt))
+			}
+			writeBillingTotals(w, crud.TotalAmounts(nil, resp.Data))
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// buildBillingFilter builds filter of paid memberships and purchases from flags
+func buildBillingFilter() (*crud.BillingFilter, error) {
+	var since, until time.Time
+	var err error
+	if since, err = parseTime(conf.BillingSince); err != nil {
+		return nil, err
+	}
+	if until, err = parseTime(conf.BillingUntil); err != nil {
+		return nil, err
+	}
+
+	return &crud.BillingFilter{
+		Since:  since,
+		Until:  until,
+		Status: conf.BillingStatus,
+		Page:   conf.BillingPage,
+		Size:   conf.BillingSize,
+	}, nil
+}
+
+// writeBillingTotals writes sum of amounts for each currency, currencies are sorted
+func writeBillingTotals(w io.Writer, totals map[string]int) {
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		fmt.Fprintf(w, "\nTOTAL\t%s %s\n", crud.FormatAmount(totals[currency], currency), currency)
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bejaneps/acroplia/internal/bot"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var cmdBot = &cobra.Command{
+	Use:   "bot",
+	Short: "Run chat bots in Acroplia",
+	Long: `Run chat bots in Acroplia, that reply to messages automatically and send reminders.
+
+You have to use it's subcommand: run.
+
+Don't forget to perform login through API, before using this command ! Bot acts on behalf of logged in user.
+
+Example:
+  ./acroplia bot run --config bot.toml
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var botRun = &cobra.Command{
+	Use:   "run",
+	Short: "Use Acroplia API to run a bot described in config file",
+	Long: `Use Acroplia API to run a bot described in config file, it runs until it's stopped by Ctrl + C.
+
+Config file lists chats bot follows, rules of replies, default reply and reminders:
+
+  chats = ["{chat_uuid}"]
+  default = "Sorry, I don't understand. Try /help"
+
+  [[rules]]
+  command = "/help"
+  reply = "Hi {{ .Sender.FirstName }}, ask me about homework"
+
+  [[rules]]
+  regexp = "(?i)homework"
+  reply = "Homework is in the library"
+
+  [[reminders]]
+  every = "24h"
+  text = "Don't forget to submit homework"
+
+Replies are Go text/template, they have access to Sender, Message, Args (text after command) and Match (submatches of regexp).
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.BotConfig == "" {
+			return errors.New("--config is required for this command")
+		}
+
+		log.Logger.Debug().Msgf("reading bot config %s ...", conf.BotConfig)
+		botConf, err := bot.LoadConfig(conf.BotConfig)
+		if err != nil {
+			log.Logger.Debug().Msgf("bot.LoadConfig: %v", err)
+
+			return errors.New("couldn't read bot config")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		b := bot.New(bot.NewAPIBackend(authResponse.Data.AccessToken), authResponse.Data.User)
+		b.OnError = func(err error) {
+			log.Logger.Warn().Msgf("bot: %v", err)
+		}
+		if err := botConf.Register(b); err != nil {
+			return errors.Wrap(err, "invalid bot config")
+		}
+
+		// stop on Ctrl + C
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			<-quit
+			log.Logger.Debug().Msg("received SIGINT(Ctrl + C) signal, stopping bot...")
+
+			cancel()
+		}()
+
+		log.Logger.Info().Msgf("bot is following %d chats with %d rules", len(botConf.Chats), len(botConf.Rules))
+		err = b.Run(ctx, botConf.Chats...)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			log.Logger.Debug().Msgf("bot.Run: %v", err)
+
+			return errors.New("running bot failed")
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrChatsUnreadFailed used when fetching unread counts fails
+	ErrChatsUnreadFailed = errors.New("fetching unread messages failed")
+
+	// ErrChatsReadFailed used when marking a chat as read fails
+	ErrChatsReadFailed = errors.New("marking chat as read failed")
+)
+
+var cmdChats = &cobra.Command{
+	Use:   "chats",
+	Short: "Manage chats in Acroplia",
+	Long: `Manage chats in Acroplia.
+
+You have to use it's subcommands: unread or read.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia chats unread --format table
+  ./acroplia chats read {chat_uuid}
+  ./acroplia chats read {chat_uuid} --message {message_uuid}
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var chatsUnread = &cobra.Command{
+	Use:   "unread",
+	Short: "Use Acroplia API to list chats with unread messages",
+	Long: `Use Acroplia API to list chats with unread messages and number of unread messages in each of them.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msg("fetching unread counts ...")
+		resp, err := crud.ListUnreadCounts(authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListUnreadCounts: %v", err)
+
+			return ErrChatsUnreadFailed
+		}
+		log.Logger.Debug().Msg("fetching unread counts was done successfully")
+
+		unread := resp.Unread()
+
+		return writeOutput(unread, func(w io.Writer) {
+			writeUnreadTable(w, authResponse.Data.User.UUID, unread...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var chatsRead = &cobra.Command{
+	Use:   "read <chat-uuid>",
+	Short: "Use Acroplia API to mark a chat as read",
+	Long: `Use Acroplia API to mark messages of a chat as read, up to --message or up to the last message of a chat.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		messageUUID := conf.ChatsMessage
+		if messageUUID == "" {
+			log.Logger.Debug().Msgf("getting last message of chat %s ...", args[0])
+			resp, err := crud.ListMessages(args[0], &crud.MessageQuery{Limit: 1}, authResponse.Data.AccessToken)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.ListMessages: %v", err)
+
+				return ErrChatsReadFailed
+			}
+
+			if len(resp.Data) == 0 {
+				log.Logger.Info().Msgf("chat %s has no messages", args[0])
+
+				return nil
+			}
+			messageUUID = resp.Data[0].UUID
+		}
+
+		log.Logger.Debug().Msgf("marking chat %s as read up to message %s ...", args[0], messageUUID)
+		err = crud.MarkChatRead(args[0], messageUUID, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.MarkChatRead: %v", err)
+
+			return ErrChatsReadFailed
+		}
+		log.Logger.Info().Msgf("chat %s was marked as read", args[0])
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// writeUnreadTable writes unread counts of chats as table rows, private chats are named after other member
+func writeUnreadTable(w io.Writer, userUUID string, chats ...*crud.ChatUnread) {
+	fmt.Fprintln(w, "UUID\tTITLE\tUNREAD\tLAST MESSAGE AT")
+	for _, c := range chats {
+		chat := c.Chat
+		if chat == nil {
+			chat = &crud.Chat{UUID: "-"}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", chat.UUID, chatTitle(chat, userUUID), c.UnreadCount, formatTimestamp(c.LastMessageAt))
+	}
+}
+
+// chatTitle returns title of a chat, or names of it's members except current user if chat has no title
+func chatTitle(chat *crud.Chat, userUUID string) string {
+	if chat.Title != "" {
+		return chat.Title
+	}
+
+	names := make([]string, 0, len(chat.Members))
+	for _, member := range chat.Members {
+		if member.UUID != userUUID {
+			names = append(names, formatUser(member))
+		}
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+
+	return strings.Join(names, ", ")
+}
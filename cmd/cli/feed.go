@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrFeedFailed used when feed request to Acroplia fails
+	ErrFeedFailed = errors.New("feed request failed")
+
+	// ErrFeedForbidden used when user isn't a member of workspace or community
+	ErrFeedForbidden = errors.New("only members can read and post to a feed of workspace or community")
+
+	// ErrFeedNotFound used when workspace or post doesn't exist
+	ErrFeedNotFound = errors.New("workspace or post not found")
+)
+
+var cmdFeed = &cobra.Command{
+	Use:   "feed",
+	Short: "Read and post to a feed of workspace or community in Acroplia",
+	Long: `Read a feed of workspace or community, publish posts, comment and react to them in Acroplia.
+
+You have to use it's subcommands: list, post, comment, comments or react.
+
+Users mentioned in text by @username are resolved to their uuids and get a notification about mention, unknown usernames are left as plain text.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia feed list {workspace_uuid} --limit 10 --format table
+  ./acroplia feed post {workspace_uuid} --text "Lesson notes are ready, @ekaterina please check" --attach notes.pdf
+  ./acroplia feed comment {post_uuid} --text "Thanks !"
+  ./acroplia feed comments {post_uuid} --format table
+  ./acroplia feed react {post_uuid} like
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var feedList = &cobra.Command{
+	Use:   "list <workspace-uuid>",
+	Short: "Use Acroplia API to list posts of a feed",
+	Long: `Use Acroplia API to list a page of posts in a feed of workspace or community, from newest to oldest.
+
+Next page is listed by setting --before to uuid of the last post of a page.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("listing feed of %s ...", args[0])
+		resp, err := crud.ListFeed(args[0], &crud.FeedQuery{Before: conf.FeedBefore, Limit: conf.FeedLimit}, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListFeed: %v", err)
+
+			return apiError(err, ErrFeedForbidden, ErrFeedNotFound, ErrFeedFailed)
+		}
+		log.Logger.Debug().Msgf("listing feed was done successfully, found %d posts", len(resp.Data))
+
+		if len(resp.Data) > 0 && len(resp.Data) == conf.FeedLimit {
+			log.Logger.Info().Msgf("next page: --before %s", resp.Data[len(resp.Data)-1].UUID)
+		}
+
+		return writeOutput(resp, func(w io.Writer) {
+			writePostTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var feedPost = &cobra.Command{
+	Use:   "post <workspace-uuid>",
+	Short: "Use Acroplia API to publish a post to a feed",
+	Long: `Use Acroplia API to publish a post with --text to a feed of workspace or community, members get a notification about new content.
+
+Files supplied by --attach are uploaded and attached to a post, --text can be empty then.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if strings.TrimSpace(conf.FeedText) == "" && len(conf.FeedAttach) == 0 {
+			return crud.ErrPostEmpty
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+		token := authResponse.Data.AccessToken
+
+		mentions, unresolved, err := crud.ResolveMentions(conf.FeedText, token)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ResolveMentions: %v", err)
+
+			return errors.Wrap(err, "resolving mentions")
+		}
+		for _, username := range unresolved {
+			log.Logger.Warn().Msgf("user @%s isn't found, it's left as plain text", username)
+		}
+
+		// upload attachments
+		attachments := make([]*crud.MediaItem, 0, len(conf.FeedAttach))
+		for _, path := range conf.FeedAttach {
+			log.Logger.Debug().Msgf("uploading %s ...", path)
+			mediaResp, err := crud.UploadMedia(path, token)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.UploadMedia: %v", err)
+
+				return errors.Wrap(ErrMessageAttachFailed, path)
+			}
+			attachments = append(attachments, mediaResp.Data)
+			log.Logger.Debug().Msgf("uploading %s was done successfully", path)
+		}
+
+		post, err := crud.NewPost(conf.FeedText, mentions, attachments, authResponse.Data.User)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("publishing post to %s with %d mentions ...", args[0], len(post.Post.Mentions))
+		resp, err := crud.PublishPost(args[0], post, token)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.PublishPost: %v", err)
+
+			return apiError(err, ErrFeedForbidden, ErrFeedNotFound, ErrFeedFailed)
+		}
+		log.Logger.Debug().Msg("publishing post was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writePostTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var feedComment = &cobra.Command{
+	Use:   "comment <post-uuid>",
+	Short: "Use Acroplia API to comment a post",
+	Long: `Use Acroplia API to add a comment with --text to a post.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if strings.TrimSpace(conf.FeedText) == "" {
+			return errors.New("comment text can't be empty, use --text flag")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+		token := authResponse.Data.AccessToken
+
+		mentions, unresolved, err := crud.ResolveMentions(conf.FeedText, token)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ResolveMentions: %v", err)
+
+			return errors.Wrap(err, "resolving mentions")
+		}
+		for _, username := range unresolved {
+			log.Logger.Warn().Msgf("user @%s isn't found, it's left as plain text", username)
+		}
+
+		comment, err := crud.NewComment(conf.FeedText, mentions, authResponse.Data.User)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("commenting post %s ...", args[0])
+		resp, err := crud.AddComment(args[0], comment, token)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.AddComment: %v", err)
+
+			return apiError(err, ErrFeedForbidden, ErrFeedNotFound, ErrFeedFailed)
+		}
+		log.Logger.Debug().Msg("commenting post was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeCommentTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var feedComments = &cobra.Command{
+	Use:   "comments <post-uuid>",
+	Short: "Use Acroplia API to list comments of a post",
+	Long: `Use Acroplia API to list comments of a post, from oldest to newest.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("listing comments of post %s ...", args[0])
+		resp, err := crud.ListComments(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListComments: %v", err)
+
+			return apiError(err, ErrFeedForbidden, ErrFeedNotFound, ErrFeedFailed)
+		}
+		log.Logger.Debug().Msgf("listing comments was done successfully, found %d comments", len(resp.Data))
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeCommentTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var feedReact = &cobra.Command{
+	Use:   "react <post-uuid> <reaction>",
+	Short: "Use Acroplia API to react to a post",
+	Long: fmt.Sprintf(`Use Acroplia API to put a reaction on a post, or to remove it with --remove.
+
+Reaction is one of: %s.
+
+You have to perform login before using this command as it needs user information from login.
+`, strings.Join(crud.Reactions, ", ")),
+	Args:    cobra.ExactArgs(2),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("reacting %s to post %s ...", args[1], args[0])
+		resp, err := crud.React(args[0], args[1], conf.FeedRemove, authResponse.Data.AccessToken)
+		if errors.Is(err, crud.ErrReactionType) {
+			return err
+		} else if err != nil {
+			log.Logger.Debug().Msgf("crud.React: %v", err)
+
+			return apiError(err, ErrFeedForbidden, ErrFeedNotFound, ErrFeedFailed)
+		}
+		log.Logger.Debug().Msg("reacting to post was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writePostTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func writePostTable(w io.Writer, posts ...*crud.LibraryItem) {
+	fmt.Fprintln(w, "UUID\tCREATED AT\tAUTHOR\tTEXT\tATTACHMENTS\tREACTIONS\tCOMMENTS")
+	for _, item := range posts {
+		post := item.Post
+		if post == nil {
+			post = &crud.Post{}
+		}
+
+		text := strings.TrimSpace(strings.ReplaceAll(post.Delta.Text(), "\n", " "))
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", item.UUID, formatTimestamp(item.CreatedAt), formatUser(item.User), text, formatAttachments(post.Attachments), formatReactions(post), post.CommentCount)
+	}
+}
+
+func writeCommentTable(w io.Writer, comments ...*crud.Comment) {
+	fmt.Fprintln(w, "UUID\tCREATED AT\tAUTHOR\tTEXT")
+	for _, c := range comments {
+		text := strings.TrimSpace(strings.ReplaceAll(c.Delta.Text(), "\n", " "))
+		if c.UpdatedAt > c.CreatedAt {
+			text += " (edited)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.UUID, formatTimestamp(c.CreatedAt), formatUser(c.User), text)
+	}
+}
+
+// formatReactions formats numbers of reactions in order of crud.Reactions, ex: LIKE 3, CLAP 1
+func formatReactions(post *crud.Post) string {
+	parts := make([]string, 0, len(post.Reactions))
+	for _, reaction := range crud.Reactions {
+		if n := post.ReactionCount(reaction); n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", reaction, n))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrLibraryFailed used when creating or listing library items in Acroplia fails
+	ErrLibraryFailed = errors.New("library request failed")
+
+	// ErrLibraryType used when user supplied library item type isn't supported
+	ErrLibraryType = errors.Errorf("library item type must be one of: %s", strings.Join(crud.CreatableLibraryTypes, ", "))
+)
+
+var cmdLibrary = &cobra.Command{
+	Use:   "library",
+	Short: "Manage library items in Acroplia",
+	Long: `Manage library items in Acroplia, like folders, collections, links and task lists.
+
+You have to use it's subcommands: create or list.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia library create --type FOLDER --title "My Folder"
+  ./acroplia library create --type LINK --title "Go" --url https://golang.org --parent {folder_uuid}
+  ./acroplia library create --type TASK_LIST --title "Homework" --task "Read chapter 1" --task "Solve exercises"
+  ./acroplia library list --type FOLDER
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var libraryCreate = &cobra.Command{
+	Use:   "create",
+	Short: "Use Acroplia API to create a library item",
+	Long: `Use Acroplia API to create a library item.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// check if user supplied library item title
+		if conf.LibraryTitle == "" {
+			return errors.New("library item title can't be empty for this command")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		// build library item based on it's type
+		itemType := strings.ToUpper(conf.LibraryType)
+		item, err := crud.NewLibraryItem(itemType, conf.LibraryTitle, conf.LibrarySubtitle, authResponse.Data.User)
+		if errors.Is(err, crud.ErrLibraryType) {
+			return ErrLibraryType
+		} else if err != nil {
+			return err
+		}
+
+		switch itemType {
+		case crud.LibraryCollection:
+			item.Collection.Items = append(item.Collection.Items, conf.LibraryItems...)
+		case crud.LibraryLink:
+			if conf.LibraryURL == "" {
+				return errors.New("url can't be empty for link")
+			}
+			item = crud.NewLink(conf.LibraryTitle, conf.LibrarySubtitle, conf.LibraryURL, authResponse.Data.User)
+		case crud.LibraryTaskList:
+			item = crud.NewTaskList(conf.LibraryTitle, conf.LibrarySubtitle, conf.LibraryTasks, authResponse.Data.User)
+		}
+
+		// nest item under parent folder
+		if conf.LibraryParent != "" {
+			log.Logger.Debug().Msgf("getting parent folder %s", conf.LibraryParent)
+			parentResp, err := crud.GetLibraryItem(conf.LibraryParent, authResponse.Data.AccessToken)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.GetLibraryItem: %v", err)
+
+				return errors.New("couldn't get parent folder")
+			}
+
+			err = item.SetParent(parentResp.Data)
+			if err != nil {
+				return err
+			}
+		}
+
+		// create an actual library item
+		log.Logger.Debug().Msgf("creating a %s with title %s", itemType, conf.LibraryTitle)
+		resp, err := item.Create(authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("item.Create: %v", err)
+
+			return ErrLibraryFailed
+		}
+		log.Logger.Debug().Msgf("creating a %s was done successfully", itemType)
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeLibraryTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var libraryList = &cobra.Command{
+	Use:   "list",
+	Short: "Use Acroplia API to list library items",
+	Long: `Use Acroplia API to list library items, optionally filtered by type and parent folder.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		filter := &crud.LibraryFilter{
+			Type:   strings.ToUpper(conf.LibraryType),
+			Parent: conf.LibraryParent,
+		}
+
+		log.Logger.Debug().Msg("listing library items ...")
+		resp, err := crud.ListLibrary(authResponse.Data.User.UUID, authResponse.Data.AccessToken, filter)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListLibrary: %v", err)
+
+			return ErrLibraryFailed
+		}
+		log.Logger.Debug().Msg("listing library items was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeLibraryTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// writeLibraryTable writes library items as table rows
+func writeLibraryTable(w io.Writer, items ...*crud.LibraryItem) {
+	fmt.Fprintln(w, "UUID\tTYPE\tTITLE\tPATH")
+	for _, item := range items {
+		path := make([]string, 0, len(item.Path))
+		for _, p := range item.Path {
+			path = append(path, p.Title)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t/%s\n", item.UUID, item.Type, item.Title, strings.Join(path, "/"))
+	}
+}
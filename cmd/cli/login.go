@@ -27,6 +27,9 @@ var (
 
 	// ErrLoginPasswordRequired used when password is not supplied by user when performing login
 	ErrLoginPasswordRequired = errors.New("password is required for login by email")
+
+	// ErrLoginExpired used when token from login is rejected by API
+	ErrLoginExpired = errors.New("session expired, run login again")
 )
 
 // cmdLogin is a command to make a login request to Acroplia using API
@@ -238,3 +241,30 @@ func performLoginWeb(wd selenium.WebDriver) error {
 
 	return nil
 }
+
+// readLoginResponse decodes login response, that was saved by login api command
+func readLoginResponse() (*crud.ResponseAuthResponse, error) {
+	// open login response file for decoding it
+	log.Logger.Debug().Msgf("opening response file: %v ...", loginRespFilename)
+	f, err := os.Open(loginRespFilename)
+	if err != nil {
+		log.Logger.Debug().Msgf("os.Open: %v", err)
+
+		return nil, errors.New("couldn't open login response file, make sure you have performed login")
+	}
+	defer f.Close()
+	log.Logger.Debug().Msgf("opening response file was done successfully")
+
+	// decode contents of login response into struct
+	log.Logger.Debug().Msg("decoding response file into struct")
+	authResponse := &crud.ResponseAuthResponse{}
+	err = json.NewDecoder(f).Decode(authResponse)
+	if err != nil {
+		log.Logger.Debug().Msgf("json.Decode: %v", err)
+
+		return nil, errors.New("couldn't decode login response to struct")
+	}
+	log.Logger.Debug().Msg("decoding response file was done successfully")
+
+	return authResponse, nil
+}
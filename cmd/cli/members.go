@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrMembersFailed used when members request to Acroplia fails
+	ErrMembersFailed = errors.New("members request failed")
+
+	// ErrMembersForbidden used when user isn't admin of a workspace
+	ErrMembersForbidden = errors.New("only admins can manage members of a workspace")
+
+	// ErrMembersNotFound used when workspace or it's member doesn't exist
+	ErrMembersNotFound = errors.New("workspace or member not found")
+)
+
+var cmdMembers = &cobra.Command{
+	Use:   "members",
+	Short: "Manage members of workspaces and communities in Acroplia",
+	Long: `Manage members of workspaces and communities and their roles in Acroplia.
+
+You have to use it's subcommands: list, invite, remove, grant or revoke.
+
+Roles that can be granted: ` + strings.Join(crud.GrantableRoles, ", ") + `
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia members list {workspace_uuid} --format table
+  ./acroplia members invite {workspace_uuid} student@example.com @ekaterina
+  ./acroplia members invite {workspace_uuid} @ekaterina --role WORKSPACE_ADMIN
+  ./acroplia members remove {workspace_uuid} {user_uuid}
+  ./acroplia members grant {workspace_uuid} {user_uuid} --role MODERATOR
+  ./acroplia members revoke {workspace_uuid} {user_uuid} --role MODERATOR
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var membersList = &cobra.Command{
+	Use:   "list <workspace-uuid>",
+	Short: "Use Acroplia API to list members of a workspace or community",
+	Long: `Use Acroplia API to list members of a workspace or community with their roles.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("listing members of workspace %s ...", args[0])
+		resp, err := crud.ListMembers(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListMembers: %v", err)
+
+			return apiError(err, ErrMembersForbidden, ErrMembersNotFound, ErrMembersFailed)
+		}
+		log.Logger.Debug().Msgf("listing members was done successfully, found %d members", len(resp.Data))
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeMemberTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var membersInvite = &cobra.Command{
+	Use:   "invite <workspace-uuid> <email|username>...",
+	Short: "Use Acroplia API to invite users to a workspace or community",
+	Long: `Use Acroplia API to invite users by email or username to a workspace or community, only admins can invite.
+
+Invited users get member role, unless --role is set.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.MinimumNArgs(2),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		invitations := make([]*crud.Invitation, 0, len(args)-1)
+		for _, user := range args[1:] {
+			invitation, err := crud.NewInvitation(user, strings.ToUpper(conf.MemberRole))
+			if err != nil {
+				return err
+			}
+			invitations = append(invitations, invitation)
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		members := make([]*crud.Member, 0, len(invitations))
+		for i, invitation := range invitations {
+			log.Logger.Debug().Msgf("inviting %s to workspace %s ...", args[i+1], args[0])
+			resp, err := crud.InviteMember(args[0], invitation, authResponse.Data.AccessToken)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.InviteMember: %v", err)
+
+				return apiError(err, ErrMembersForbidden, ErrMembersNotFound, ErrMembersFailed)
+			}
+			members = append(members, resp.Data)
+		}
+		log.Logger.Debug().Msgf("inviting %d users was done successfully", len(members))
+
+		return writeOutput(members, func(w io.Writer) {
+			writeMemberTable(w, members...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var membersRemove = &cobra.Command{
+	Use:   "remove <workspace-uuid> <user-uuid>",
+	Short: "Use Acroplia API to remove a member from a workspace or community",
+	Long: `Use Acroplia API to remove a member from a workspace or community, only admins can remove members.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(2),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("removing member %s from workspace %s ...", args[1], args[0])
+		err = crud.RemoveMember(args[0], args[1], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.RemoveMember: %v", err)
+
+			return apiError(err, ErrMembersForbidden, ErrMembersNotFound, ErrMembersFailed)
+		}
+		log.Logger.Info().Msgf("member %s was removed from workspace %s", args[1], args[0])
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var membersGrant = &cobra.Command{
+	Use:   "grant <workspace-uuid> <user-uuid>",
+	Short: "Use Acroplia API to grant a role to a member",
+	Long: `Use Acroplia API to grant a --role to a member of a workspace or community, only admins can grant roles.
+
+Roles that can be granted: ` + strings.Join(crud.GrantableRoles, ", ") + `
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(2),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeRole(args[0], args[1], crud.GrantRole)
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var membersRevoke = &cobra.Command{
+	Use:   "revoke <workspace-uuid> <user-uuid>",
+	Short: "Use Acroplia API to revoke a role from a member",
+	Long: `Use Acroplia API to revoke a --role from a member of a workspace or community, only admins can revoke roles.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(2),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeRole(args[0], args[1], crud.RevokeRole)
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// changeRole validates role from flags and grants or revokes it by change, then writes changed member
+func changeRole(workspaceUUID, userUUID string, change func(workspaceUUID, userUUID, roleType, token string) (*crud.ResponseMember, error)) error {
+	role := strings.ToUpper(conf.MemberRole)
+	if role == "" {
+		return errors.New("role can't be empty for this command")
+	}
+	if err := crud.ValidateRole(role); err != nil {
+		return err
+	}
+
+	authResponse, err := readLoginResponse()
+	if err != nil {
+		return err
+	}
+
+	log.Logger.Debug().Msgf("changing role %s of member %s in workspace %s ...", role, userUUID, workspaceUUID)
+	resp, err := change(workspaceUUID, userUUID, role, authResponse.Data.AccessToken)
+	if err != nil {
+		log.Logger.Debug().Msgf("changing role: %v", err)
+
+		return apiError(err, ErrMembersForbidden, ErrMembersNotFound, ErrMembersFailed)
+	}
+	log.Logger.Debug().Msg("changing role was done successfully")
+
+	return writeOutput(resp, func(w io.Writer) {
+		writeMemberTable(w, resp.Data)
+	})
+}
+
+// writeMemberTable writes members with their roles as table rows
+func writeMemberTable(w io.Writer, members ...*crud.Member) {
+	fmt.Fprintln(w, "UUID\tUSER\tROLES\tJOINED AT")
+	for _, m := range members {
+		uuid := "-"
+		if m.User != nil {
+			uuid = m.User.UUID
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", uuid, formatUser(m.User), strings.Join(m.RoleTypes(), ","), formatTimestamp(m.JoinedAt))
+	}
+}
@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/bejaneps/acroplia/internal/crud"
 	"github.com/bejaneps/acroplia/internal/services"
@@ -19,6 +25,48 @@ var (
 
 	// ErrMessageReceiver used when user didn't supply fullname and username
 	ErrMessageReceiver = errors.New("fullname and username of receiver can't be empty")
+
+	// ErrMessageText used when user didn't supply text of reply, edited or scheduled message, as default text would be sent instead
+	ErrMessageText = errors.New("text can't be empty, use --text flag")
+
+	// ErrMessageChat used when user didn't supply chat uuid, fullname or username
+	ErrMessageChat = errors.New("chat uuid, fullname and username can't be empty")
+
+	// ErrMessageReceiverAmbiguous used when several users match supplied fullname
+	ErrMessageReceiverAmbiguous = errors.New("several users match fullname, use username instead")
+
+	// ErrMessageAttachFailed used when uploading an attachment fails
+	ErrMessageAttachFailed = errors.New("uploading attachment failed")
+
+	// ErrMessageTailFailed used when watching a chat fails
+	ErrMessageTailFailed = errors.New("watching chat failed")
+
+	// ErrMessageChatForbidden used when user isn't a member of a chat
+	ErrMessageChatForbidden = errors.New("only members of a chat can watch it")
+
+	// ErrMessageChatNotFound used when chat doesn't exist
+	ErrMessageChatNotFound = errors.New("chat not found")
+
+	// ErrMessageExportFailed used when writing exported messages fails
+	ErrMessageExportFailed = errors.New("exporting messages failed")
+
+	// ErrMessageForbidden used when user tries to edit or delete message of another user
+	ErrMessageForbidden = errors.New("you can only edit or delete your own messages")
+
+	// ErrMessageNotFound used when message doesn't exist in a chat
+	ErrMessageNotFound = errors.New("message not found")
+
+	// ErrMessageEditFailed used when editing a message fails
+	ErrMessageEditFailed = errors.New("editing message failed")
+
+	// ErrMessageDeleteFailed used when deleting a message fails
+	ErrMessageDeleteFailed = errors.New("deleting message failed")
+
+	// ErrMessageReceiverFailed used when searching receiver or opening a chat with it fails
+	ErrMessageReceiverFailed = errors.New("finding chat with receiver failed")
+
+	// ErrMessageListFailed used when listing messages of a chat fails
+	ErrMessageListFailed = errors.New("listing messages failed")
 )
 
 var cmdMessage = &cobra.Command{
@@ -26,11 +74,20 @@ var cmdMessage = &cobra.Command{
 	Short: "Message a user in Acroplia",
 	Long: `Message a user in Acroplia.
 
-You have to use it's subcommands: api or web to send actual message.
+You have to use it's subcommands: api or web to send actual message, or list to read messages of a chat.
 
 Example: 
 	./acroplia message api
 	./acroplia message web
+	./acroplia message api --username ekaterina --text "Hello"
+	./acroplia message list --chat-uuid {uuid} --since 24h
+	./acroplia message reply {message-uuid} --chat-uuid {uuid} --text "Thanks"
+	./acroplia message edit {message-uuid} --chat-uuid {uuid} --text "Fixed typo"
+	./acroplia message delete {message-uuid} --chat-uuid {uuid}
+	./acroplia message tail --chat-uuid {uuid} --format table
+	./acroplia message broadcast --recipients students.csv --template reminder.tmpl --dry-run --format table
+	./acroplia message schedule --username ekaterina --text "Lesson starts now" --at 2020-11-01T09:00
+	./acroplia message export --chat-uuid {uuid} --format html --since 2020-07-01 --output chat.html --attachments-dir chat_files
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.Help()
@@ -112,39 +169,54 @@ var messageAPI = &cobra.Command{
 	Short: "Use Acroplia API to send a message",
 	Long: `User Acroplia API to send a message.
 
+Receiver is found by --username or --fullname and a private chat with it is opened, unless --chat-uuid is supplied.
+
+Files supplied by --attach are uploaded and sent as a media message, --text is used as it's caption, without --text they are sent without caption.
+
 You have to perform login before using this command as it needs user information from login.
 `,
 	PreRunE: buildPreRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if conf.MessageFullname == "" && conf.MessageUsername == "" {
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
 			return ErrMessageReceiver
 		}
 
-		// open login response file for decoding it
-		log.Logger.Debug().Msgf("opening response file: %v ...", loginRespFilename)
-		f, err := os.Open(loginRespFilename)
+		authResponse, err := readLoginResponse()
 		if err != nil {
-			log.Logger.Debug().Msgf("os.Open: %v", err)
-
-			return errors.New("couldn't open login response file, make sure you have performed login")
+			return err
 		}
-		log.Logger.Debug().Msgf("opening response file was done successfully")
 
-		// decode contents of login response into struct
-		log.Logger.Debug().Msg("decoding response file into struct")
-		authResponse := &crud.ResponseAuthResponse{}
-		err = json.NewDecoder(f).Decode(authResponse)
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
 		if err != nil {
-			log.Logger.Debug().Msgf("json.Decode: %v", err)
+			return err
+		}
 
-			return errors.New("couldn't decode login response to struct")
+		// upload attachments
+		attachments := make([]*crud.MediaItem, 0, len(conf.MessageAttach))
+		for _, path := range conf.MessageAttach {
+			log.Logger.Debug().Msgf("uploading %s ...", path)
+			mediaResp, err := crud.UploadMedia(path, authResponse.Data.AccessToken)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.UploadMedia: %v", err)
+
+				return errors.Wrap(ErrMessageAttachFailed, path)
+			}
+			attachments = append(attachments, mediaResp.Data)
+			log.Logger.Debug().Msgf("uploading %s was done successfully", path)
 		}
-		log.Logger.Debug().Msg("decoding response file was done successfully")
 
 		// sending a message
 		log.Logger.Debug().Msg("sending message ...")
 		msg := crud.NewMessage(conf.MessageText, authResponse.Data.User)
-		resp, err := msg.SendMessage(conf.MessageChatUUID, authResponse.Data.AccessToken)
+		if len(attachments) > 0 {
+			// default text isn't used as caption of attachments
+			caption := ""
+			if cmd.Flags().Changed("text") {
+				caption = conf.MessageText
+			}
+			msg = crud.NewMediaMessage(caption, attachments, authResponse.Data.User)
+		}
+		resp, err := msg.SendMessage(chatUUID, authResponse.Data.AccessToken)
 		if errors.Is(err, crud.ErrMessageInternal) {
 			return crud.ErrMessageInternal
 		} else if err != nil {
@@ -154,19 +226,686 @@ You have to perform login before using this command as it needs user information
 		}
 		log.Logger.Debug().Msg("sending message was done successfully")
 
-		// store response message to specified output file
-		log.Logger.Info().Msgf("writing response message to: %s\n", conf.PathToOutputFile)
+		return writeOutput(resp, func(w io.Writer) {
+			writeMessageTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var messageList = &cobra.Command{
+	Use:   "list",
+	Short: "Use Acroplia API to list messages of a chat",
+	Long: `Use Acroplia API to list messages of a chat, from oldest to newest.
+
+Messages are fetched page by page, until --since or --limit is reached.
+--since accepts either a duration, ex: 24h, or a date, ex: 2020-07-01 or 2020-07-01T09:00:00Z.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
+			return ErrMessageChat
+		}
+
+		since, err := parseTime(conf.MessageSince)
+		if err != nil {
+			return err
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("listing messages of chat %s ...", chatUUID)
+		it := crud.NewMessageIterator(chatUUID, authResponse.Data.AccessToken)
+		it.Since = since
+
+		messages := make([]*crud.UserMessageEntity, 0)
+		for it.Next() {
+			messages = append(messages, it.Message())
+			if conf.MessageLimit > 0 && len(messages) >= conf.MessageLimit {
+				break
+			}
+		}
+		if err := it.Err(); err != nil {
+			log.Logger.Debug().Msgf("it.Next: %v", err)
+
+			return ErrMessageListFailed
+		}
+		log.Logger.Debug().Msgf("listing messages was done successfully, found %d messages", len(messages))
+
+		// show messages from oldest to newest
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+
+		return writeOutput(messages, func(w io.Writer) {
+			writeMessageTable(w, messages...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var messageReply = &cobra.Command{
+	Use:   "reply <message-uuid>",
+	Short: "Use Acroplia API to reply to a message",
+	Long: `Use Acroplia API to reply to a message of a chat with --text.
+
+Chat is either supplied by --chat-uuid or is a private chat with a user found by --username or --fullname.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("text") || strings.TrimSpace(conf.MessageText) == "" {
+			return ErrMessageText
+		}
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
+			return ErrMessageChat
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("replying to message %s ...", args[0])
+		msg := crud.NewReply(conf.MessageText, args[0], authResponse.Data.User)
+		resp, err := msg.SendMessage(chatUUID, authResponse.Data.AccessToken)
+		if errors.Is(err, crud.ErrMessageInternal) {
+			return crud.ErrMessageInternal
+		} else if err != nil {
+			log.Logger.Debug().Msgf("crud.SendMessage: %v", err)
+
+			return ErrMessageFailed
+		}
+		log.Logger.Debug().Msg("replying to message was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeMessageTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var messageEdit = &cobra.Command{
+	Use:   "edit <message-uuid>",
+	Short: "Use Acroplia API to edit a message",
+	Long: `Use Acroplia API to replace text of a message with --text, only your own messages can be edited.
+
+Chat is either supplied by --chat-uuid or is a private chat with a user found by --username or --fullname.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("text") || strings.TrimSpace(conf.MessageText) == "" {
+			return ErrMessageText
+		}
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
+			return ErrMessageChat
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("editing message %s ...", args[0])
+		resp, err := crud.EditMessage(chatUUID, args[0], conf.MessageText, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.EditMessage: %v", err)
+
+			return apiError(err, ErrMessageForbidden, ErrMessageNotFound, ErrMessageEditFailed)
+		}
+		log.Logger.Debug().Msg("editing message was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeMessageTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var messageDelete = &cobra.Command{
+	Use:   "delete <message-uuid>",
+	Short: "Use Acroplia API to delete a message",
+	Long: `Use Acroplia API to delete a message, only your own messages can be deleted.
+
+Chat is either supplied by --chat-uuid or is a private chat with a user found by --username or --fullname.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
+			return ErrMessageChat
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("deleting message %s ...", args[0])
+		err = crud.DeleteMessage(chatUUID, args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.DeleteMessage: %v", err)
+
+			return apiError(err, ErrMessageForbidden, ErrMessageNotFound, ErrMessageDeleteFailed)
+		}
+		log.Logger.Info().Msgf("message %s was deleted", args[0])
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var messageTail = &cobra.Command{
+	Use:   "tail",
+	Short: "Use Acroplia API to watch messages of a chat live",
+	Long: `Use Acroplia API to watch messages of a chat live, like tail -f.
+
+New, edited and deleted messages are printed as soon as they happen, in json format each event is printed as a separate json object.
+Connection is restored automatically after failures, without losing or repeating events.
+Pass --cursor of last printed event to continue watching from where previous run stopped.
+
+Chat is either supplied by --chat-uuid or is a private chat with a user found by --username or --fullname.
+
+Press Ctrl + C to stop watching.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
+			return ErrMessageChat
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
 		enc := json.NewEncoder(outputFile)
-		enc.SetIndent("", "  ")
-		err = enc.Encode(resp)
+		stream := crud.NewMessageStream(chatUUID, authResponse.Data.AccessToken, func(event *crud.MessageEvent) {
+			if conf.OutputFormat == formatTable {
+				// deleted message may come without body
+				msg := event.Message
+				if msg == nil {
+					msg = &crud.UserMessageEntity{}
+				}
+				fmt.Fprintf(outputFile, "%d\t%s\t%s\t%s\t%s\n", event.Cursor, formatTimestamp(msg.CreatedAt), event.Type, formatUser(msg.User), strings.ReplaceAll(msg.Text, "\n", " "))
+				return
+			}
+
+			if err := enc.Encode(event); err != nil {
+				log.Logger.Warn().Msgf("couldn't encode event: %v", err)
+			}
+		})
+		stream.Cursor = conf.MessageCursor
+
+		// stop watching on Ctrl + C
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			<-quit
+			log.Logger.Debug().Msg("received SIGINT(Ctrl + C) signal, stopping watching...")
+
+			cancel()
+		}()
+
+		log.Logger.Info().Msgf("watching chat %s, writing messages to: %s", chatUUID, conf.PathToOutputFile)
+		err = stream.Run(ctx)
+		log.Logger.Info().Msgf("stopped watching at cursor %d", stream.Cursor)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			log.Logger.Debug().Msgf("stream.Run: %v", err)
+
+			return apiError(err, ErrMessageChatForbidden, ErrMessageChatNotFound, ErrMessageTailFailed)
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var messageExport = &cobra.Command{
+	Use:   "export",
+	Short: "Use Acroplia API to export messages of a chat",
+	Long: `Use Acroplia API to export messages of a chat for archiving, from oldest to newest.
+
+Export is written to --output in --format: jsonl (a json object per line, json is the same), csv or html (transcript for reading).
+Each message has it's author, timestamps, replied message and attachment links.
+If --attachments-dir is supplied, then attachments are downloaded into it and export links to downloaded files.
+--since and --until accept either a duration, ex: 24h, or a date, ex: 2020-07-01 or 2020-07-01T09:00:00Z.
+
+Chat is either supplied by --chat-uuid or is a private chat with a user found by --username or --fullname.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Annotations: map[string]string{
+		annotationFormats: strings.Join([]string{formatJSON, crud.ExportJSONL, crud.ExportCSV, crud.ExportHTML}, ","),
+	},
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
+			return ErrMessageChat
+		}
+
+		// json export is written as json lines, so it can be streamed
+		format := conf.OutputFormat
+		if format == formatJSON {
+			format = crud.ExportJSONL
+		}
+
+		since, err := parseTime(conf.MessageSince)
+		if err != nil {
+			return err
+		}
+		until, err := parseTime(conf.MessageUntil)
+		if err != nil {
+			return err
+		}
+
+		authResponse, err := readLoginResponse()
 		if err != nil {
-			log.Logger.Debug().Msgf("json.NewEncoder: %v", err)
+			return err
+		}
 
-			return errors.New("couldn't encode response")
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
+		if err != nil {
+			return err
 		}
 
+		if conf.MessageExportDir != "" {
+			if err := os.MkdirAll(conf.MessageExportDir, 0755); err != nil {
+				log.Logger.Debug().Msgf("os.MkdirAll: %v", err)
+
+				return errors.New("couldn't create attachments directory")
+			}
+		}
+
+		ew, err := crud.NewMessageExportWriter(outputFile, format, fmt.Sprintf("Chat %s", chatUUID))
+		if err != nil {
+			return err
+		}
+
+		// history is iterated from newest to oldest, but exported from oldest to newest
+		log.Logger.Debug().Msgf("listing messages of chat %s ...", chatUUID)
+		it := crud.NewMessageIterator(chatUUID, authResponse.Data.AccessToken)
+		it.Since = since
+		it.Until = until
+
+		messages := make([]*crud.UserMessageEntity, 0)
+		for it.Next() {
+			messages = append(messages, it.Message())
+		}
+		if err := it.Err(); err != nil {
+			log.Logger.Debug().Msgf("it.Next: %v", err)
+
+			return ErrMessageListFailed
+		}
+		log.Logger.Debug().Msgf("listing messages was done successfully, found %d messages", len(messages))
+
+		log.Logger.Info().Msgf("exporting %d messages to: %s", len(messages), conf.PathToOutputFile)
+		for i := len(messages) - 1; i >= 0; i-- {
+			msg := crud.NewExportMessage(messages[i])
+
+			if conf.MessageExportDir != "" {
+				for _, a := range msg.Attachments {
+					log.Logger.Debug().Msgf("downloading attachment %s ...", a.UUID)
+					if err := a.Download(conf.MessageExportDir, authResponse.Data.AccessToken); err != nil {
+						// export still links to original url
+						log.Logger.Warn().Msgf("couldn't download attachment %s of message %s: %v", a.UUID, msg.UUID, err)
+					}
+				}
+			}
+
+			if err := ew.Write(msg); err != nil {
+				log.Logger.Debug().Msgf("ew.Write: %v", err)
+
+				return ErrMessageExportFailed
+			}
+		}
+
+		if err := ew.Close(); err != nil {
+			log.Logger.Debug().Msgf("ew.Close: %v", err)
+
+			return ErrMessageExportFailed
+		}
+		log.Logger.Info().Msg("exporting messages was done successfully")
+
 		return nil
 	},
 	SilenceUsage:  true,
 	SilenceErrors: true,
 }
+
+var messageBroadcast = &cobra.Command{
+	Use:   "broadcast",
+	Short: "Use Acroplia API to send a templated message to many recipients",
+	Long: `Use Acroplia API to send a message rendered from Go text/template to each recipient of csv file.
+
+Recipients file has a header, each recipient is identified by chat_uuid, username or fullname column.
+All columns are available in template, ex: Hello {{ .first_name }}, your grade is {{ .grade }}.
+
+Messages are sent with --concurrency at the same time, but not more often than once per --interval.
+Delivery report with status and message uuid of each recipient is written to --report (by default: recipients name followed by .report.csv).
+With --dry-run messages are rendered and recipients are found, but nothing is sent, rendered messages are written to --output.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.MessageRecipients == "" || conf.MessageTemplate == "" {
+			return errors.New("--recipients and --template are required for this command")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("reading recipients %s ...", conf.MessageRecipients)
+		recipients, err := crud.ReadBroadcastRecipients(conf.MessageRecipients)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ReadBroadcastRecipients: %v", err)
+
+			return errors.New("couldn't read recipients")
+		}
+		log.Logger.Debug().Msgf("reading recipients was done successfully, found %d recipients", len(recipients))
+
+		tmpl, err := crud.ParseBroadcastTemplate(conf.MessageTemplate)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ParseBroadcastTemplate: %v", err)
+
+			return errors.New("couldn't parse template")
+		}
+
+		broadcast := crud.NewBroadcast(authResponse.Data.User, authResponse.Data.AccessToken, tmpl, conf.MessageBroadcastConcurrency)
+		broadcast.Interval = conf.MessageBroadcastInterval
+		broadcast.DryRun = conf.MessageDryRun
+
+		// dry run doesn't send anything, so there is nothing to report
+		var rw *crud.BroadcastReportWriter
+		if !broadcast.DryRun {
+			reportPath := conf.MessageReport
+			if reportPath == "" {
+				reportPath = conf.MessageRecipients + ".report.csv"
+			}
+
+			rw, err = crud.NewBroadcastReportWriter(reportPath)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.NewBroadcastReportWriter: %v", err)
+
+				return errors.New("couldn't create report file")
+			}
+			defer rw.Close()
+			log.Logger.Info().Msgf("writing delivery report to: %s", reportPath)
+		}
+
+		broadcast.OnResult = func(result *crud.BroadcastResult) {
+			if result.Error != "" {
+				log.Logger.Warn().Msgf("row %d (%s): %s", result.Row, result.Recipient, result.Error)
+			} else {
+				log.Logger.Info().Msgf("row %d (%s): %s %s", result.Row, result.Recipient, result.Status, result.MessageUUID)
+			}
+
+			if rw != nil {
+				if err := rw.Write(result); err != nil {
+					log.Logger.Warn().Msgf("couldn't write result of row %d: %v", result.Row, err)
+				}
+			}
+		}
+
+		log.Logger.Debug().Msgf("sending messages with concurrency %d ...", broadcast.Concurrency)
+		results := broadcast.Send(recipients)
+
+		var failed int
+		for _, result := range results {
+			if result.Status == crud.BroadcastFailed {
+				failed++
+			}
+		}
+		log.Logger.Info().Msgf("broadcast finished: %d succeeded, %d failed", len(results)-failed, failed)
+
+		if broadcast.DryRun {
+			err := writeOutput(results, func(w io.Writer) {
+				fmt.Fprintln(w, "ROW\tRECIPIENT\tSTATUS\tTEXT")
+				for _, result := range results {
+					text := result.Text
+					if result.Error != "" {
+						text = result.Error
+					}
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", result.Row, result.Recipient, result.Status, strings.ReplaceAll(text, "\n", " "))
+				}
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if failed > 0 {
+			return errors.Errorf("failed to send messages to %d recipients", failed)
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var messageSchedule = &cobra.Command{
+	Use:   "schedule",
+	Short: "Schedule a message to be sent later",
+	Long: `Schedule a message to be sent at --at time or after --in delay, message is stored in a local queue.
+
+Scheduled messages are sent by scheduler run command, which has to be running at due time.
+--at accepts a date, ex: 2020-11-01T09:00 or 2020-11-01T09:00:00+03:00, --in accepts a duration, ex: 2h30m.
+
+Chat is either supplied by --chat-uuid or is a private chat with a user found by --username or --fullname, it's resolved now.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.MessageChatUUID == "" && conf.MessageFullname == "" && conf.MessageUsername == "" {
+			return ErrMessageReceiver
+		}
+		if !cmd.Flags().Changed("text") || strings.TrimSpace(conf.MessageText) == "" {
+			return ErrMessageText
+		}
+
+		var at time.Time
+		if conf.MessageAt != "" {
+			t, err := parseTime(conf.MessageAt)
+			if err != nil {
+				return err
+			}
+			at = t
+		} else if conf.MessageIn > 0 {
+			at = time.Now().Add(conf.MessageIn)
+		} else {
+			return errors.New("either --at or --in is required for this command")
+		}
+		if at.Before(time.Now()) {
+			return errors.Errorf("due time %s is in the past", at.Format(time.RFC3339))
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		chatUUID, err := resolveChat(authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		msg := crud.NewScheduledMessage(chatUUID, conf.MessageText, at)
+		log.Logger.Debug().Msgf("adding message %s to queue %s ...", msg.ID, conf.SchedulerQueue)
+		if err := crud.NewMessageQueue(conf.SchedulerQueue).Add(msg); err != nil {
+			log.Logger.Debug().Msgf("queue.Add: %v", err)
+
+			return ErrSchedulerFailed
+		}
+		log.Logger.Info().Msgf("message %s is scheduled at %s, make sure scheduler is running", msg.ID, at.Format(time.RFC3339))
+
+		return writeOutput(msg, func(w io.Writer) {
+			writeScheduleTable(w, msg)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// resolveChat returns uuid of a chat to use, it's either supplied by user or a private chat with a user found by username or full name.
+//
+// If several users share full name, then they are printed, so user can pick one by username.
+func resolveChat(token string) (string, error) {
+	if conf.MessageChatUUID != "" {
+		return conf.MessageChatUUID, nil
+	}
+
+	var user *crud.PublicUser
+	var err error
+	if conf.MessageUsername != "" {
+		log.Logger.Debug().Msgf("searching user by username %s ...", conf.MessageUsername)
+		user, err = crud.FindUserByUsername(conf.MessageUsername, token)
+	} else {
+		log.Logger.Debug().Msgf("searching user by full name %s ...", conf.MessageFullname)
+		user, err = crud.FindUserByFullname(conf.MessageFullname, token)
+	}
+
+	ambiguous := &crud.AmbiguousUserError{}
+	if errors.As(err, &ambiguous) {
+		fmt.Fprintf(os.Stderr, "several users are named %s, pick one with --username:\n", ambiguous.Fullname)
+		tw := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+		writeUserTable(tw, ambiguous.Users...)
+		tw.Flush()
+
+		return "", ErrMessageReceiverAmbiguous
+	} else if errors.Is(err, crud.ErrUserNotFound) {
+		return "", crud.ErrUserNotFound
+	} else if err != nil {
+		log.Logger.Debug().Msgf("crud.FindUser: %v", err)
+
+		return "", ErrMessageReceiverFailed
+	}
+	log.Logger.Debug().Msgf("found user %s", user.UUID)
+
+	log.Logger.Debug().Msg("opening private chat ...")
+	chat, err := crud.OpenDirectChat(user.UUID, token)
+	if err != nil {
+		log.Logger.Debug().Msgf("crud.OpenDirectChat: %v", err)
+
+		return "", ErrMessageReceiverFailed
+	}
+	log.Logger.Debug().Msgf("opening private chat %s was done successfully", chat.UUID)
+
+	return chat.UUID, nil
+}
+
+// writeUserTable writes users as table rows
+func writeUserTable(w io.Writer, users ...*crud.PublicUser) {
+	fmt.Fprintln(w, "UUID\tUSERNAME\tNAME\tONLINE\tTUTOR\tGUEST")
+	for _, user := range users {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%t\t%t\n", user.UUID, user.UserName, user.FullName(), user.Online, user.Tutor, user.Guest)
+	}
+}
+
+// writeMessageTable writes messages as table rows
+func writeMessageTable(w io.Writer, messages ...*crud.UserMessageEntity) {
+	fmt.Fprintln(w, "UUID\tCREATED AT\tAUTHOR\tTYPE\tREPLY TO\tTEXT\tATTACHMENTS")
+	for _, msg := range messages {
+		text := strings.ReplaceAll(msg.Text, "\n", " ")
+		if msg.UpdatedAt > msg.CreatedAt {
+			text += " (edited)"
+		}
+
+		replyTo := msg.ReplyToMessage
+		if replyTo == "" {
+			replyTo = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", msg.UUID, formatTimestamp(msg.CreatedAt), formatUser(msg.User), msg.Type, replyTo, text, formatAttachments(msg.Attachments))
+	}
+}
+
+// formatAttachments formats attachments as their names, types and sizes, ex: lesson.pdf (DOCUMENT, 1.5 MB)
+func formatAttachments(attachments []*crud.MediaItem) string {
+	if len(attachments) == 0 {
+		return "-"
+	}
+
+	names := make([]string, 0, len(attachments))
+	for _, item := range attachments {
+		name := item.FileName()
+		if name == "" {
+			name = item.UUID
+		}
+		names = append(names, fmt.Sprintf("%s (%s, %s)", name, item.Type, formatSize(item.FileSize())))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// parseTime parses either a duration before now, ex: 24h, or a date in RFC3339 or 2006-01-02 formats. Empty string is parsed as zero time.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("couldn't parse time %s, use either a duration, ex: 24h, or a date, ex: 2020-07-01", value)
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// supported output formats
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+	formatCSV   = "csv" // only for commands, that declare it in annotationFormats
+)
+
+// annotationFormats is a command annotation with comma separated output formats, that command supports instead of json and table
+const annotationFormats = "formats"
+
+var (
+	// ErrOutputFormat used when user supplied output format isn't supported
+	ErrOutputFormat = errors.New("output format must be either json or table")
+)
+
+// writeOutput writes v to output file in format chosen by user.
+//
+// table renders v as rows of tab separated columns, if it's nil then v is always written as json.
+func writeOutput(v interface{}, table func(w io.Writer)) error {
+	log.Logger.Info().Msgf("writing response message to: %s\n", conf.PathToOutputFile)
+
+	if conf.OutputFormat == formatTable && table != nil {
+		tw := tabwriter.NewWriter(outputFile, 0, 4, 2, ' ', 0)
+		table(tw)
+
+		err := tw.Flush()
+		if err != nil {
+			log.Logger.Debug().Msgf("tabwriter.Flush: %v", err)
+
+			return errors.New("couldn't write response")
+		}
+
+		return nil
+	}
+
+	enc := json.NewEncoder(outputFile)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(v)
+	if err != nil {
+		log.Logger.Debug().Msgf("json.NewEncoder: %v", err)
+
+		return errors.New("couldn't encode response")
+	}
+
+	return nil
+}
+
+// formatTimestamp formats unix timestamp in milliseconds, that is used by Acroplia API
+func formatTimestamp(ms int) string {
+	if ms == 0 {
+		return "-"
+	}
+
+	return time.Unix(0, int64(ms)*int64(time.Millisecond)).Format(time.RFC3339)
+}
+
+// formatUser formats user as it's full name followed by username
+func formatUser(user *crud.PublicUser) string {
+	if user == nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%s %s (@%s)", user.FirstName, user.LastName, user.UserName)
+}
+
+// formatSize formats size in bytes in human readable units, ex: 1.5 MB
+func formatSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+func stringInSlice(s string, slice []string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apiError converts permission and not found errors of API to errors of a command, other errors are replaced by failed.
+// Expired token is reported as ErrLoginExpired
+func apiError(err, forbidden, notFound, failed error) error {
+	if errors.Is(err, crud.ErrUnauthorized) {
+		return ErrLoginExpired
+	} else if errors.Is(err, crud.ErrForbidden) {
+		return forbidden
+	} else if errors.Is(err, crud.ErrNotFound) {
+		return notFound
+	}
+
+	return failed
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// hookTimeout is a maximum time of running a hook command
+const hookTimeout = 30 * time.Second
+
+var (
+	// ErrPresenceUsers used when user didn't supply users to watch
+	ErrPresenceUsers = errors.New("users to watch can't be empty, use --users flag")
+
+	// ErrPresenceFailed used when watching presence fails
+	ErrPresenceFailed = errors.New("watching presence failed")
+
+	// ErrPresenceForbidden used when user isn't allowed to see presence of watched users
+	ErrPresenceForbidden = errors.New("you aren't allowed to see presence of these users")
+)
+
+var cmdPresence = &cobra.Command{
+	Use:   "presence",
+	Short: "Monitor online status of people in Acroplia",
+	Long: `Monitor online status of people and availability of tutors in Acroplia.
+
+You have to use it's subcommands: watch.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia presence watch --users @ekaterina,@ivan,{user_uuid} --format table
+  ./acroplia presence watch --users @ekaterina --on-tutor-online 'notify-send "$ACROPLIA_USER_NAME is available"'
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var presenceWatch = &cobra.Command{
+	Use:   "watch",
+	Short: "Use Acroplia API to watch online status of users",
+	Long: `Use Acroplia API to watch online status of users and availability of tutors, it runs until it's stopped by Ctrl + C.
+
+Users are supplied by --users as comma separated uuids or @usernames. Statuses are polled every --interval,
+current statuses are printed first and then each transition with it's time: ONLINE, OFFLINE, TUTOR_ONLINE or TUTOR_OFFLINE.
+
+--on-tutor-online is a shell command, that is run each time a tutor becomes available. It gets the tutor in environment variables:
+ACROPLIA_USER_UUID, ACROPLIA_USERNAME, ACROPLIA_USER_NAME, ACROPLIA_STATUS and ACROPLIA_AT.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(conf.PresenceUsers) == 0 {
+			return ErrPresenceUsers
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+		token := authResponse.Data.AccessToken
+
+		// resolve usernames to uuids once, presence is requested by uuids
+		userUUIDs := make([]string, 0, len(conf.PresenceUsers))
+		for _, user := range conf.PresenceUsers {
+			user = strings.TrimSpace(user)
+			if !strings.HasPrefix(user, "@") {
+				userUUIDs = append(userUUIDs, user)
+				continue
+			}
+
+			found, err := crud.FindUserByUsername(user, token)
+			if errors.Is(err, crud.ErrUserNotFound) {
+				return errors.Wrapf(err, "%s", user)
+			} else if err != nil {
+				log.Logger.Debug().Msgf("crud.FindUserByUsername: %v", err)
+
+				return ErrPresenceFailed
+			}
+			userUUIDs = append(userUUIDs, found.UUID)
+		}
+
+		enc := json.NewEncoder(outputFile)
+		watcher := crud.NewPresenceWatcher(userUUIDs, token, func(change *crud.PresenceChange) {
+			if conf.OutputFormat == formatTable {
+				fmt.Fprintf(outputFile, "%s\t%s\t%s\n", change.At.Format(time.RFC3339), change.Status, formatUser(change.User))
+			} else if err := enc.Encode(change); err != nil {
+				log.Logger.Warn().Msgf("couldn't encode presence change: %v", err)
+			}
+
+			if conf.PresenceTutorHook != "" && change.Status == crud.PresenceTutorOnline && !change.Initial {
+				go runHook(conf.PresenceTutorHook, change)
+			}
+		})
+		watcher.Interval = conf.PresenceInterval
+
+		// stop watching on Ctrl + C
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			<-quit
+			log.Logger.Debug().Msg("received SIGINT(Ctrl + C) signal, stopping watching...")
+
+			cancel()
+		}()
+
+		log.Logger.Info().Msgf("watching presence of %d users every %s, writing changes to: %s", len(userUUIDs), conf.PresenceInterval, conf.PathToOutputFile)
+		err = watcher.Run(ctx)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if errors.Is(err, crud.ErrPresenceInterval) {
+			return err
+		} else if err != nil {
+			log.Logger.Debug().Msgf("watcher.Run: %v", err)
+
+			return apiError(err, ErrPresenceForbidden, ErrPresenceFailed, ErrPresenceFailed)
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// runHook runs command in a shell with presence change in environment, failures are only logged
+func runHook(command string, change *crud.PresenceChange) {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"ACROPLIA_USER_UUID="+change.User.UUID,
+		"ACROPLIA_USERNAME="+change.User.UserName,
+		"ACROPLIA_USER_NAME="+change.User.FullName(),
+		"ACROPLIA_STATUS="+change.Status,
+		"ACROPLIA_AT="+change.At.Format(time.RFC3339),
+	)
+
+	log.Logger.Debug().Msgf("running hook for %s %s ...", change.User.UserName, change.Status)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Logger.Error().Msgf("hook for %s failed: %v: %s", change.User.UserName, err, strings.TrimSpace(string(out)))
+		return
+	}
+	log.Logger.Debug().Msgf("hook for %s was done successfully: %s", change.User.UserName, strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrQuizFailed used when quiz request to Acroplia fails
+	ErrQuizFailed = errors.New("test request failed")
+
+	// ErrQuizForbidden used when user isn't author of a quiz
+	ErrQuizForbidden = errors.New("only author of a test can assign it and get it's results")
+
+	// ErrQuizNotFound used when quiz doesn't exist
+	ErrQuizNotFound = errors.New("test not found")
+)
+
+var cmdQuiz = &cobra.Command{
+	Use:   "test",
+	Short: "Manage tests (quizzes) in Acroplia",
+	Long: `Manage tests (quizzes) in Acroplia: create them from a yaml or toml definition, assign them to students and get results.
+
+You have to use it's subcommands: create, assign or results.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia test create --file quizzes.yaml --format table
+  ./acroplia test create --file quizzes.yaml --workspace {workspace_uuid} --due 168h
+  ./acroplia test assign {test_uuid} --user @ekaterina --due 2020-09-01
+  ./acroplia test results {test_uuid} --format csv --output results.csv
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var quizCreate = &cobra.Command{
+	Use:   "create",
+	Short: "Use Acroplia API to create tests from a definition",
+	Long: `Use Acroplia API to create tests in your library from a yaml or toml definition set by --file.
+
+Definition has a list of quizzes, each with title, pass_score (percent), time_limit (duration, ex: 20m) and questions.
+Question with choices and one correct choice is a single choice question, with several correct choices is a multiple choice question,
+question with answer and without choices is answered by typing text:
+
+  quizzes:
+    - title: Photosynthesis
+      pass_score: 60
+      questions:
+        - text: What do plants produce?
+          choices: [Oxygen, Nitrogen, Helium]
+          correct: [Oxygen]
+        - text: Name the green pigment
+          answer: chlorophyll
+          points: 2
+
+Whole definition is checked before any test is created. Created tests are assigned to --user and --workspace, if they are set.
+If creating or assigning a test fails, tests created before it are still written to output.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.QuizFile == "" {
+			return errors.New("test definition can't be empty, use --file flag")
+		}
+
+		defs, err := crud.LoadQuizDefinitions(conf.QuizFile)
+		if err != nil {
+			return err
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+		token := authResponse.Data.AccessToken
+
+		var parent *crud.LibraryItem
+		if conf.QuizParent != "" {
+			log.Logger.Debug().Msgf("getting parent folder %s", conf.QuizParent)
+			parentResp, err := crud.GetLibraryItem(conf.QuizParent, token)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.GetLibraryItem: %v", err)
+
+				return errors.New("couldn't get parent folder")
+			}
+			parent = parentResp.Data
+		}
+
+		var req *crud.AssignmentRequest
+		if len(conf.QuizUsers) > 0 || len(conf.QuizWorkspaces) > 0 {
+			req, err = buildAssignmentRequest(conf.QuizUsers, conf.QuizWorkspaces, conf.QuizDue, token)
+			if err != nil {
+				return err
+			}
+		}
+
+		created := make([]*crud.LibraryItem, 0, len(defs))
+		table := func(w io.Writer) {
+			writeLibraryTable(w, created...)
+		}
+		for _, def := range defs {
+			item, err := createQuiz(def, parent, req, authResponse.Data.User, token)
+			if item != nil {
+				created = append(created, item)
+			}
+			if err != nil {
+				// tests created before failure are written, so it's known which of them to skip in next run
+				if len(created) > 0 {
+					if err := writeOutput(created, table); err != nil {
+						log.Logger.Warn().Msgf("couldn't write created tests: %v", err)
+					}
+				}
+
+				return err
+			}
+		}
+		log.Logger.Debug().Msgf("creating %d tests was done successfully", len(created))
+
+		return writeOutput(created, table)
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var quizAssign = &cobra.Command{
+	Use:   "assign <test-uuid>",
+	Short: "Use Acroplia API to assign a test to users or workspaces",
+	Long: `Use Acroplia API to assign a test to users by --user (uuid or @username) and to all members of workspaces by --workspace.
+
+--due accepts either a duration from now, ex: 72h, or a date, ex: 2020-09-01 or 2020-09-01T18:00:00Z.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(conf.QuizUsers) == 0 && len(conf.QuizWorkspaces) == 0 {
+			return crud.ErrAssigneeEmpty
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		req, err := buildAssignmentRequest(conf.QuizUsers, conf.QuizWorkspaces, conf.QuizDue, authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("assigning test %s to %d assignees ...", args[0], len(req.Assignees))
+		resp, err := crud.AssignQuiz(args[0], req, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.AssignQuiz: %v", err)
+
+			return apiError(err, ErrQuizForbidden, ErrQuizNotFound, ErrQuizFailed)
+		}
+		log.Logger.Debug().Msgf("assigning test was done successfully, %d assignments created", len(resp.Data))
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeAssignmentTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var quizResults = &cobra.Command{
+	Use:   "results <test-uuid>",
+	Short: "Use Acroplia API to get results of a test",
+	Long: `Use Acroplia API to get submitted results of all students, only author of a test can get them.
+
+Results are written in --format: json, table or csv. Csv has a row per student with score, percent, whether student passed
+and points for each question in q1, q2, ... columns, unanswered questions are empty.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationFormats: strings.Join([]string{formatJSON, formatTable, formatCSV}, ","),
+	},
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("getting test %s ...", args[0])
+		quiz, err := crud.GetLibraryItem(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.GetLibraryItem: %v", err)
+
+			return apiError(err, ErrQuizForbidden, ErrQuizNotFound, ErrQuizFailed)
+		}
+		if quiz.Data.Type != crud.LibraryTest || quiz.Data.Quiz == nil {
+			return errors.Errorf("%s isn't a test, it's %s", args[0], quiz.Data.Type)
+		}
+
+		log.Logger.Debug().Msgf("listing results of test %s ...", args[0])
+		resp, err := crud.ListQuizResults(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListQuizResults: %v", err)
+
+			return apiError(err, ErrQuizForbidden, ErrQuizNotFound, ErrQuizFailed)
+		}
+		log.Logger.Debug().Msgf("listing results was done successfully, found %d results", len(resp.Data))
+
+		if conf.OutputFormat == formatCSV {
+			log.Logger.Info().Msgf("writing results to: %s", conf.PathToOutputFile)
+			if err := crud.WriteQuizResultsCSV(outputFile, quiz.Data.Quiz, resp.Data); err != nil {
+				log.Logger.Debug().Msgf("crud.WriteQuizResultsCSV: %v", err)
+
+				return errors.New("couldn't write results")
+			}
+
+			return nil
+		}
+
+		return writeOutput(resp, func(w io.Writer) {
+			fmt.Fprintln(w, "STUDENT\tSCORE\tPERCENT\tPASSED\tSUBMITTED AT")
+			for _, r := range resp.Data {
+				fmt.Fprintf(w, "%s\t%d/%d\t%.1f%%\t%t\t%s\n", formatUser(r.Student), r.Score, r.MaxScore, r.Percent(), r.Passed, formatTimestamp(r.SubmittedAt))
+			}
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// createQuiz creates a test from definition and assigns it, if req isn't nil.
+// Created test is returned even if assigning it fails
+func createQuiz(def *crud.QuizDefinition, parent *crud.LibraryItem, req *crud.AssignmentRequest, user *crud.PrivateUser, token string) (*crud.LibraryItem, error) {
+	quiz, err := crud.NewQuiz(def, user)
+	if err != nil {
+		return nil, err
+	}
+	if parent != nil {
+		if err := quiz.SetParent(parent); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Logger.Debug().Msgf("creating test %s with %d questions ...", quiz.Title, len(quiz.Quiz.Questions))
+	resp, err := quiz.Create(token)
+	if err != nil {
+		log.Logger.Debug().Msgf("quiz.Create: %v", err)
+
+		return nil, errors.Wrapf(ErrQuizFailed, "creating %s", quiz.Title)
+	}
+
+	if req != nil {
+		log.Logger.Debug().Msgf("assigning test %s to %d assignees ...", quiz.Title, len(req.Assignees))
+		if _, err := crud.AssignQuiz(resp.Data.UUID, req, token); err != nil {
+			log.Logger.Debug().Msgf("crud.AssignQuiz: %v", err)
+
+			return resp.Data, errors.Wrapf(apiError(err, ErrQuizForbidden, ErrQuizNotFound, ErrQuizFailed), "assigning %s", quiz.Title)
+		}
+	}
+
+	return resp.Data, nil
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/csv"
 	"os"
 	"strings"
 	"time"
@@ -20,6 +22,7 @@ type Config struct {
 	Debug            bool
 	PathToLogFile    string
 	PathToOutputFile string
+	OutputFormat     string
 
 	Email    string
 	Password string
@@ -30,13 +33,109 @@ type Config struct {
 	SeleniumBrowser string
 	SeleniumOptions []string
 
-	TextpadTitle    string
-	TextpadSubtitle string
+	TextpadTitle      string
+	TextpadSubtitle   string
+	TextpadLang       string
+	TextpadTags       []string
+	TextpadRemoveTags []string
+	TextpadVersion    int
+	TextpadDiffFrom   int
+	TextpadDiffTo     int
+
+	TextpadBulkConcurrency int
+	TextpadBulkResults     string
+	TextpadBulkResume      bool
 
 	MessageUsername string
 	MessageFullname string
 	MessageText     string
 	MessageChatUUID string
+	MessageSince    string
+	MessageLimit    int
+	MessageAttach   []string
+	MessageCursor   int
+
+	MessageUntil     string
+	MessageExportDir string
+
+	MessageRecipients           string
+	MessageTemplate             string
+	MessageReport               string
+	MessageDryRun               bool
+	MessageBroadcastConcurrency int
+	MessageBroadcastInterval    time.Duration
+
+	MessageAt string
+	MessageIn time.Duration
+
+	SchedulerQueue       string
+	SchedulerInterval    time.Duration
+	SchedulerMaxAttempts int
+
+	BotConfig string
+
+	WorkspaceType        string
+	WorkspaceTitle       string
+	WorkspaceDescription string
+	WorkspaceArchived    bool
+
+	MemberRole string
+
+	SpecFile string
+
+	UsersPage   int
+	UsersSize   int
+	UsersOnline bool
+	UsersTutor  bool
+	UsersGuest  bool
+
+	SearchTypes []string
+	SearchSince string
+	SearchUntil string
+	SearchLang  string
+	SearchPage  int
+	SearchSize  int
+
+	PresenceUsers     []string
+	PresenceInterval  time.Duration
+	PresenceTutorHook string
+
+	TaskTitle      string
+	TaskSubtitle   string
+	TaskParent     string
+	TaskItems      []string
+	TaskUsers      []string
+	TaskWorkspaces []string
+	TaskDue        string
+	TaskAll        bool
+
+	QuizFile       string
+	QuizParent     string
+	QuizUsers      []string
+	QuizWorkspaces []string
+	QuizDue        string
+
+	FeedText   string
+	FeedAttach []string
+	FeedBefore string
+	FeedLimit  int
+	FeedRemove bool
+
+	BillingSince  string
+	BillingUntil  string
+	BillingStatus string
+	BillingPage   int
+	BillingSize   int
+
+	ChatsMessage string
+
+	LibraryType     string
+	LibraryTitle    string
+	LibrarySubtitle string
+	LibraryParent   string
+	LibraryURL      string
+	LibraryTasks    []string
+	LibraryItems    []string
 }
 
 // conf values are set in command preRun
@@ -52,6 +151,9 @@ func init() {
 	cmdRoot.PersistentFlags().StringVarP(&conf.PathToOutputFile, "output", "o", "stdout", "store output of requests in specified file")
 	viper.BindPFlag("misc.output", cmdRoot.PersistentFlags().Lookup("output"))
 
+	cmdRoot.PersistentFlags().StringVar(&conf.OutputFormat, "format", formatJSON, "format of output: json or table")
+	viper.BindPFlag("misc.format", cmdRoot.PersistentFlags().Lookup("format"))
+
 	cmdLogin.PersistentFlags().StringVar(&conf.Email, "email", "", "email for login")
 	viper.BindPFlag("credentials.email", cmdLogin.PersistentFlags().Lookup("email"))
 
@@ -88,6 +190,31 @@ func init() {
 	textpadWeb.Flags().StringVar(&conf.Phone, "phone", "", "phone for login")
 	viper.BindPFlag("credentials.phone", textpadWeb.Flags().Lookup("phone"))
 
+	textpadAPI.Flags().StringVar(&conf.TextpadLang, "lang", "", "iso code of textpad language, ex: en (optional)")
+
+	textpadAPI.Flags().StringArrayVar(&conf.TextpadTags, "tag", []string{}, "tag for textpad, can be repeated (optional)")
+
+	textpadUpdate.Flags().StringVar(&conf.TextpadLang, "lang", "", "iso code of new textpad language, ex: en (optional)")
+
+	textpadUpdate.Flags().StringArrayVar(&conf.TextpadTags, "tag", []string{}, "tag to add to textpad, can be repeated (optional)")
+
+	textpadUpdate.Flags().StringArrayVar(&conf.TextpadRemoveTags, "remove-tag", []string{}, "tag to remove from textpad, can be repeated (optional)")
+
+	textpadShow.Flags().IntVar(&conf.TextpadVersion, "version", 0, "version of textpad to show (optional)")
+
+	textpadRestore.Flags().IntVar(&conf.TextpadVersion, "version", 0, "version of textpad to restore")
+
+	textpadDiff.Flags().IntVar(&conf.TextpadDiffFrom, "from", 0, "version of textpad to compare from")
+
+	textpadDiff.Flags().IntVar(&conf.TextpadDiffTo, "to", 0, "version of textpad to compare to")
+
+	textpadBulkCreate.Flags().IntVar(&conf.TextpadBulkConcurrency, "concurrency", 4, "maximum number of textpads created at the same time")
+	viper.BindPFlag("textpad.bulk.concurrency", textpadBulkCreate.Flags().Lookup("concurrency"))
+
+	textpadBulkCreate.Flags().StringVar(&conf.TextpadBulkResults, "results", "", "file to write results to (default: manifest name followed by .results.csv)")
+
+	textpadBulkCreate.Flags().BoolVar(&conf.TextpadBulkResume, "resume", false, "skip rows that were already created according to results file")
+
 	cmdMessage.PersistentFlags().StringVar(&conf.MessageFullname, "fullname", "", "full name of a user, ex: Ekaterina Gorbunova")
 	viper.BindPFlag("message.fullname", cmdMessage.PersistentFlags().Lookup("fullname"))
 
@@ -97,8 +224,110 @@ func init() {
 	cmdMessage.PersistentFlags().StringVar(&conf.MessageText, "text", "Hello, World !", "message to send to a user")
 	viper.BindPFlag("message.text", cmdMessage.PersistentFlags().Lookup("text"))
 
-	messageAPI.Flags().StringVar(&conf.MessageChatUUID, "chat-uuid", "23e7235e-2072-3330-91b7-3b747d5a87e7", "chat uuid to send message to")
-	viper.BindPFlag("message.chat-uuid", messageAPI.Flags().Lookup("chat-uuid"))
+	cmdMessage.PersistentFlags().StringVar(&conf.MessageChatUUID, "chat-uuid", "", "uuid of a chat, if empty then private chat with a user found by username or fullname is used")
+	viper.BindPFlag("message.chat-uuid", cmdMessage.PersistentFlags().Lookup("chat-uuid"))
+
+	messageList.Flags().StringVar(&conf.MessageSince, "since", "", "list messages since duration before now, ex: 24h, or since date, ex: 2020-07-01 (optional)")
+
+	messageList.Flags().IntVar(&conf.MessageLimit, "limit", 0, "maximum number of messages to list (optional)")
+
+	chatsRead.Flags().StringVar(&conf.ChatsMessage, "message", "", "uuid of a message, chat is read up to it, default is last message of a chat (optional)")
+
+	messageTail.Flags().IntVar(&conf.MessageCursor, "cursor", 0, "cursor of last seen event, to continue watching from it (optional)")
+
+	messageExport.Flags().StringVar(&conf.MessageSince, "since", "", "export messages since duration before now, ex: 24h, or since date, ex: 2020-07-01 (optional)")
+	messageExport.Flags().StringVar(&conf.MessageUntil, "until", "", "export messages until duration before now, ex: 1h, or until date, ex: 2020-08-01 (optional)")
+	messageExport.Flags().StringVar(&conf.MessageExportDir, "attachments-dir", "", "download attachments into this directory (optional)")
+
+	messageBroadcast.Flags().StringVar(&conf.MessageRecipients, "recipients", "", "csv file with recipients, identified by chat_uuid, username or fullname column")
+	messageBroadcast.Flags().StringVar(&conf.MessageTemplate, "template", "", "file with Go text/template of a message, columns of recipient are available in it")
+	messageBroadcast.Flags().StringVar(&conf.MessageReport, "report", "", "file to write delivery report to (default: recipients name followed by .report.csv)")
+	messageBroadcast.Flags().BoolVar(&conf.MessageDryRun, "dry-run", false, "render messages and find recipients without sending")
+
+	messageBroadcast.Flags().IntVar(&conf.MessageBroadcastConcurrency, "concurrency", 4, "maximum number of messages sent at the same time")
+	viper.BindPFlag("message.broadcast.concurrency", messageBroadcast.Flags().Lookup("concurrency"))
+
+	messageBroadcast.Flags().DurationVar(&conf.MessageBroadcastInterval, "interval", time.Second, "minimum interval between sent messages, 0 turns off rate limit")
+	viper.BindPFlag("message.broadcast.interval", messageBroadcast.Flags().Lookup("interval"))
+
+	messageSchedule.Flags().StringVar(&conf.MessageAt, "at", "", "date and time to send message at, ex: 2020-11-01T09:00")
+	messageSchedule.Flags().DurationVar(&conf.MessageIn, "in", 0, "delay before sending message, ex: 2h30m")
+
+	// queue flag is shared by message schedule and scheduler commands, so it's read from config in buildPreRun
+	messageSchedule.Flags().StringVar(&conf.SchedulerQueue, "queue", defaultQueueFilename, "file with queue of scheduled messages")
+	cmdScheduler.PersistentFlags().StringVar(&conf.SchedulerQueue, "queue", defaultQueueFilename, "file with queue of scheduled messages")
+
+	schedulerRun.Flags().DurationVar(&conf.SchedulerInterval, "interval", 10*time.Second, "how often queue is checked for due messages")
+	viper.BindPFlag("scheduler.interval", schedulerRun.Flags().Lookup("interval"))
+
+	schedulerRun.Flags().IntVar(&conf.SchedulerMaxAttempts, "max-attempts", 5, "failed message is retried until it fails this many times")
+	viper.BindPFlag("scheduler.max-attempts", schedulerRun.Flags().Lookup("max-attempts"))
+
+	cmdWorkspace.PersistentFlags().StringVar(&conf.WorkspaceType, "type", "", "type of workspace: WORKSPACE or COMMUNITY (optional)")
+	workspaceCreate.Flags().StringVar(&conf.WorkspaceTitle, "title", "", "title of workspace")
+	workspaceRename.Flags().StringVar(&conf.WorkspaceTitle, "title", "", "new title of workspace")
+	workspaceCreate.Flags().StringVar(&conf.WorkspaceDescription, "description", "", "description of workspace (optional)")
+	workspaceList.Flags().BoolVar(&conf.WorkspaceArchived, "archived", false, "list archived workspaces")
+
+	membersInvite.Flags().StringVar(&conf.MemberRole, "role", "", "role of invited users, by default member role (optional)")
+	membersGrant.Flags().StringVar(&conf.MemberRole, "role", "", "role to grant, ex: MODERATOR")
+	membersRevoke.Flags().StringVar(&conf.MemberRole, "role", "", "role to revoke, ex: MODERATOR")
+
+	cmdPlan.Flags().StringVarP(&conf.SpecFile, "file", "f", "", "yaml or toml file with spec of workspaces and library")
+	cmdApply.Flags().StringVarP(&conf.SpecFile, "file", "f", "", "yaml or toml file with spec of workspaces and library")
+
+	usersSearch.Flags().IntVar(&conf.UsersPage, "page", 1, "number of page with results")
+	usersSearch.Flags().IntVar(&conf.UsersSize, "size", 0, "number of users on a page, by default server decides (optional)")
+	usersSearch.Flags().BoolVar(&conf.UsersOnline, "online", false, "only users, that are online (optional)")
+	usersSearch.Flags().BoolVar(&conf.UsersTutor, "tutor", false, "only tutors (optional)")
+	usersSearch.Flags().BoolVar(&conf.UsersGuest, "guest", false, "only guests (optional)")
+
+	cmdSearch.Flags().StringSliceVar(&conf.SearchTypes, "type", nil, "types of content to search: textpad, folder or message, all by default (optional)")
+	cmdSearch.Flags().StringVar(&conf.SearchSince, "since", "", "content since duration before now, ex: 24h, or since date, ex: 2020-07-01 (optional)")
+	cmdSearch.Flags().StringVar(&conf.SearchUntil, "until", "", "content until duration before now, ex: 1h, or until date, ex: 2020-08-01 (optional)")
+	cmdSearch.Flags().StringVar(&conf.SearchLang, "lang", "", "iso code of query language, ex: en (optional)")
+	cmdSearch.Flags().IntVar(&conf.SearchPage, "page", 1, "number of page with results")
+	cmdSearch.Flags().IntVar(&conf.SearchSize, "size", 0, "number of results on a page, by default server decides (optional)")
+
+	presenceWatch.Flags().StringSliceVar(&conf.PresenceUsers, "users", nil, "comma separated uuids or @usernames of users to watch")
+	presenceWatch.Flags().DurationVar(&conf.PresenceInterval, "interval", 30*time.Second, "how often presence is checked")
+	presenceWatch.Flags().StringVar(&conf.PresenceTutorHook, "on-tutor-online", "", "shell command to run when a tutor becomes available (optional)")
+
+	tasksCreate.Flags().StringVar(&conf.TaskTitle, "title", "", "title of task list")
+	tasksCreate.Flags().StringVar(&conf.TaskSubtitle, "subtitle", "", "subtitle of task list (optional)")
+	tasksCreate.Flags().StringVar(&conf.TaskParent, "parent", "", "uuid of parent folder (optional)")
+	tasksCreate.Flags().StringArrayVar(&conf.TaskItems, "task", []string{}, "task of task list, can be repeated")
+	tasksAssign.Flags().StringSliceVar(&conf.TaskUsers, "user", []string{}, "uuid or @username of assigned user, can be repeated")
+	tasksAssign.Flags().StringSliceVar(&conf.TaskWorkspaces, "workspace", []string{}, "uuid of assigned workspace, can be repeated")
+	tasksAssign.Flags().StringVar(&conf.TaskDue, "due", "", "due time as duration from now, ex: 72h, or date, ex: 2020-09-01 (optional)")
+	tasksList.Flags().BoolVar(&conf.TaskAll, "all", false, "list done assignments too")
+
+	quizCreate.Flags().StringVar(&conf.QuizFile, "file", "", "yaml or toml file with definition of tests")
+	quizCreate.Flags().StringVar(&conf.QuizParent, "parent", "", "uuid of parent folder (optional)")
+	for _, cmd := range []*cobra.Command{quizCreate, quizAssign} {
+		cmd.Flags().StringSliceVar(&conf.QuizUsers, "user", []string{}, "uuid or @username of assigned user, can be repeated")
+		cmd.Flags().StringSliceVar(&conf.QuizWorkspaces, "workspace", []string{}, "uuid of assigned workspace, can be repeated")
+		cmd.Flags().StringVar(&conf.QuizDue, "due", "", "due time as duration from now, ex: 72h, or date, ex: 2020-09-01 (optional)")
+	}
+
+	feedList.Flags().StringVar(&conf.FeedBefore, "before", "", "uuid of post, only older posts are listed (optional)")
+	feedList.Flags().IntVar(&conf.FeedLimit, "limit", 20, "maximum number of listed posts")
+	feedPost.Flags().StringVar(&conf.FeedText, "text", "", "text of post, @username mentions a user")
+	feedPost.Flags().StringArrayVar(&conf.FeedAttach, "attach", []string{}, "path to a file attached to post, can be repeated")
+	feedComment.Flags().StringVar(&conf.FeedText, "text", "", "text of comment, @username mentions a user")
+	feedReact.Flags().BoolVar(&conf.FeedRemove, "remove", false, "remove reaction instead of putting it")
+
+	for _, cmd := range []*cobra.Command{billingMembers, billingPurchases} {
+		cmd.Flags().StringVar(&conf.BillingSince, "since", "", "start of period as duration back from now, ex: 720h, or date, ex: 2020-07-01 (optional)")
+		cmd.Flags().StringVar(&conf.BillingUntil, "until", "", "end of period as duration back from now or date (optional)")
+		cmd.Flags().IntVar(&conf.BillingPage, "page", 0, "number of page, all pages are listed by default")
+		cmd.Flags().IntVar(&conf.BillingSize, "size", 0, "number of records on a page (optional)")
+	}
+	billingMembers.Flags().StringVar(&conf.BillingStatus, "status", "", "status of memberships: active, canceled or expired (optional)")
+
+	botRun.Flags().StringVar(&conf.BotConfig, "config", "", "toml file with bot config")
+
+	messageAPI.Flags().StringArrayVar(&conf.MessageAttach, "attach", []string{}, "path to a file to attach, can be repeated (optional)")
 
 	messageWeb.Flags().StringVar(&conf.Email, "email", "", "email for login")
 	viper.BindPFlag("credentials.email", messageWeb.Flags().Lookup("email"))
@@ -108,6 +337,27 @@ func init() {
 
 	messageWeb.Flags().StringVar(&conf.Phone, "phone", "", "phone for login")
 	viper.BindPFlag("credentials.phone", messageWeb.Flags().Lookup("phone"))
+
+	cmdLibrary.PersistentFlags().StringVar(&conf.LibraryType, "type", "", "type of library item: TEXTPAD, FOLDER, COLLECTION, LINK or TASK_LIST")
+	viper.BindPFlag("library.type", cmdLibrary.PersistentFlags().Lookup("type"))
+
+	cmdLibrary.PersistentFlags().StringVar(&conf.LibraryParent, "parent", "", "uuid of parent folder (optional)")
+	viper.BindPFlag("library.parent", cmdLibrary.PersistentFlags().Lookup("parent"))
+
+	libraryCreate.Flags().StringVar(&conf.LibraryTitle, "title", "", "title for library item")
+	viper.BindPFlag("library.title", libraryCreate.Flags().Lookup("title"))
+
+	libraryCreate.Flags().StringVar(&conf.LibrarySubtitle, "subtitle", "", "subtitle for library item (optional)")
+	viper.BindPFlag("library.subtitle", libraryCreate.Flags().Lookup("subtitle"))
+
+	libraryCreate.Flags().StringVar(&conf.LibraryURL, "url", "", "url for link")
+	viper.BindPFlag("library.url", libraryCreate.Flags().Lookup("url"))
+
+	libraryCreate.Flags().Var(newStringArrayValue(&conf.LibraryTasks), "task", "task for task list, can be repeated")
+	viper.BindPFlag("library.tasks", libraryCreate.Flags().Lookup("task"))
+
+	libraryCreate.Flags().StringSliceVar(&conf.LibraryItems, "item", []string{}, "uuid of library item for collection, can be repeated")
+	viper.BindPFlag("library.items", libraryCreate.Flags().Lookup("item"))
 }
 
 var (
@@ -131,6 +381,28 @@ Example:
   ./acroplia textpad web --email my@email.com --password myPassword --title MyTitle --subtitle MySubtitle
   ./acroplia message api --fullname="Bezhan Mukhidinov" --text="Hello, World !"
   ./acroplia message web --email my@email.com --password myPassword --fullname="Bezhan Mukhidinov" --text="Hello, World !"
+  ./acroplia message list --chat-uuid {uuid} --since 24h --format table
+  ./acroplia message api --username ekaterina --text="Homework" --attach lesson.pdf --attach photo.jpg
+  ./acroplia message reply {message-uuid} --username ekaterina --text="Thanks"
+  ./acroplia message tail --username ekaterina --format table
+  ./acroplia message export --chat-uuid {uuid} --format csv --since 720h --output chat.csv
+  ./acroplia message broadcast --recipients students.csv --template reminder.tmpl --interval 2s
+  ./acroplia message schedule --username ekaterina --text "Good morning" --in 12h
+  ./acroplia scheduler run
+  ./acroplia chats unread --format table
+  ./acroplia bot run --config bot.toml
+  ./acroplia workspace create --title "Biology 101"
+  ./acroplia members grant {workspace_uuid} {user_uuid} --role MODERATOR
+  ./acroplia apply -f space.yaml
+  ./acroplia users search ekaterina --online
+  ./acroplia search "photosynthesis" --type textpad
+  ./acroplia presence watch --users @ekaterina,@ivan
+  ./acroplia tasks list --format table
+  ./acroplia test results {test_uuid} --format csv
+  ./acroplia feed post {workspace_uuid} --text "Hello @ekaterina"
+  ./acroplia billing purchases {workspace_uuid} --since 720h --format csv
+  ./acroplia library create --type FOLDER --title MyFolder
+  ./acroplia library list --format table
 `,
 	Version: "0.1alpha",
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -152,14 +424,39 @@ func buildPreRun(cmd *cobra.Command, args []string) error {
 	conf.Debug = viper.GetBool("misc.debug")
 	conf.PathToLogFile = viper.GetString("misc.log")
 	conf.PathToOutputFile = viper.GetString("misc.output")
+	conf.OutputFormat = viper.GetString("misc.format")
 	conf.SeleniumPort = viper.GetString("selenium.port")
 	conf.SeleniumBrowser = viper.GetString("selenium.browser")
 	conf.SeleniumOptions = viper.GetStringSlice("selenium.options")
 	conf.TextpadTitle = viper.GetString("textpad.title")
 	conf.TextpadSubtitle = viper.GetString("textpad.subtitle")
+	conf.TextpadBulkConcurrency = viper.GetInt("textpad.bulk.concurrency")
 	conf.MessageFullname = viper.GetString("message.fullname")
 	conf.MessageUsername = viper.GetString("message.username")
 	conf.MessageChatUUID = viper.GetString("message.chat-uuid")
+	conf.MessageBroadcastConcurrency = viper.GetInt("message.broadcast.concurrency")
+	conf.MessageBroadcastInterval = viper.GetDuration("message.broadcast.interval")
+	conf.SchedulerInterval = viper.GetDuration("scheduler.interval")
+	conf.SchedulerMaxAttempts = viper.GetInt("scheduler.max-attempts")
+	if queue := viper.GetString("scheduler.queue"); queue != "" && !cmd.Flags().Changed("queue") {
+		conf.SchedulerQueue = queue
+	}
+	conf.LibraryType = viper.GetString("library.type")
+	conf.LibraryTitle = viper.GetString("library.title")
+	conf.LibrarySubtitle = viper.GetString("library.subtitle")
+	conf.LibraryParent = viper.GetString("library.parent")
+	conf.LibraryURL = viper.GetString("library.url")
+	conf.LibraryTasks = viper.GetStringSlice("library.tasks")
+	conf.LibraryItems = viper.GetStringSlice("library.items")
+
+	// check if output format is supported, commands may support their own formats
+	if formats, ok := cmd.Annotations[annotationFormats]; ok {
+		if !stringInSlice(conf.OutputFormat, strings.Split(formats, ",")) {
+			return errors.Errorf("output format must be one of: %s", strings.ReplaceAll(formats, ",", ", "))
+		}
+	} else if conf.OutputFormat != formatJSON && conf.OutputFormat != formatTable {
+		return ErrOutputFormat
+	}
 
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.Kitchen}).With().Logger()
 
@@ -242,13 +539,96 @@ func Execute() error {
 
 	cmdTextpad.AddCommand(textpadAPI)
 	cmdTextpad.AddCommand(textpadWeb)
+	cmdTextpad.AddCommand(textpadUpdate)
+	cmdTextpad.AddCommand(textpadLanguages)
+	cmdTextpad.AddCommand(textpadHistory)
+	cmdTextpad.AddCommand(textpadShow)
+	cmdTextpad.AddCommand(textpadDiff)
+	cmdTextpad.AddCommand(textpadRestore)
+	cmdTextpad.AddCommand(textpadWatch)
+
+	textpadBulk.AddCommand(textpadBulkCreate)
+	cmdTextpad.AddCommand(textpadBulk)
 
 	cmdMessage.AddCommand(messageWeb)
 	cmdMessage.AddCommand(messageAPI)
+	cmdMessage.AddCommand(messageList)
+	cmdMessage.AddCommand(messageReply)
+	cmdMessage.AddCommand(messageEdit)
+	cmdMessage.AddCommand(messageDelete)
+	cmdMessage.AddCommand(messageTail)
+	cmdMessage.AddCommand(messageExport)
+	cmdMessage.AddCommand(messageBroadcast)
+	cmdMessage.AddCommand(messageSchedule)
+
+	cmdChats.AddCommand(chatsUnread)
+	cmdChats.AddCommand(chatsRead)
+
+	cmdLibrary.AddCommand(libraryCreate)
+	cmdLibrary.AddCommand(libraryList)
 
 	cmdRoot.AddCommand(cmdLogin)
 	cmdRoot.AddCommand(cmdTextpad)
 	cmdRoot.AddCommand(cmdMessage)
+	cmdRoot.AddCommand(cmdLibrary)
+	cmdRoot.AddCommand(cmdChats)
+
+	cmdScheduler.AddCommand(schedulerRun)
+	cmdScheduler.AddCommand(schedulerList)
+	cmdRoot.AddCommand(cmdScheduler)
+
+	cmdBot.AddCommand(botRun)
+	cmdRoot.AddCommand(cmdBot)
+
+	cmdWorkspace.AddCommand(workspaceList)
+	cmdWorkspace.AddCommand(workspaceCreate)
+	cmdWorkspace.AddCommand(workspaceShow)
+	cmdWorkspace.AddCommand(workspaceRename)
+	cmdWorkspace.AddCommand(workspaceArchive)
+	cmdRoot.AddCommand(cmdWorkspace)
+
+	cmdMembers.AddCommand(membersList)
+	cmdMembers.AddCommand(membersInvite)
+	cmdMembers.AddCommand(membersRemove)
+	cmdMembers.AddCommand(membersGrant)
+	cmdMembers.AddCommand(membersRevoke)
+	cmdRoot.AddCommand(cmdMembers)
+
+	cmdUsers.AddCommand(usersSearch)
+	cmdUsers.AddCommand(usersShow)
+	cmdRoot.AddCommand(cmdUsers)
+
+	cmdRoot.AddCommand(cmdSearch)
+
+	cmdPresence.AddCommand(presenceWatch)
+	cmdRoot.AddCommand(cmdPresence)
+
+	cmdTasks.AddCommand(tasksCreate)
+	cmdTasks.AddCommand(tasksAssign)
+	cmdTasks.AddCommand(tasksList)
+	cmdTasks.AddCommand(tasksShow)
+	cmdTasks.AddCommand(tasksDone)
+	cmdTasks.AddCommand(tasksUndo)
+	cmdRoot.AddCommand(cmdTasks)
+
+	cmdQuiz.AddCommand(quizCreate)
+	cmdQuiz.AddCommand(quizAssign)
+	cmdQuiz.AddCommand(quizResults)
+	cmdRoot.AddCommand(cmdQuiz)
+
+	cmdFeed.AddCommand(feedList)
+	cmdFeed.AddCommand(feedPost)
+	cmdFeed.AddCommand(feedComment)
+	cmdFeed.AddCommand(feedComments)
+	cmdFeed.AddCommand(feedReact)
+	cmdRoot.AddCommand(cmdFeed)
+
+	cmdBilling.AddCommand(billingMembers)
+	cmdBilling.AddCommand(billingPurchases)
+	cmdRoot.AddCommand(cmdBilling)
+
+	cmdRoot.AddCommand(cmdPlan)
+	cmdRoot.AddCommand(cmdApply)
 
 	viper.SetConfigName("config")
 	viper.SetConfigType("toml")
@@ -265,3 +645,39 @@ func Execute() error {
 
 	return cmdRoot.Execute()
 }
+
+// stringArrayValue is a flag value, that keeps each repeated value as it is, like string array flag does.
+// It's reported as string slice and printed as csv, so viper reads it back without splitting values with spaces or commas
+type stringArrayValue struct {
+	value   *[]string
+	changed bool
+}
+
+func newStringArrayValue(p *[]string) *stringArrayValue {
+	*p = []string{}
+
+	return &stringArrayValue{value: p}
+}
+
+func (s *stringArrayValue) Set(val string) error {
+	if !s.changed {
+		*s.value = []string{}
+		s.changed = true
+	}
+	*s.value = append(*s.value, val)
+
+	return nil
+}
+
+func (s *stringArrayValue) Type() string {
+	return "stringSlice"
+}
+
+func (s *stringArrayValue) String() string {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Write(*s.value)
+	w.Flush()
+
+	return "[" + strings.TrimSuffix(buf.String(), "\n") + "]"
+}
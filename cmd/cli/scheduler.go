@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// defaultQueueFilename is a queue of scheduled messages used by default
+const defaultQueueFilename = "./config/.message-queue.json"
+
+var (
+	// ErrSchedulerFailed used when reading or writing message queue fails
+	ErrSchedulerFailed = errors.New("message queue request failed")
+)
+
+var cmdScheduler = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Deliver scheduled messages",
+	Long: `Deliver messages scheduled by message schedule command.
+
+You have to use it's subcommands: run or list.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia scheduler run
+  ./acroplia scheduler list --format table
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var schedulerRun = &cobra.Command{
+	Use:   "run",
+	Short: "Use Acroplia API to send scheduled messages at their due time",
+	Long: `Use Acroplia API to send scheduled messages at their due time, it runs until it's stopped by Ctrl + C.
+
+Queue is checked every --interval, failed messages are retried with growing delay up to --max-attempts times.
+Outcome of each message is saved to queue, so it's safe to restart scheduler at any time.
+Message interrupted while sending is left with SENDING status and isn't sent again.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		scheduler := crud.NewScheduler(crud.NewMessageQueue(conf.SchedulerQueue), authResponse.Data.User, authResponse.Data.AccessToken)
+		scheduler.Interval = conf.SchedulerInterval
+		scheduler.MaxAttempts = conf.SchedulerMaxAttempts
+		scheduler.OnResult = func(msg *crud.ScheduledMessage) {
+			switch msg.Status {
+			case crud.ScheduleSent:
+				log.Logger.Info().Msgf("message %s was sent to chat %s as %s", msg.ID, msg.ChatUUID, msg.MessageUUID)
+			case crud.ScheduleFailed:
+				log.Logger.Error().Msgf("message %s failed after %d attempts: %s", msg.ID, msg.Attempts, msg.LastError)
+			default:
+				log.Logger.Warn().Msgf("message %s failed, retrying at %s: %s", msg.ID, msg.NextAttempt.Format(time.RFC3339), msg.LastError)
+			}
+		}
+
+		scheduler.OnError = func(err error) {
+			log.Logger.Warn().Msgf("checking queue failed, retrying at next check: %v", err)
+		}
+
+		// stop on Ctrl + C
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			<-quit
+			log.Logger.Debug().Msg("received SIGINT(Ctrl + C) signal, stopping scheduler...")
+
+			cancel()
+		}()
+
+		log.Logger.Info().Msgf("delivering messages from %s every %s", conf.SchedulerQueue, conf.SchedulerInterval)
+		err = scheduler.Run(ctx)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			log.Logger.Debug().Msgf("scheduler.Run: %v", err)
+
+			return ErrSchedulerFailed
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var schedulerList = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled messages and their outcomes",
+	Long: `List scheduled messages and their outcomes, sorted by due time.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		messages, err := crud.NewMessageQueue(conf.SchedulerQueue).List()
+		if err != nil {
+			log.Logger.Debug().Msgf("queue.List: %v", err)
+
+			return ErrSchedulerFailed
+		}
+
+		return writeOutput(messages, func(w io.Writer) {
+			writeScheduleTable(w, messages...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// writeScheduleTable writes scheduled messages as table rows
+func writeScheduleTable(w io.Writer, messages ...*crud.ScheduledMessage) {
+	fmt.Fprintln(w, "ID\tAT\tCHAT\tSTATUS\tATTEMPTS\tTEXT")
+	for _, msg := range messages {
+		status := msg.Status
+		if msg.LastError != "" {
+			status += ": " + msg.LastError
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", msg.ID, msg.At.Format(time.RFC3339), msg.ChatUUID, status, msg.Attempts, strings.ReplaceAll(msg.Text, "\n", " "))
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// snippetLength is a maximum length of a snippet in table output
+const snippetLength = 80
+
+var (
+	// ErrSearchFailed used when searching content fails
+	ErrSearchFailed = errors.New("searching failed")
+)
+
+var cmdSearch = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Use Acroplia API to search textpads, folders and messages",
+	Long: `Use Acroplia API to search textpads, folders and chat messages available to you.
+
+Results can be filtered by --type (` + strings.ToLower(strings.Join(crud.SearchableTypes, ", ")) + `), by date range with --since and --until,
+and query words are matched in all their forms of --lang, ex: photosynthesis matches photosynthetic.
+Matches are highlighted with ** in table output.
+
+You have to perform login before using this command as it needs user information from login.
+
+Example:
+  ./acroplia search "photosynthesis" --type textpad --format table
+  ./acroplia search "homework" --type message --since 168h
+  ./acroplia search "фотосинтез" --lang ru --since 2020-09-01 --until 2020-12-31 --page 2
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, err := parseTime(conf.SearchSince)
+		if err != nil {
+			return err
+		}
+		until, err := parseTime(conf.SearchUntil)
+		if err != nil {
+			return err
+		}
+
+		filter := &crud.SearchFilter{
+			Types: conf.SearchTypes,
+			Since: since,
+			Until: until,
+			Page:  conf.SearchPage,
+			Size:  conf.SearchSize,
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		if conf.SearchLang != "" {
+			filter.Lang, err = findLanguage(conf.SearchLang, authResponse.Data.AccessToken)
+			if err != nil {
+				return err
+			}
+		}
+
+		log.Logger.Debug().Msgf("searching %s ...", args[0])
+		resp, err := crud.Search(args[0], authResponse.Data.AccessToken, filter)
+		if errors.Is(err, crud.ErrSearchType) || errors.Is(err, crud.ErrSearchRange) {
+			return err
+		} else if err != nil {
+			log.Logger.Debug().Msgf("crud.Search: %v", err)
+
+			return apiError(err, ErrSearchFailed, ErrSearchFailed, ErrSearchFailed)
+		}
+		log.Logger.Debug().Msgf("searching was done successfully, found %d of %d results", len(resp.Data), resp.Total)
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeSearchTable(w, resp.Data...)
+			if resp.Total > len(resp.Data) {
+				fmt.Fprintf(w, "\npage %d, %d of %d results\n", resp.Page, len(resp.Data), resp.Total)
+			}
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// writeSearchTable writes search results as table rows, snippets are shortened and their new lines are replaced by spaces
+func writeSearchTable(w io.Writer, results ...*crud.SearchResult) {
+	fmt.Fprintln(w, "TYPE\tUUID\tPATH\tSNIPPET\tUPDATED AT")
+	for _, r := range results {
+		r.TruncateSnippet(snippetLength)
+		snippet := strings.Join(strings.Fields(r.HighlightedSnippet("**", "**")), " ")
+
+		updatedAt := r.UpdatedAt
+		if updatedAt == 0 {
+			updatedAt = r.CreatedAt
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Type, r.UUID, r.Breadcrumbs(" / "), snippet, formatTimestamp(updatedAt))
+	}
+}
@@ -0,0 +1,352 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrTasksFailed used when tasks request to Acroplia fails
+	ErrTasksFailed = errors.New("tasks request failed")
+
+	// ErrTasksForbidden used when user isn't allowed to assign a task list or change an assignment
+	ErrTasksForbidden = errors.New("you aren't allowed to change this task list or assignment")
+
+	// ErrTasksNotFound used when task list or assignment doesn't exist
+	ErrTasksNotFound = errors.New("task list or assignment not found")
+)
+
+var cmdTasks = &cobra.Command{
+	Use:   "tasks",
+	Short: "Manage task lists and assignments in Acroplia",
+	Long: `Manage task lists, assign them to users or workspaces and track completion of assignments in Acroplia.
+
+You have to use it's subcommands: create, assign, list, show, done or undo.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia tasks create --title "Homework 1" --task "Read chapter 1" --task "Solve exercises"
+  ./acroplia tasks assign {task_list_uuid} --user @ekaterina --workspace {workspace_uuid} --due 2020-09-01
+  ./acroplia tasks list --format table
+  ./acroplia tasks show {assignment_uuid} --format table
+  ./acroplia tasks done {assignment_uuid} 1 2
+  ./acroplia tasks undo {assignment_uuid} {task_uuid}
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var tasksCreate = &cobra.Command{
+	Use:   "create",
+	Short: "Use Acroplia API to create a task list",
+	Long: `Use Acroplia API to create a task list in your library with --task items, that can be assigned later.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.TaskTitle == "" {
+			return errors.New("task list title can't be empty for this command")
+		}
+		if len(conf.TaskItems) == 0 {
+			return errors.New("task list must have at least one task, use --task flag")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		taskList := crud.NewTaskList(conf.TaskTitle, conf.TaskSubtitle, conf.TaskItems, authResponse.Data.User)
+		if conf.TaskParent != "" {
+			log.Logger.Debug().Msgf("getting parent folder %s", conf.TaskParent)
+			parentResp, err := crud.GetLibraryItem(conf.TaskParent, authResponse.Data.AccessToken)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.GetLibraryItem: %v", err)
+
+				return errors.New("couldn't get parent folder")
+			}
+
+			if err := taskList.SetParent(parentResp.Data); err != nil {
+				return err
+			}
+		}
+
+		log.Logger.Debug().Msgf("creating task list %s with %d tasks ...", conf.TaskTitle, len(conf.TaskItems))
+		resp, err := taskList.Create(authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("taskList.Create: %v", err)
+
+			return ErrTasksFailed
+		}
+		log.Logger.Debug().Msg("creating task list was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			// server may not return payload of created item
+			var items []*crud.TaskListItem
+			if resp.Data != nil && resp.Data.TaskList != nil {
+				items = resp.Data.TaskList.Items
+			}
+			writeTaskTable(w, items...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var tasksAssign = &cobra.Command{
+	Use:   "assign <task-list-uuid>",
+	Short: "Use Acroplia API to assign a task list to users or workspaces",
+	Long: `Use Acroplia API to assign a task list to users by --user (uuid or @username) and to all members of workspaces by --workspace.
+Each assignee gets own assignment to complete.
+
+--due accepts either a duration from now, ex: 72h, or a date, ex: 2020-09-01 or 2020-09-01T18:00:00Z.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(conf.TaskUsers) == 0 && len(conf.TaskWorkspaces) == 0 {
+			return crud.ErrAssigneeEmpty
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		req, err := buildAssignmentRequest(conf.TaskUsers, conf.TaskWorkspaces, conf.TaskDue, authResponse.Data.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("assigning task list %s to %d assignees ...", args[0], len(req.Assignees))
+		resp, err := crud.AssignTaskList(args[0], req, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.AssignTaskList: %v", err)
+
+			return apiError(err, ErrTasksForbidden, ErrTasksNotFound, ErrTasksFailed)
+		}
+		log.Logger.Debug().Msgf("assigning task list was done successfully, %d assignments created", len(resp.Data))
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeAssignmentTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var tasksList = &cobra.Command{
+	Use:   "list",
+	Short: "Use Acroplia API to list your assignments",
+	Long: `Use Acroplia API to list your open assignments, use --all to list done assignments too.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		filter := &crud.AssignmentFilter{Status: crud.AssignmentOpen}
+		if conf.TaskAll {
+			filter.Status = ""
+		}
+
+		log.Logger.Debug().Msg("listing assignments ...")
+		resp, err := crud.ListAssignments(authResponse.Data.User.UUID, authResponse.Data.AccessToken, filter)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListAssignments: %v", err)
+
+			return apiError(err, ErrTasksForbidden, ErrTasksNotFound, ErrTasksFailed)
+		}
+		log.Logger.Debug().Msgf("listing assignments was done successfully, found %d assignments", len(resp.Data))
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeAssignmentTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var tasksShow = &cobra.Command{
+	Use:   "show <assignment-uuid>",
+	Short: "Use Acroplia API to show tasks of an assignment",
+	Long: `Use Acroplia API to show tasks of an assignment and whether they are done.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("getting assignment %s ...", args[0])
+		resp, err := crud.GetAssignment(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.GetAssignment: %v", err)
+
+			return apiError(err, ErrTasksForbidden, ErrTasksNotFound, ErrTasksFailed)
+		}
+		log.Logger.Debug().Msg("getting assignment was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeTaskTable(w, resp.Data.Items...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var tasksDone = &cobra.Command{
+	Use:   "done <assignment-uuid> <task>...",
+	Short: "Use Acroplia API to mark tasks of an assignment as done",
+	Long: `Use Acroplia API to mark tasks of an assignment as done, task is either it's uuid or it's number in assignment, starting from 1.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.MinimumNArgs(2),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setTasksDone(args[0], args[1:], true)
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var tasksUndo = &cobra.Command{
+	Use:   "undo <assignment-uuid> <task>...",
+	Short: "Use Acroplia API to mark tasks of an assignment as not done",
+	Long: `Use Acroplia API to mark tasks of an assignment as not done, task is either it's uuid or it's number in assignment, starting from 1.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.MinimumNArgs(2),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setTasksDone(args[0], args[1:], false)
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// setTasksDone finds tasks of an assignment and changes their completion state, then writes changed assignment
+func setTasksDone(assignmentUUID string, tasks []string, done bool) error {
+	authResponse, err := readLoginResponse()
+	if err != nil {
+		return err
+	}
+
+	log.Logger.Debug().Msgf("getting assignment %s ...", assignmentUUID)
+	resp, err := crud.GetAssignment(assignmentUUID, authResponse.Data.AccessToken)
+	if err != nil {
+		log.Logger.Debug().Msgf("crud.GetAssignment: %v", err)
+
+		return apiError(err, ErrTasksForbidden, ErrTasksNotFound, ErrTasksFailed)
+	}
+
+	// find all tasks first, so nothing is changed if one of them is mistyped
+	items := make([]*crud.TaskListItem, 0, len(tasks))
+	for _, task := range tasks {
+		item, err := resp.Data.FindTask(task)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+
+	for _, item := range items {
+		log.Logger.Debug().Msgf("marking task %s as done=%t ...", item.UUID, done)
+		resp, err = crud.SetTaskDone(assignmentUUID, item.UUID, done, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.SetTaskDone: %v", err)
+
+			return apiError(err, ErrTasksForbidden, ErrTasksNotFound, ErrTasksFailed)
+		}
+	}
+	log.Logger.Debug().Msg("changing tasks was done successfully")
+
+	return writeOutput(resp, func(w io.Writer) {
+		writeTaskTable(w, resp.Data.Items...)
+	})
+}
+
+// buildAssignmentRequest finds assigned users by their uuids or @usernames and parses due time of assignment
+func buildAssignmentRequest(users, workspaces []string, due, token string) (*crud.AssignmentRequest, error) {
+	req := &crud.AssignmentRequest{}
+	if due != "" {
+		dueAt, err := parseDue(due)
+		if err != nil {
+			return nil, err
+		}
+		req.DueAt = int(dueAt.UnixNano() / int64(time.Millisecond))
+	}
+
+	for _, user := range users {
+		found, err := crud.FindUser(strings.TrimSpace(user), token)
+		if errors.Is(err, crud.ErrUserNotFound) {
+			return nil, errors.Wrapf(err, "%s", user)
+		} else if err != nil {
+			log.Logger.Debug().Msgf("crud.FindUser: %v", err)
+
+			return nil, errors.New("couldn't find assigned user")
+		}
+		req.Assignees = append(req.Assignees, &crud.Assignee{Type: crud.AssigneeUser, UUID: found.UUID})
+	}
+	for _, workspace := range workspaces {
+		req.Assignees = append(req.Assignees, &crud.Assignee{Type: crud.AssigneeWorkspace, UUID: workspace})
+	}
+
+	return req, nil
+}
+
+// parseDue parses due time either as a duration from now or as a date
+func parseDue(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(d), nil
+	}
+
+	return parseTime(value)
+}
+
+// writeAssignmentTable writes assignments with their progress as table rows
+func writeAssignmentTable(w io.Writer, assignments ...*crud.Assignment) {
+	fmt.Fprintln(w, "UUID\tTITLE\tASSIGNEE\tASSIGNED BY\tDUE AT\tPROGRESS\tSTATUS")
+	for _, a := range assignments {
+		done, total := a.Progress()
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d/%d\t%s\n", a.UUID, a.Title, formatUser(a.Assignee), formatUser(a.AssignedBy), formatTimestamp(a.DueAt), done, total, a.Status)
+	}
+}
+
+// writeTaskTable writes tasks as numbered table rows
+func writeTaskTable(w io.Writer, items ...*crud.TaskListItem) {
+	fmt.Fprintln(w, "#\tUUID\tDONE\tTEXT")
+	for i, item := range items {
+		done := "[ ]"
+		if item.Done {
+			done = "[x]"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, item.UUID, done, item.Text)
+	}
+}
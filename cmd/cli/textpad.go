@@ -1,11 +1,16 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bejaneps/acroplia/internal/crud"
 	"github.com/bejaneps/acroplia/internal/services"
@@ -17,6 +22,12 @@ import (
 var (
 	// ErrTextpadFailed used when creating textpad in Acroplia fails
 	ErrTextpadFailed = errors.New("creating textpad failed")
+
+	// ErrTextpadUpdateFailed used when updating textpad in Acroplia fails
+	ErrTextpadUpdateFailed = errors.New("updating textpad failed")
+
+	// ErrTextpadVersionFailed used when getting or restoring versions of textpad fails
+	ErrTextpadVersionFailed = errors.New("textpad version request failed")
 )
 
 var cmdTextpad = &cobra.Command{
@@ -26,11 +37,22 @@ var cmdTextpad = &cobra.Command{
 
 You have to use it's subcommands: api or web to create actual textpad.
 
+Versions of a textpad can be managed by history, show, diff and restore subcommands.
+
 Don't forget to perform login through API, before using this command !
 
 Example: 
   ./acroplia textpad api
   ./acroplia textpad web
+  ./acroplia textpad api --title MyTitle --lang en --tag biology --tag lesson
+  ./acroplia textpad update {uuid} --lang ru --tag homework --remove-tag lesson
+  ./acroplia textpad languages
+  ./acroplia textpad history {uuid}
+  ./acroplia textpad show {uuid} --version 2
+  ./acroplia textpad diff {uuid} --from 1 --to 3
+  ./acroplia textpad restore {uuid} --version 2
+  ./acroplia textpad bulk create manifest.csv
+  ./acroplia textpad watch {uuid} --format table
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.Help()
@@ -55,30 +77,24 @@ You have to perform login before using this command as it needs user information
 			return errors.New("textpad title can't be empty for this command")
 		}
 
-		// open login response file for decoding it
-		log.Logger.Debug().Msgf("opening response file: %v ...", loginRespFilename)
-		f, err := os.Open(loginRespFilename)
+		authResponse, err := readLoginResponse()
 		if err != nil {
-			log.Logger.Debug().Msgf("os.Open: %v", err)
-
-			return errors.New("couldn't open login response file, make sure you have performed login")
+			return err
 		}
-		log.Logger.Debug().Msgf("opening response file was done successfully")
 
-		// decode contents of login response into struct
-		log.Logger.Debug().Msg("decoding response file into struct")
-		authResponse := &crud.ResponseAuthResponse{}
-		err = json.NewDecoder(f).Decode(authResponse)
-		if err != nil {
-			log.Logger.Debug().Msgf("json.Decode: %v", err)
+		textpad := crud.NewTextpad(conf.TextpadTitle, conf.TextpadSubtitle, authResponse.Data.User)
+		textpad.SetTags(conf.TextpadTags)
 
-			return errors.New("couldn't decode login response to struct")
+		// set language of a textpad if user specified it
+		if conf.TextpadLang != "" {
+			textpad.Lang, err = findLanguage(conf.TextpadLang, authResponse.Data.AccessToken)
+			if err != nil {
+				return err
+			}
 		}
-		log.Logger.Debug().Msg("decoding response file was done successfully")
 
 		// create an actual textpad
 		log.Logger.Debug().Msgf("creating a textpad with title %s and subtitle %s", conf.TextpadTitle, conf.TextpadSubtitle)
-		textpad := crud.NewTextpad(conf.TextpadTitle, conf.TextpadSubtitle, authResponse.Data.User)
 		textpadResp, err := textpad.Create(authResponse.Data.AccessToken)
 		if err != nil {
 			log.Logger.Debug().Msgf("crud.NewTextpad: %v", err)
@@ -87,18 +103,9 @@ You have to perform login before using this command as it needs user information
 		}
 		log.Logger.Debug().Msg("creating a textpad was done successfully")
 
-		// store response message to specified output file
-		log.Logger.Info().Msgf("writing response message to: %s\n", conf.PathToOutputFile)
-		enc := json.NewEncoder(outputFile)
-		enc.SetIndent("", "  ")
-		err = enc.Encode(textpadResp)
-		if err != nil {
-			log.Logger.Debug().Msgf("json.NewEncoder: %v", err)
-
-			return errors.New("couldn't encode response")
-		}
-
-		return nil
+		return writeOutput(textpadResp, func(w io.Writer) {
+			writeLibraryTable(w, textpadResp.Data)
+		})
 	},
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -166,3 +173,508 @@ You have to supply your email or phone and password to perform login, after that
 	SilenceUsage:  true,
 	SilenceErrors: true,
 }
+
+var textpadHistory = &cobra.Command{
+	Use:   "history <uuid>",
+	Short: "Use Acroplia API to list versions of a textpad",
+	Long: `Use Acroplia API to list versions of a textpad with their authors and timestamps.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("listing versions of textpad %s ...", args[0])
+		resp, err := crud.ListTextpadVersions(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListTextpadVersions: %v", err)
+
+			return ErrTextpadVersionFailed
+		}
+		log.Logger.Debug().Msg("listing versions was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			fmt.Fprintln(w, "VERSION\tAUTHOR\tCREATED AT")
+			for _, v := range resp.Data {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", v.Version, formatUser(v.User), formatTimestamp(v.CreatedAt))
+			}
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var textpadShow = &cobra.Command{
+	Use:   "show <uuid>",
+	Short: "Use Acroplia API to show a textpad",
+	Long: `Use Acroplia API to show a textpad, latest version is shown unless --version is specified.
+
+In table format only text of a textpad is shown.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		// show latest version of a textpad
+		if conf.TextpadVersion == 0 {
+			log.Logger.Debug().Msgf("getting textpad %s ...", args[0])
+			resp, err := crud.GetLibraryItem(args[0], authResponse.Data.AccessToken)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.GetLibraryItem: %v", err)
+
+				return ErrTextpadFailed
+			}
+			log.Logger.Debug().Msg("getting textpad was done successfully")
+
+			return writeOutput(resp, func(w io.Writer) {
+				fmt.Fprint(w, resp.Data.Delta.Text())
+			})
+		}
+
+		log.Logger.Debug().Msgf("getting version %d of textpad %s ...", conf.TextpadVersion, args[0])
+		resp, err := crud.GetTextpadVersion(args[0], conf.TextpadVersion, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.GetTextpadVersion: %v", err)
+
+			return ErrTextpadVersionFailed
+		}
+		log.Logger.Debug().Msg("getting version was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			fmt.Fprint(w, resp.Data.Delta.Text())
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var textpadDiff = &cobra.Command{
+	Use:   "diff <uuid>",
+	Short: "Use Acroplia API to show a difference between two versions of a textpad",
+	Long: `Use Acroplia API to show a line by line difference between two versions of a textpad.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.TextpadDiffFrom == 0 || conf.TextpadDiffTo == 0 {
+			return errors.New("both --from and --to versions are required for this command")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		// get both versions of a textpad
+		versions := make([]*crud.TextpadVersion, 0, 2)
+		for _, version := range []int{conf.TextpadDiffFrom, conf.TextpadDiffTo} {
+			log.Logger.Debug().Msgf("getting version %d of textpad %s ...", version, args[0])
+			resp, err := crud.GetTextpadVersion(args[0], version, authResponse.Data.AccessToken)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.GetTextpadVersion: %v", err)
+
+				return ErrTextpadVersionFailed
+			}
+			versions = append(versions, resp.Data)
+		}
+		log.Logger.Debug().Msg("getting versions was done successfully")
+
+		log.Logger.Info().Msgf("writing response message to: %s\n", conf.PathToOutputFile)
+		_, err = io.WriteString(outputFile, crud.DiffVersions(versions[0], versions[1]))
+		if err != nil {
+			log.Logger.Debug().Msgf("io.WriteString: %v", err)
+
+			return errors.New("couldn't write response")
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var textpadRestore = &cobra.Command{
+	Use:   "restore <uuid>",
+	Short: "Use Acroplia API to restore a textpad to one of it's versions",
+	Long: `Use Acroplia API to restore a textpad to one of it's versions, restoring creates a new version of a textpad.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.TextpadVersion == 0 {
+			return errors.New("--version is required for this command")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("restoring textpad %s to version %d ...", args[0], conf.TextpadVersion)
+		resp, err := crud.RestoreTextpadVersion(args[0], conf.TextpadVersion, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.RestoreTextpadVersion: %v", err)
+
+			return ErrTextpadVersionFailed
+		}
+		log.Logger.Debug().Msg("restoring textpad was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeLibraryTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var textpadUpdate = &cobra.Command{
+	Use:   "update <uuid>",
+	Short: "Use Acroplia API to update a textpad",
+	Long: `Use Acroplia API to update title, subtitle, language and tags of a textpad.
+
+Only specified fields are changed, tags are added by --tag and removed by --remove-tag.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		// get current state of a textpad
+		log.Logger.Debug().Msgf("getting textpad %s ...", args[0])
+		getResp, err := crud.GetLibraryItem(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.GetLibraryItem: %v", err)
+
+			return ErrTextpadUpdateFailed
+		}
+		log.Logger.Debug().Msg("getting textpad was done successfully")
+
+		textpad := getResp.Data
+		if conf.TextpadTitle != "" {
+			textpad.Title = conf.TextpadTitle
+		}
+		if conf.TextpadSubtitle != "" {
+			textpad.Subtitle = conf.TextpadSubtitle
+		}
+		textpad.AddTags(conf.TextpadTags...)
+		textpad.RemoveTags(conf.TextpadRemoveTags...)
+
+		if conf.TextpadLang != "" {
+			textpad.Lang, err = findLanguage(conf.TextpadLang, authResponse.Data.AccessToken)
+			if err != nil {
+				return err
+			}
+		}
+
+		log.Logger.Debug().Msgf("updating textpad %s ...", args[0])
+		resp, err := textpad.Update(authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("textpad.Update: %v", err)
+
+			return ErrTextpadUpdateFailed
+		}
+		log.Logger.Debug().Msg("updating textpad was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeLibraryTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var textpadLanguages = &cobra.Command{
+	Use:   "languages",
+	Short: "Use Acroplia API to list languages supported for textpads",
+	Long: `Use Acroplia API to list languages supported for textpads.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msg("listing languages ...")
+		resp, err := crud.ListLanguages(authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListLanguages: %v", err)
+
+			return errors.New("couldn't list languages")
+		}
+		log.Logger.Debug().Msg("listing languages was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			fmt.Fprintln(w, "ISO CODE\tTITLE")
+			for _, lang := range resp.Data {
+				fmt.Fprintf(w, "%s\t%s\n", lang.ISOCode, lang.Title)
+			}
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// findLanguage validates iso code against languages supported by Acroplia
+func findLanguage(isoCode, token string) (*crud.Language, error) {
+	log.Logger.Debug().Msg("listing languages ...")
+	resp, err := crud.ListLanguages(token)
+	if err != nil {
+		log.Logger.Debug().Msgf("crud.ListLanguages: %v", err)
+
+		return nil, errors.New("couldn't list languages")
+	}
+	log.Logger.Debug().Msg("listing languages was done successfully")
+
+	lang, err := resp.FindLanguage(isoCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return lang, nil
+}
+
+var textpadBulk = &cobra.Command{
+	Use:   "bulk",
+	Short: "Create many textpads in Acroplia",
+	Long: `Create many textpads in Acroplia from a manifest.
+
+You have to use it's subcommand: create.
+
+Example:
+  ./acroplia textpad bulk create manifest.csv
+  ./acroplia textpad bulk create manifest.json --concurrency 8 --results results.csv --resume
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var textpadBulkCreate = &cobra.Command{
+	Use:   "create <manifest>",
+	Short: "Use Acroplia API to create textpads from csv or json manifest",
+	Long: `Use Acroplia API to create textpads from csv or json manifest.
+
+Each row of a manifest has title, subtitle, folder, lang and content_file (contentFile in json) fields.
+Content file is a path to plain text file with textpad content, relative paths are resolved from directory of manifest.
+Folder is a path of parent folder, ex: Biology/Week 1, missing folders are created.
+
+Results are written to results file (by default: manifest name followed by .results.csv) as soon as each textpad is created,
+results file is overwritten unless --resume is specified, in which case rows that were already created are skipped.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("reading manifest %s ...", args[0])
+		rows, err := crud.ReadTextpadManifest(args[0])
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ReadTextpadManifest: %v", err)
+
+			return errors.New("couldn't read manifest")
+		}
+		log.Logger.Debug().Msgf("reading manifest was done successfully, found %d rows", len(rows))
+
+		resultsPath := conf.TextpadBulkResults
+		if resultsPath == "" {
+			resultsPath = args[0] + ".results.csv"
+		}
+
+		bulk := crud.NewTextpadBulk(authResponse.Data.User, authResponse.Data.AccessToken, conf.TextpadBulkConcurrency)
+
+		// skip rows that were created during previous run
+		if conf.TextpadBulkResume {
+			log.Logger.Debug().Msgf("reading previous results %s ...", resultsPath)
+			previous, err := crud.ReadTextpadBulkResults(resultsPath)
+			if err != nil {
+				log.Logger.Debug().Msgf("crud.ReadTextpadBulkResults: %v", err)
+
+				return errors.New("couldn't read previous results")
+			}
+
+			for row, result := range previous {
+				if result.UUID != "" && result.Error == "" {
+					bulk.Skip[row] = true
+				}
+			}
+			log.Logger.Info().Msgf("skipping %d rows that are already created", len(bulk.Skip))
+		} else if err := os.Remove(resultsPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Logger.Debug().Msgf("os.Remove: %v", err)
+
+			return errors.New("couldn't remove previous results")
+		}
+
+		rw, err := crud.NewTextpadBulkResultWriter(resultsPath)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.NewTextpadBulkResultWriter: %v", err)
+
+			return errors.New("couldn't open results file")
+		}
+		defer rw.Close()
+
+		bulk.OnResult = func(result *crud.TextpadBulkResult) {
+			if result.Error != "" {
+				log.Logger.Warn().Msgf("row %d (%s): %s", result.Row, result.Title, result.Error)
+			} else {
+				log.Logger.Info().Msgf("row %d (%s): created %s", result.Row, result.Title, result.UUID)
+			}
+
+			if err := rw.Write(result); err != nil {
+				log.Logger.Warn().Msgf("couldn't write result of row %d: %v", result.Row, err)
+			}
+		}
+
+		log.Logger.Debug().Msgf("creating textpads with concurrency %d ...", bulk.Concurrency)
+		results, err := bulk.Create(rows)
+		if err != nil {
+			log.Logger.Debug().Msgf("bulk.Create: %v", err)
+
+			return ErrTextpadFailed
+		}
+
+		var failed int
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+			}
+		}
+		log.Logger.Info().Msgf("created %d textpads, %d failed, results are written to: %s", len(results)-failed, failed, resultsPath)
+
+		return writeOutput(results, func(w io.Writer) {
+			fmt.Fprintln(w, "ROW\tTITLE\tUUID\tERROR")
+			for _, result := range results {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", result.Row, result.Title, result.UUID, result.Error)
+			}
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var textpadWatch = &cobra.Command{
+	Use:   "watch <uuid>",
+	Short: "Use Acroplia API to watch changes of a textpad live",
+	Long: `Use Acroplia API to watch changes of a textpad live.
+
+Current text of a textpad is printed first, then each change made by other users is printed as soon as it's received.
+In json format each change is printed as a separate json object.
+
+Press Ctrl + C to stop watching.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("opening textpad %s for live editing ...", args[0])
+		live, err := crud.OpenLiveTextpad(args[0], authResponse.Data.User, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.OpenLiveTextpad: %v", err)
+
+			return ErrTextpadFailed
+		}
+		log.Logger.Debug().Msg("opening textpad was done successfully")
+
+		log.Logger.Info().Msgf("watching textpad %s at version %d, writing changes to: %s", args[0], live.Version(), conf.PathToOutputFile)
+		if conf.OutputFormat == formatTable {
+			fmt.Fprint(outputFile, live.Text())
+		}
+
+		enc := json.NewEncoder(outputFile)
+		live.OnChange = func(change *crud.TextpadChange) {
+			if conf.OutputFormat == formatTable {
+				fmt.Fprintf(outputFile, "%s\tversion %d\t%s\t%s\n", time.Now().Format(time.Kitchen), change.Version, formatUser(change.User), formatDelta(change.Delta))
+				return
+			}
+
+			if err := enc.Encode(change); err != nil {
+				log.Logger.Warn().Msgf("couldn't encode change: %v", err)
+			}
+		}
+
+		// stop watching on Ctrl + C
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			<-quit
+			log.Logger.Debug().Msg("received SIGINT(Ctrl + C) signal, stopping watching...")
+
+			cancel()
+		}()
+
+		err = live.Run(ctx)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			log.Logger.Debug().Msgf("live.Run: %v", err)
+
+			return errors.New("watching textpad failed")
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// formatDelta describes a change by it's operations and their positions in a document
+func formatDelta(delta *crud.Delta) string {
+	parts := make([]string, 0, len(delta.Ops))
+
+	var pos int
+	for _, op := range delta.Ops {
+		switch {
+		case op.Delete > 0:
+			parts = append(parts, fmt.Sprintf("delete %d chars at %d", op.Delete, pos))
+		case op.Retain > 0:
+			if op.Attributes != nil {
+				parts = append(parts, fmt.Sprintf("format %d chars at %d", op.Retain, pos))
+			}
+			pos += op.Retain
+		default:
+			parts = append(parts, fmt.Sprintf("insert %q at %d", op.Insert, pos))
+			pos += utf8.RuneCountInString(op.Insert)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
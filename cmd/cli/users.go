@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrUsersSearchFailed used when searching users fails
+	ErrUsersSearchFailed = errors.New("searching users failed")
+
+	// ErrUsersShowFailed used when getting user profile fails
+	ErrUsersShowFailed = errors.New("getting user failed")
+)
+
+var cmdUsers = &cobra.Command{
+	Use:   "users",
+	Short: "Find people in Acroplia",
+	Long: `Find people in Acroplia.
+
+You have to use it's subcommands: search or show.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia users search ekaterina --format table
+  ./acroplia users search "Ivan Petrov" --online --page 2 --size 20
+  ./acroplia users search biology --tutor --guest=false
+  ./acroplia users show @ekaterina
+  ./acroplia users show {user_uuid}
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var usersSearch = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Use Acroplia API to search people by name, username or email",
+	Long: `Use Acroplia API to search people by name, username or email, page by page.
+
+Results can be filtered by --online, --tutor and --guest, use --flag=false to get the opposite.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := &crud.UserFilter{
+			Page: conf.UsersPage,
+			Size: conf.UsersSize,
+		}
+		if cmd.Flags().Changed("online") {
+			filter.Online = &conf.UsersOnline
+		}
+		if cmd.Flags().Changed("tutor") {
+			filter.Tutor = &conf.UsersTutor
+		}
+		if cmd.Flags().Changed("guest") {
+			filter.Guest = &conf.UsersGuest
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("searching users by %s ...", args[0])
+		resp, err := crud.SearchUsers(args[0], authResponse.Data.AccessToken, filter)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.SearchUsers: %v", err)
+
+			return apiError(err, ErrUsersSearchFailed, ErrUsersSearchFailed, ErrUsersSearchFailed)
+		}
+		log.Logger.Debug().Msgf("searching users was done successfully, found %d of %d users", len(resp.Data), resp.Total)
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeUserTable(w, resp.Data...)
+			if resp.Total > len(resp.Data) {
+				fmt.Fprintf(w, "\npage %d, %d of %d users\n", resp.Page, len(resp.Data), resp.Total)
+			}
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var usersShow = &cobra.Command{
+	Use:   "show <uuid|@username>",
+	Short: "Use Acroplia API to show profile of a user",
+	Long: `Use Acroplia API to show public profile of a user by uuid or @username.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("getting user %s ...", args[0])
+		user, err := crud.FindUser(args[0], authResponse.Data.AccessToken)
+		if errors.Is(err, crud.ErrUserNotFound) {
+			return err
+		} else if err != nil {
+			log.Logger.Debug().Msgf("crud.FindUser: %v", err)
+
+			return apiError(err, ErrUsersShowFailed, crud.ErrUserNotFound, ErrUsersShowFailed)
+		}
+		log.Logger.Debug().Msg("getting user was done successfully")
+
+		return writeOutput(user, func(w io.Writer) {
+			fmt.Fprintf(w, "UUID:\t%s\n", user.UUID)
+			fmt.Fprintf(w, "USERNAME:\t%s\n", user.UserName)
+			fmt.Fprintf(w, "NAME:\t%s\n", user.FullName())
+			fmt.Fprintf(w, "ONLINE:\t%t\n", user.Online)
+			fmt.Fprintf(w, "TUTOR:\t%t\n", user.Tutor)
+			fmt.Fprintf(w, "GUEST:\t%t\n", user.Guest)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
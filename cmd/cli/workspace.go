@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrWorkspaceFailed used when workspace request to Acroplia fails
+	ErrWorkspaceFailed = errors.New("workspace request failed")
+
+	// ErrWorkspaceForbidden used when user isn't admin of a workspace
+	ErrWorkspaceForbidden = errors.New("only admins can change a workspace")
+
+	// ErrWorkspaceNotFound used when workspace doesn't exist
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+)
+
+var cmdWorkspace = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage workspaces and communities in Acroplia",
+	Long: `Manage workspaces and communities in Acroplia, like a course or a class space.
+
+You have to use it's subcommands: list, create, show, rename or archive.
+
+Don't forget to perform login through API, before using this command !
+
+Example:
+  ./acroplia workspace create --title "Biology 101" --description "Autumn course"
+  ./acroplia workspace create --type COMMUNITY --title "Biology lovers"
+  ./acroplia workspace list --format table
+  ./acroplia workspace show {workspace_uuid}
+  ./acroplia workspace rename {workspace_uuid} --title "Biology 102"
+  ./acroplia workspace archive {workspace_uuid}
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Help()
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var workspaceList = &cobra.Command{
+	Use:   "list",
+	Short: "Use Acroplia API to list workspaces and communities",
+	Long: `Use Acroplia API to list workspaces and communities you are member of, filtered by --type.
+
+Archived workspaces are listed only with --archived.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msg("listing workspaces ...")
+		resp, err := crud.ListWorkspaces(authResponse.Data.AccessToken, &crud.WorkspaceFilter{
+			Type:     strings.ToUpper(conf.WorkspaceType),
+			Archived: conf.WorkspaceArchived,
+		})
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ListWorkspaces: %v", err)
+
+			return ErrWorkspaceFailed
+		}
+		log.Logger.Debug().Msgf("listing workspaces was done successfully, found %d workspaces", len(resp.Data))
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeWorkspaceTable(w, resp.Data...)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var workspaceCreate = &cobra.Command{
+	Use:   "create",
+	Short: "Use Acroplia API to create a workspace or community",
+	Long: `Use Acroplia API to create a workspace or community with --title and --description, you become it's admin.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.WorkspaceTitle == "" {
+			return errors.New("workspace title can't be empty for this command")
+		}
+
+		workspaceType := strings.ToUpper(conf.WorkspaceType)
+		if workspaceType == "" {
+			workspaceType = crud.WorkspaceTypeWorkspace
+		}
+
+		workspace, err := crud.NewWorkspace(workspaceType, conf.WorkspaceTitle, conf.WorkspaceDescription)
+		if err != nil {
+			return err
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("creating %s %s ...", workspace.Type, workspace.Title)
+		resp, err := workspace.Create(authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("workspace.Create: %v", err)
+
+			return ErrWorkspaceFailed
+		}
+		log.Logger.Debug().Msg("creating workspace was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeWorkspaceTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var workspaceShow = &cobra.Command{
+	Use:   "show <uuid>",
+	Short: "Use Acroplia API to show a workspace or community",
+	Long: `Use Acroplia API to show a workspace or community.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("getting workspace %s ...", args[0])
+		resp, err := crud.GetWorkspace(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.GetWorkspace: %v", err)
+
+			return apiError(err, ErrWorkspaceForbidden, ErrWorkspaceNotFound, ErrWorkspaceFailed)
+		}
+		log.Logger.Debug().Msg("getting workspace was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			ws := resp.Data
+			fmt.Fprintf(w, "UUID:\t%s\n", ws.UUID)
+			fmt.Fprintf(w, "TYPE:\t%s\n", ws.Type)
+			fmt.Fprintf(w, "TITLE:\t%s\n", ws.Title)
+			fmt.Fprintf(w, "DESCRIPTION:\t%s\n", ws.Description)
+			fmt.Fprintf(w, "OWNER:\t%s\n", formatUser(ws.Owner))
+			fmt.Fprintf(w, "MEMBERS:\t%d\n", ws.MembersCount)
+			fmt.Fprintf(w, "ARCHIVED:\t%t\n", ws.Archived)
+			fmt.Fprintf(w, "CREATED AT:\t%s\n", formatTimestamp(ws.CreatedAt))
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var workspaceRename = &cobra.Command{
+	Use:   "rename <uuid>",
+	Short: "Use Acroplia API to rename a workspace or community",
+	Long: `Use Acroplia API to change title of a workspace or community to --title, only admins can rename it.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conf.WorkspaceTitle == "" {
+			return errors.New("workspace title can't be empty for this command")
+		}
+
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("renaming workspace %s ...", args[0])
+		resp, err := crud.RenameWorkspace(args[0], conf.WorkspaceTitle, authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.RenameWorkspace: %v", err)
+
+			return apiError(err, ErrWorkspaceForbidden, ErrWorkspaceNotFound, ErrWorkspaceFailed)
+		}
+		log.Logger.Debug().Msg("renaming workspace was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeWorkspaceTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var workspaceArchive = &cobra.Command{
+	Use:   "archive <uuid>",
+	Short: "Use Acroplia API to archive a workspace or community",
+	Long: `Use Acroplia API to archive a workspace or community, it becomes read only. Only admins can archive it.
+
+You have to perform login before using this command as it needs user information from login.
+`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: buildPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authResponse, err := readLoginResponse()
+		if err != nil {
+			return err
+		}
+
+		log.Logger.Debug().Msgf("archiving workspace %s ...", args[0])
+		resp, err := crud.ArchiveWorkspace(args[0], authResponse.Data.AccessToken)
+		if err != nil {
+			log.Logger.Debug().Msgf("crud.ArchiveWorkspace: %v", err)
+
+			return apiError(err, ErrWorkspaceForbidden, ErrWorkspaceNotFound, ErrWorkspaceFailed)
+		}
+		log.Logger.Debug().Msg("archiving workspace was done successfully")
+
+		return writeOutput(resp, func(w io.Writer) {
+			writeWorkspaceTable(w, resp.Data)
+		})
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// writeWorkspaceTable writes workspaces as table rows
+func writeWorkspaceTable(w io.Writer, workspaces ...*crud.Workspace) {
+	fmt.Fprintln(w, "UUID\tTYPE\tTITLE\tMEMBERS\tARCHIVED\tCREATED AT")
+	for _, ws := range workspaces {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%t\t%s\n", ws.UUID, ws.Type, ws.Title, ws.MembersCount, ws.Archived, formatTimestamp(ws.CreatedAt))
+	}
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+// Backend is a chat service, that bot follows and sends messages to
+type Backend interface {
+	// Events waits for events of a chat after cursor, zero cursor means only new events
+	Events(ctx context.Context, chatUUID string, cursor int) (*crud.ResponseMessageEvents, error)
+
+	// Send sends a message to a chat and returns sent message
+	Send(chatUUID string, msg *crud.UserMessageEntity) (*crud.UserMessageEntity, error)
+}
+
+// APIBackend is a Backend of Acroplia API
+type APIBackend struct {
+	token string
+}
+
+// NewAPIBackend is a constructor for APIBackend, token is an access token of bot's user.
+func NewAPIBackend(token string) *APIBackend {
+	return &APIBackend{token: token}
+}
+
+func (a *APIBackend) Events(ctx context.Context, chatUUID string, cursor int) (*crud.ResponseMessageEvents, error) {
+	return crud.ListMessageEvents(ctx, chatUUID, cursor, a.token)
+}
+
+func (a *APIBackend) Send(chatUUID string, msg *crud.UserMessageEntity) (*crud.UserMessageEntity, error) {
+	resp, err := msg.SendMessage(chatUUID, a.token)
+	if errors.Is(err, crud.ErrMessageInternal) {
+		// server fails, but message is sent anyway
+		return msg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// MemoryBackend is an in-memory Backend, that is used to test bots without Acroplia
+type MemoryBackend struct {
+	mu     sync.Mutex
+	events map[string][]*crud.MessageEvent
+	notify chan struct{}
+}
+
+// NewMemoryBackend is a constructor for MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		events: make(map[string][]*crud.MessageEvent),
+		notify: make(chan struct{}),
+	}
+}
+
+// Post adds a message to a chat as if it was sent by another user.
+func (m *MemoryBackend) Post(chatUUID string, msg *crud.UserMessageEntity) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events[chatUUID] = append(m.events[chatUUID], &crud.MessageEvent{
+		Cursor:  len(m.events[chatUUID]) + 1,
+		Type:    crud.MessageEventNew,
+		Message: msg,
+	})
+
+	// wake up waiting Events calls
+	close(m.notify)
+	m.notify = make(chan struct{})
+}
+
+// Messages returns all messages of a chat in order.
+func (m *MemoryBackend) Messages(chatUUID string) []*crud.UserMessageEntity {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]*crud.UserMessageEntity, 0, len(m.events[chatUUID]))
+	for _, event := range m.events[chatUUID] {
+		messages = append(messages, event.Message)
+	}
+
+	return messages
+}
+
+func (m *MemoryBackend) Events(ctx context.Context, chatUUID string, cursor int) (*crud.ResponseMessageEvents, error) {
+	// zero cursor means only events posted after the call
+	if cursor == 0 {
+		m.mu.Lock()
+		cursor = len(m.events[chatUUID])
+		m.mu.Unlock()
+	}
+
+	for {
+		m.mu.Lock()
+		events := m.events[chatUUID]
+		notify := m.notify
+		if len(events) > cursor {
+			resp := &crud.ResponseMessageEvents{Data: make([]*crud.MessageEvent, 0), Cursor: len(events)}
+			resp.Data = append(resp.Data, events[cursor:]...)
+			m.mu.Unlock()
+
+			return resp, nil
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (m *MemoryBackend) Send(chatUUID string, msg *crud.UserMessageEntity) (*crud.UserMessageEntity, error) {
+	m.Post(chatUUID, msg)
+
+	return msg, nil
+}
@@ -0,0 +1,237 @@
+// Package bot is a framework for chat bots in Acroplia.
+//
+// Bot follows chats, dispatches each new message to a handler registered by command prefix or regular expression,
+// and handlers answer through Context:
+//
+//	b := bot.New(bot.NewAPIBackend(token), user)
+//	b.Command("/help", func(c *bot.Context) error {
+//		return c.Reply("Ask me about homework")
+//	})
+//	b.Run(ctx, chatUUID)
+package bot
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+// HandlerFunc handles a message, that matched it's route
+type HandlerFunc func(c *Context) error
+
+// route is a handler with a rule of matching messages
+type route struct {
+	prefix  string
+	re      *regexp.Regexp
+	handler HandlerFunc
+}
+
+// match returns arguments of a message if it matches route, ok is false otherwise
+func (r *route) match(text string) (args string, match []string, ok bool) {
+	if r.re != nil {
+		match = r.re.FindStringSubmatch(text)
+		return text, match, match != nil
+	}
+
+	// prefix has to be followed by space or end of text, so /help doesn't match /helpme
+	if !strings.HasPrefix(text, r.prefix) {
+		return "", nil, false
+	}
+	rest := text[len(r.prefix):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\n' {
+		return "", nil, false
+	}
+
+	return strings.TrimSpace(rest), nil, true
+}
+
+// Bot dispatches messages of followed chats to handlers, routes are checked in order of registration
+type Bot struct {
+	// OnError is called when handler or backend fails, can be nil
+	OnError func(err error)
+
+	backend  Backend
+	user     *crud.PrivateUser
+	mu       sync.RWMutex
+	routes   []*route
+	fallback HandlerFunc
+	jobs     []*job
+}
+
+// job is a function, that bot runs periodically for each followed chat, ex: a reminder
+type job struct {
+	every time.Duration
+	fn    func(c *Context) error
+}
+
+// New is a constructor for Bot, messages are sent on behalf of user.
+func New(backend Backend, user *crud.PrivateUser) *Bot {
+	return &Bot{
+		backend: backend,
+		user:    user,
+	}
+}
+
+// Command registers handler for messages starting with prefix, text after prefix is available as Context.Args.
+func (b *Bot) Command(prefix string, handler HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.routes = append(b.routes, &route{prefix: prefix, handler: handler})
+}
+
+// Regexp registers handler for messages matching regular expression, submatches are available as Context.Match.
+func (b *Bot) Regexp(re *regexp.Regexp, handler HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.routes = append(b.routes, &route{re: re, handler: handler})
+}
+
+// Default registers handler for messages, that didn't match any route.
+func (b *Bot) Default(handler HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.fallback = handler
+}
+
+// Every registers function, that is called for each followed chat every interval, ex: to send reminders.
+// Context passed to it has no message.
+func (b *Bot) Every(interval time.Duration, fn func(c *Context) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.jobs = append(b.jobs, &job{every: interval, fn: fn})
+}
+
+// Handle dispatches a message of a chat to matching handler. Messages of bot itself and messages without text are ignored.
+func (b *Bot) Handle(ctx context.Context, chatUUID string, msg *crud.UserMessageEntity) error {
+	if msg.Type == "DELETED" || strings.TrimSpace(msg.Text) == "" {
+		return nil
+	}
+	if msg.User != nil && msg.User.UUID == b.user.UUID {
+		return nil
+	}
+
+	c := &Context{
+		ctx:      ctx,
+		bot:      b,
+		ChatUUID: chatUUID,
+		Message:  msg,
+		Sender:   msg.User,
+	}
+
+	b.mu.RLock()
+	handler := b.fallback
+	for _, r := range b.routes {
+		if args, match, ok := r.match(strings.TrimSpace(msg.Text)); ok {
+			handler, c.Args, c.Match = r.handler, args, match
+			break
+		}
+	}
+	b.mu.RUnlock()
+
+	if handler == nil {
+		return nil
+	}
+
+	return errors.Wrapf(handler(c), "handling message %s", msg.UUID)
+}
+
+// Run follows chats and handles their new messages, until ctx is done or backend fails.
+func (b *Bot) Run(ctx context.Context, chatUUIDs ...string) error {
+	if len(chatUUIDs) == 0 {
+		return errors.New("bot has no chats to follow")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, len(chatUUIDs))
+	for _, chatUUID := range chatUUIDs {
+		stream := crud.NewMessageStream(chatUUID, "", nil)
+		stream.List = b.backend.Events
+		stream.Retries = 5
+		stream.OnEvent = func(event *crud.MessageEvent) {
+			if event.Type != crud.MessageEventNew {
+				return
+			}
+
+			if err := b.Handle(ctx, stream.ChatUUID, event.Message); err != nil && b.OnError != nil {
+				b.OnError(err)
+			}
+		}
+
+		go func() {
+			errc <- errors.Wrapf(stream.Run(ctx), "following chat %s", stream.ChatUUID)
+		}()
+	}
+
+	b.mu.RLock()
+	for _, j := range b.jobs {
+		go b.runJob(ctx, j, chatUUIDs)
+	}
+	b.mu.RUnlock()
+
+	return <-errc
+}
+
+// runJob calls job for each chat every interval, until ctx is done
+func (b *Bot) runJob(ctx context.Context, j *job, chatUUIDs []string) {
+	ticker := time.NewTicker(j.every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, chatUUID := range chatUUIDs {
+			c := &Context{ctx: ctx, bot: b, ChatUUID: chatUUID}
+			if err := j.fn(c); err != nil && b.OnError != nil {
+				b.OnError(errors.Wrapf(err, "running job in chat %s", chatUUID))
+			}
+		}
+	}
+}
+
+// Context is a message being handled and helpers to answer it
+type Context struct {
+	ChatUUID string
+	Message  *crud.UserMessageEntity
+	Sender   *crud.PublicUser
+	Args     string   // text after command prefix
+	Match    []string // submatches of regular expression
+
+	ctx context.Context
+	bot *Bot
+}
+
+// Context returns context of bot run, it's done when bot stops.
+func (c *Context) Context() context.Context {
+	return c.ctx
+}
+
+// Send sends a message to the chat.
+func (c *Context) Send(text string) error {
+	_, err := c.bot.backend.Send(c.ChatUUID, crud.NewMessage(text, c.bot.user))
+	return errors.Wrap(err, "sending message")
+}
+
+// Reply sends a message to the chat as a reply to handled message, if there is no handled message then it's sent as a usual message.
+func (c *Context) Reply(text string) error {
+	if c.Message == nil {
+		return c.Send(text)
+	}
+
+	_, err := c.bot.backend.Send(c.ChatUUID, crud.NewReply(text, c.Message.UUID, c.bot.user))
+	return errors.Wrap(err, "sending reply")
+}
@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"bytes"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// Rule is an automated reply to messages matching command prefix or regular expression
+type Rule struct {
+	Command string `mapstructure:"command"`
+	Regexp  string `mapstructure:"regexp"`
+	Reply   string `mapstructure:"reply"` // Go text/template, Context is available in it, ex: Hi {{ .Sender.FirstName }}
+}
+
+// Reminder is a message sent to all followed chats periodically
+type Reminder struct {
+	Every string `mapstructure:"every"` // duration, ex: 24h
+	Text  string `mapstructure:"text"`
+}
+
+// Config describes a bot without code, it's read from toml file:
+//
+//	chats = ["{chat_uuid}"]
+//	default = "Sorry, I don't understand. Try /help"
+//
+//	[[rules]]
+//	command = "/help"
+//	reply = "Hi {{ .Sender.FirstName }}, ask me about /deadline"
+//
+//	[[rules]]
+//	regexp = "(?i)homework"
+//	reply = "Homework is in the library"
+//
+//	[[reminders]]
+//	every = "24h"
+//	text = "Don't forget to submit homework"
+type Config struct {
+	Chats     []string    `mapstructure:"chats"`
+	Default   string      `mapstructure:"default"`
+	Rules     []*Rule     `mapstructure:"rules"`
+	Reminders []*Reminder `mapstructure:"reminders"`
+}
+
+// LoadConfig reads bot config from a file, format is chosen by file extension.
+func LoadConfig(path string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Wrap(err, "reading bot config")
+	}
+
+	c := &Config{}
+	if err := v.Unmarshal(c); err != nil {
+		return nil, errors.Wrap(err, "decoding bot config")
+	}
+
+	return c, nil
+}
+
+// Register registers rules, default reply and reminders of config in a bot.
+func (c *Config) Register(b *Bot) error {
+	for i, rule := range c.Rules {
+		handler, err := replyHandler(rule.Reply)
+		if err != nil {
+			return errors.Wrapf(err, "rule %d", i+1)
+		}
+
+		switch {
+		case rule.Command != "":
+			b.Command(rule.Command, handler)
+		case rule.Regexp != "":
+			re, err := regexp.Compile(rule.Regexp)
+			if err != nil {
+				return errors.Wrapf(err, "rule %d", i+1)
+			}
+			b.Regexp(re, handler)
+		default:
+			return errors.Errorf("rule %d has neither command nor regexp", i+1)
+		}
+	}
+
+	if c.Default != "" {
+		handler, err := replyHandler(c.Default)
+		if err != nil {
+			return errors.Wrap(err, "default reply")
+		}
+		b.Default(handler)
+	}
+
+	for i, reminder := range c.Reminders {
+		every, err := time.ParseDuration(reminder.Every)
+		if err != nil || every <= 0 {
+			return errors.Errorf("reminder %d has invalid interval %q", i+1, reminder.Every)
+		}
+
+		text := reminder.Text
+		b.Every(every, func(c *Context) error {
+			return c.Send(text)
+		})
+	}
+
+	return nil
+}
+
+// replyHandler returns handler, that replies with rendered template
+func replyHandler(text string) (HandlerFunc, error) {
+	tmpl, err := template.New("reply").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing reply")
+	}
+
+	return func(c *Context) error {
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, c); err != nil {
+			return errors.Wrap(err, "rendering reply")
+		}
+
+		return c.Reply(buf.String())
+	}, nil
+}
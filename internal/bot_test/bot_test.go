@@ -0,0 +1,146 @@
+package bot_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/bot"
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+var (
+	botUser = &crud.PrivateUser{UUID: "bot-uuid", UserName: "bot"}
+	student = &crud.PublicUser{UUID: "student-uuid", UserName: "ekaterina", FirstName: "Ekaterina"}
+)
+
+func TestBotHandlers(t *testing.T) {
+	backend := bot.NewMemoryBackend()
+	b := bot.New(backend, botUser)
+
+	b.Command("/help", func(c *bot.Context) error {
+		return c.Reply("Hi " + c.Sender.FirstName + ", help for " + c.Args)
+	})
+	b.Regexp(regexp.MustCompile(`(?i)deadline for (\w+)`), func(c *bot.Context) error {
+		return c.Send("Deadline for " + c.Match[1] + " is Friday")
+	})
+	b.Default(func(c *bot.Context) error {
+		return c.Reply("Sorry, I don't understand")
+	})
+
+	cases := []struct {
+		text  string
+		reply string
+	}{
+		{"/help homework", "Hi Ekaterina, help for homework"},
+		{"/helpme", "Sorry, I don't understand"}, // prefix must be a whole word
+		{"What is the DEADLINE for biology?", "Deadline for biology is Friday"},
+	}
+
+	for i, c := range cases {
+		msg := &crud.UserMessageEntity{UUID: "message-" + c.text, Type: "USER_TEXT", Text: c.text, User: student}
+		if err := b.Handle(context.Background(), "chat-uuid", msg); err != nil {
+			t.Fatal(err)
+		}
+
+		messages := backend.Messages("chat-uuid")
+		if len(messages) != i+1 {
+			t.Fatalf("%s: expected %d messages, got %d", c.text, i+1, len(messages))
+		}
+
+		reply := messages[i]
+		if reply.Text != c.reply {
+			t.Fatalf("%s: expected reply %q, got %q", c.text, c.reply, reply.Text)
+		}
+		if reply.User.UUID != botUser.UUID {
+			t.Fatalf("%s: expected reply from bot, got %s", c.text, reply.User.UUID)
+		}
+	}
+
+	// replies refer to original message, sent messages don't
+	messages := backend.Messages("chat-uuid")
+	if messages[0].ReplyToMessage != "message-/help homework" || messages[2].ReplyToMessage != "" {
+		t.Fatalf("unexpected reply references %q and %q", messages[0].ReplyToMessage, messages[2].ReplyToMessage)
+	}
+
+	// bot ignores it's own messages
+	if err := b.Handle(context.Background(), "chat-uuid", messages[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.Messages("chat-uuid")) != 3 {
+		t.Fatal("expected bot to ignore it's own messages")
+	}
+}
+
+func TestBotRunWithConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bot.toml")
+	config := `chats = ["chat-uuid"]
+default = "Try /help"
+
+[[rules]]
+command = "/help"
+reply = "Hi {{ .Sender.FirstName }}, ask me about homework"
+
+[[rules]]
+regexp = "(?i)homework"
+reply = "Homework is in the library"
+
+[[reminders]]
+every = "50ms"
+text = "Submit homework"
+`
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	botConf, err := bot.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := bot.NewMemoryBackend()
+	b := bot.New(backend, botUser)
+	b.OnError = func(err error) {
+		t.Error(err)
+	}
+	if err := botConf.Register(b); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- b.Run(ctx, botConf.Chats...)
+	}()
+
+	// wait until bot follows chat
+	time.Sleep(20 * time.Millisecond)
+	backend.Post("chat-uuid", &crud.UserMessageEntity{UUID: "question-uuid", Type: "USER_TEXT", Text: "where is my homework?", User: student})
+
+	var reply, reminder bool
+	for !reply || !reminder {
+		if ctx.Err() != nil {
+			t.Fatalf("expected reply and reminder, got %d messages", len(backend.Messages("chat-uuid")))
+		}
+
+		for _, msg := range backend.Messages("chat-uuid") {
+			reply = reply || (msg.Text == "Homework is in the library" && msg.ReplyToMessage == "question-uuid")
+			reminder = reminder || msg.Text == "Submit homework"
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
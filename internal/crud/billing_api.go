@@ -0,0 +1,394 @@
+package crud
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jszwec/csvutil"
+	"github.com/pkg/errors"
+)
+
+// statuses of paid memberships
+const (
+	MembershipActive   = "ACTIVE"
+	MembershipCanceled = "CANCELED" // membership is paid till expiration, but won't be renewed
+	MembershipExpired  = "EXPIRED"
+)
+
+var (
+	// MembershipStatuses is a list of statuses, that paid memberships can be filtered by
+	MembershipStatuses = []string{
+		MembershipActive,
+		MembershipCanceled,
+		MembershipExpired,
+	}
+)
+
+var (
+	// ErrMembershipStatus is used when paid membership status isn't supported
+	ErrMembershipStatus = errors.Errorf("membership status must be one of: %s", strings.Join(MembershipStatuses, ", "))
+
+	// ErrBillingRange is used when start of billing period is after it's end
+	ErrBillingRange = errors.New("start of billing period must be before it's end")
+)
+
+// PaidMembership is a paid membership of a user in workspace or community, member gets PAID_MEMBER role while it's active
+type PaidMembership struct {
+	UUID       string      `json:"uuid"`
+	Workspace  string      `json:"workspace"` // uuid of workspace or community
+	User       *PublicUser `json:"user"`
+	Plan       string      `json:"plan,omitempty"` // title of membership plan, ex: Monthly
+	Status     string      `json:"status"`         // Enum: ACTIVE, CANCELED, EXPIRED
+	Amount     int         `json:"amount"`         // paid amount in minor units of currency, ex: cents
+	Currency   string      `json:"currency"`       // ISO 4217 code, ex: EUR
+	StartedAt  int         `json:"startedAt"`
+	ExpiresAt  int         `json:"expiresAt,omitempty"`
+	CanceledAt int         `json:"canceledAt,omitempty"`
+}
+
+// Purchase is a purchase of paid content (node) of workspace or community, buyer gets NODE_BUYER role for it
+type Purchase struct {
+	UUID        string      `json:"uuid"`
+	Workspace   string      `json:"workspace"` // uuid of workspace or community
+	Node        string      `json:"node"`      // uuid of bought library item
+	NodeType    string      `json:"nodeType,omitempty"`
+	NodeTitle   string      `json:"nodeTitle,omitempty"`
+	Buyer       *PublicUser `json:"buyer"`
+	Owner       *PublicUser `json:"owner,omitempty"` // NODE_OWNER of bought content
+	Amount      int         `json:"amount"`          // paid amount in minor units of currency, ex: cents
+	Currency    string      `json:"currency"`        // ISO 4217 code, ex: EUR
+	PurchasedAt int         `json:"purchasedAt"`
+	RefundedAt  int         `json:"refundedAt,omitempty"`
+}
+
+type ResponsePaidMemberships struct {
+	Data  []*PaidMembership `json:"data"`
+	Page  int               `json:"page,omitempty"`
+	Size  int               `json:"size,omitempty"`
+	Total int               `json:"total,omitempty"` // number of memberships on all pages
+}
+
+type ResponsePurchases struct {
+	Data  []*Purchase `json:"data"`
+	Page  int         `json:"page,omitempty"`
+	Size  int         `json:"size,omitempty"`
+	Total int         `json:"total,omitempty"` // number of purchases on all pages
+}
+
+// BillingFilter is used to filter and page paid memberships and purchases, empty fields are ignored
+type BillingFilter struct {
+	Since  time.Time // only memberships started or purchases made since
+	Until  time.Time // only memberships started or purchases made until
+	Status string    // status of paid memberships, ignored for purchases
+	Page   int       // number of page, starting from 1, zero means all pages
+	Size   int       // number of records on a page
+}
+
+// values converts filter to query values of a request for a page
+func (f *BillingFilter) values(page int) (url.Values, error) {
+	values := url.Values{}
+	if f == nil {
+		if page > 0 {
+			values.Set("page", strconv.Itoa(page))
+		}
+
+		return values, nil
+	}
+
+	if !f.Since.IsZero() && !f.Until.IsZero() && f.Since.After(f.Until) {
+		return nil, ErrBillingRange
+	}
+	if !f.Since.IsZero() {
+		values.Set("since", strconv.FormatInt(f.Since.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if !f.Until.IsZero() {
+		values.Set("until", strconv.FormatInt(f.Until.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if page > 0 {
+		values.Set("page", strconv.Itoa(page))
+	}
+	if f.Size > 0 {
+		values.Set("size", strconv.Itoa(f.Size))
+	}
+
+	return values, nil
+}
+
+// ListPaidMemberships lists paid memberships of workspace or community, only admins can list them, filter can be nil.
+// If page of filter isn't set, all pages are fetched. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/billing/memberships?since={since}&until={until}&status={status}&page={page}&size={size}
+//
+// method: get
+func ListPaidMemberships(workspaceUUID, token string, filter *BillingFilter) (*ResponsePaidMemberships, error) {
+	status := ""
+	if filter != nil && filter.Status != "" {
+		status = strings.ToUpper(filter.Status)
+		if !stringInSlice(status, MembershipStatuses) {
+			return nil, errors.Wrapf(ErrMembershipStatus, "%s", status)
+		}
+	}
+
+	all := &ResponsePaidMemberships{Data: make([]*PaidMembership, 0)}
+	err := fetchBillingPages(filter, func(page int) (int, int, int, error) {
+		values, err := filter.values(page)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if status != "" {
+			values.Set("status", status)
+		}
+
+		resp := &ResponsePaidMemberships{}
+		err = makeAPIRequest("GET", fmt.Sprintf("/v1/workspaces/%s/billing/memberships?%s", workspaceUUID, values.Encode()), token, nil, resp)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		all.Data = append(all.Data, resp.Data...)
+		all.Page, all.Size, all.Total = resp.Page, resp.Size, resp.Total
+
+		return len(resp.Data), resp.Size, resp.Total, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// ListPurchases lists purchases of paid content in workspace or community, only admins can list them, filter can be nil.
+// If page of filter isn't set, all pages are fetched. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/billing/purchases?since={since}&until={until}&page={page}&size={size}
+//
+// method: get
+func ListPurchases(workspaceUUID, token string, filter *BillingFilter) (*ResponsePurchases, error) {
+	all := &ResponsePurchases{Data: make([]*Purchase, 0)}
+	err := fetchBillingPages(filter, func(page int) (int, int, int, error) {
+		values, err := filter.values(page)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		resp := &ResponsePurchases{}
+		err = makeAPIRequest("GET", fmt.Sprintf("/v1/workspaces/%s/billing/purchases?%s", workspaceUUID, values.Encode()), token, nil, resp)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		all.Data = append(all.Data, resp.Data...)
+		all.Page, all.Size, all.Total = resp.Page, resp.Size, resp.Total
+
+		return len(resp.Data), resp.Size, resp.Total, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// fetchBillingPages fetches a page set by filter, or all pages one by one, until all records are fetched or an empty page is returned.
+// If server doesn't report total, pages are fetched until a page shorter than page size is returned
+func fetchBillingPages(filter *BillingFilter, fetch func(page int) (n, size, total int, err error)) error {
+	if filter != nil && filter.Page > 0 {
+		_, _, _, err := fetch(filter.Page)
+		return err
+	}
+
+	fetched := 0
+	for page := 1; ; page++ {
+		n, size, total, err := fetch(page)
+		if err != nil {
+			return errors.Wrapf(err, "fetching page %d", page)
+		}
+		if size == 0 && filter != nil {
+			size = filter.Size
+		}
+
+		fetched += n
+		if n == 0 || (total > 0 && fetched >= total) || (total == 0 && n < size) {
+			return nil
+		}
+	}
+}
+
+// currencyExponents are numbers of fraction digits of ISO 4217 currencies, that don't have 2 of them
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns number of fraction digits of ISO 4217 currency, ex: 2 for EUR, 0 for JPY, 3 for BHD.
+// Unknown currencies have 2 fraction digits
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+
+	return 2
+}
+
+// FormatAmount formats amount in minor units of currency as decimal with fraction digits of currency,
+// ex: 1250 EUR is 12.50, 1250 JPY is 1250 and 1250 BHD is 1.250
+func FormatAmount(amount int, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	exp := CurrencyExponent(currency)
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	unit := 1
+	for i := 0; i < exp; i++ {
+		unit *= 10
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exp, amount%unit)
+}
+
+// TotalAmounts sums amounts by their currency, refunded purchases aren't counted
+func TotalAmounts(memberships []*PaidMembership, purchases []*Purchase) map[string]int {
+	totals := make(map[string]int)
+	for _, m := range memberships {
+		totals[m.Currency] += m.Amount
+	}
+	for _, p := range purchases {
+		if p.RefundedAt == 0 {
+			totals[p.Currency] += p.Amount
+		}
+	}
+
+	return totals
+}
+
+// MembershipRecord is a paid membership flattened for reconciliation, dates are formatted as RFC3339
+type MembershipRecord struct {
+	UUID       string `csv:"uuid"`
+	Workspace  string `csv:"workspace"`
+	UserUUID   string `csv:"user_uuid"`
+	Username   string `csv:"username"`
+	Name       string `csv:"name"`
+	Plan       string `csv:"plan"`
+	Status     string `csv:"status"`
+	Amount     string `csv:"amount"`
+	Currency   string `csv:"currency"`
+	StartedAt  string `csv:"started_at"`
+	ExpiresAt  string `csv:"expires_at"`
+	CanceledAt string `csv:"canceled_at"`
+}
+
+// PurchaseRecord is a purchase flattened for reconciliation, dates are formatted as RFC3339
+type PurchaseRecord struct {
+	UUID        string `csv:"uuid"`
+	Workspace   string `csv:"workspace"`
+	Node        string `csv:"node"`
+	NodeType    string `csv:"node_type"`
+	NodeTitle   string `csv:"node_title"`
+	BuyerUUID   string `csv:"buyer_uuid"`
+	Buyer       string `csv:"buyer"`
+	OwnerUUID   string `csv:"owner_uuid"`
+	Owner       string `csv:"owner"`
+	Amount      string `csv:"amount"`
+	Currency    string `csv:"currency"`
+	PurchasedAt string `csv:"purchased_at"`
+	RefundedAt  string `csv:"refunded_at"`
+}
+
+// NewMembershipRecord converts paid membership to a record
+func NewMembershipRecord(m *PaidMembership) *MembershipRecord {
+	r := &MembershipRecord{
+		UUID:       m.UUID,
+		Workspace:  m.Workspace,
+		Plan:       m.Plan,
+		Status:     m.Status,
+		Amount:     FormatAmount(m.Amount, m.Currency),
+		Currency:   m.Currency,
+		StartedAt:  formatBillingTime(m.StartedAt),
+		ExpiresAt:  formatBillingTime(m.ExpiresAt),
+		CanceledAt: formatBillingTime(m.CanceledAt),
+	}
+	if m.User != nil {
+		r.UserUUID, r.Username, r.Name = m.User.UUID, m.User.UserName, m.User.FullName()
+	}
+
+	return r
+}
+
+// NewPurchaseRecord converts purchase to a record
+func NewPurchaseRecord(p *Purchase) *PurchaseRecord {
+	r := &PurchaseRecord{
+		UUID:        p.UUID,
+		Workspace:   p.Workspace,
+		Node:        p.Node,
+		NodeType:    p.NodeType,
+		NodeTitle:   p.NodeTitle,
+		Amount:      FormatAmount(p.Amount, p.Currency),
+		Currency:    p.Currency,
+		PurchasedAt: formatBillingTime(p.PurchasedAt),
+		RefundedAt:  formatBillingTime(p.RefundedAt),
+	}
+	if p.Buyer != nil {
+		r.BuyerUUID, r.Buyer = p.Buyer.UUID, p.Buyer.UserName
+	}
+	if p.Owner != nil {
+		r.OwnerUUID, r.Owner = p.Owner.UUID, p.Owner.UserName
+	}
+
+	return r
+}
+
+// WriteMembershipsCSV writes paid memberships as csv, header is written even if there are no memberships
+func WriteMembershipsCSV(w io.Writer, memberships []*PaidMembership) error {
+	records := make([]*MembershipRecord, 0, len(memberships))
+	for _, m := range memberships {
+		records = append(records, NewMembershipRecord(m))
+	}
+
+	return writeBillingCSV(w, MembershipRecord{}, records)
+}
+
+// WritePurchasesCSV writes purchases as csv, header is written even if there are no purchases
+func WritePurchasesCSV(w io.Writer, purchases []*Purchase) error {
+	records := make([]*PurchaseRecord, 0, len(purchases))
+	for _, p := range purchases {
+		records = append(records, NewPurchaseRecord(p))
+	}
+
+	return writeBillingCSV(w, PurchaseRecord{}, records)
+}
+
+// writeBillingCSV writes header of record type followed by records
+func writeBillingCSV(w io.Writer, record, records interface{}) error {
+	cw := csv.NewWriter(w)
+	enc := csvutil.NewEncoder(cw)
+
+	if err := enc.EncodeHeader(record); err != nil {
+		return errors.Wrap(err, "encoding header")
+	}
+	if err := enc.Encode(records); err != nil {
+		return errors.Wrap(err, "encoding records")
+	}
+	cw.Flush()
+
+	return errors.Wrap(cw.Error(), "flushing csv")
+}
+
+func formatBillingTime(ms int) string {
+	if ms == 0 {
+		return ""
+	}
+
+	return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,115 @@
+package crud
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Chat is a conversation between users, private chats have exactly two members
+type Chat struct {
+	UUID      string        `json:"uuid"`
+	Type      string        `json:"type"` // Enum: PRIVATE, GROUP
+	Title     string        `json:"title,omitempty"`
+	Members   []*PublicUser `json:"members,omitempty"`
+	CreatedAt int           `json:"createdAt,omitempty"`
+	UpdatedAt int           `json:"updatedAt,omitempty"`
+}
+
+type ResponseChat struct {
+	Data *Chat `json:"data"`
+}
+
+// GetDirectChat gets a private chat with a user, ErrNotFound is returned if there is no such chat. X-Auth-Token is needed for this request
+//
+// path: /v1/users/{user_uuid}/chat
+//
+// method: get
+func GetDirectChat(userUUID, token string) (*ResponseChat, error) {
+	resp := &ResponseChat{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/users/%s/chat", userUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CreateDirectChat creates a private chat with a user. X-Auth-Token is needed for this request
+//
+// path: /v1/users/{user_uuid}/chat
+//
+// method: post
+func CreateDirectChat(userUUID, token string) (*ResponseChat, error) {
+	resp := &ResponseChat{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/users/%s/chat", userUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// OpenDirectChat gets a private chat with a user, if there is no such chat then it's created.
+func OpenDirectChat(userUUID, token string) (*Chat, error) {
+	resp, err := GetDirectChat(userUUID, token)
+	if errors.Is(err, ErrNotFound) {
+		resp, err = CreateDirectChat(userUUID, token)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating chat")
+		}
+	} else if err != nil {
+		return nil, errors.Wrap(err, "getting chat")
+	}
+
+	return resp.Data, nil
+}
+
+// ChatUnread is a number of unread messages of a chat for current user
+type ChatUnread struct {
+	Chat          *Chat  `json:"chat"`
+	UnreadCount   int    `json:"unreadCount"`
+	LastReadAt    int    `json:"lastReadAt,omitempty"`    // timestamp of last read message
+	LastMessageAt int    `json:"lastMessageAt,omitempty"` // timestamp of last message in chat
+	LastMessage   string `json:"lastMessage,omitempty"`   // uuid of last message in chat
+}
+
+type ResponseChatUnreads struct {
+	Data []*ChatUnread `json:"data"`
+}
+
+// ListUnreadCounts lists numbers of unread messages of all chats of current user. X-Auth-Token is needed for this request
+//
+// path: /v1/chats/unread
+//
+// method: get
+func ListUnreadCounts(token string) (*ResponseChatUnreads, error) {
+	resp := &ResponseChatUnreads{}
+	err := makeAPIRequest("GET", "/v1/chats/unread", token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Unread returns only chats with unread messages, sorted as server returned them.
+func (r *ResponseChatUnreads) Unread() []*ChatUnread {
+	unread := make([]*ChatUnread, 0)
+	for _, c := range r.Data {
+		if c.UnreadCount > 0 {
+			unread = append(unread, c)
+		}
+	}
+
+	return unread
+}
+
+// MarkChatRead marks messages of a chat as read by current user, up to and including specified message. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{chat_uuid}/chat/{message_uuid}/read
+//
+// method: post
+func MarkChatRead(chatUUID, messageUUID, token string) error {
+	return makeAPIRequest("POST", fmt.Sprintf("/v1/workspaces/%s/chat/%s/read", chatUUID, messageUUID), token, nil, nil)
+}
@@ -0,0 +1,345 @@
+package crud
+
+import (
+	"math"
+	"reflect"
+	"unicode/utf8"
+)
+
+// Operations on deltas follow semantics of Quill Delta (https://github.com/quilljs/delta),
+// which is used by Acroplia textpad editor. Lengths are counted in runes.
+
+// types of delta operations
+const (
+	opInsert = "insert"
+	opDelete = "delete"
+	opRetain = "retain"
+)
+
+// opInfinity is a length of an operation past the end of a delta
+const opInfinity = math.MaxInt32
+
+// opType returns type of an operation
+func opType(op *Op) string {
+	if op.Delete > 0 {
+		return opDelete
+	} else if op.Retain > 0 {
+		return opRetain
+	}
+
+	return opInsert
+}
+
+// opLength returns length of an operation
+func opLength(op *Op) int {
+	switch opType(op) {
+	case opDelete:
+		return op.Delete
+	case opRetain:
+		return op.Retain
+	}
+
+	return utf8.RuneCountInString(op.Insert)
+}
+
+// opIterator walks through operations of a delta, splitting them by requested length
+type opIterator struct {
+	ops    []*Op
+	index  int
+	offset int
+}
+
+func newOpIterator(d *Delta) *opIterator {
+	it := &opIterator{}
+	if d != nil {
+		it.ops = d.Ops
+	}
+
+	return it
+}
+
+func (it *opIterator) hasNext() bool {
+	return it.peekLength() < opInfinity
+}
+
+func (it *opIterator) peekLength() int {
+	if it.index < len(it.ops) {
+		return opLength(it.ops[it.index]) - it.offset
+	}
+
+	return opInfinity
+}
+
+func (it *opIterator) peekType() string {
+	if it.index < len(it.ops) {
+		return opType(it.ops[it.index])
+	}
+
+	return opRetain
+}
+
+// next returns next operation, that is at most length long
+func (it *opIterator) next(length int) *Op {
+	if it.index >= len(it.ops) {
+		return &Op{Retain: opInfinity}
+	}
+
+	op := it.ops[it.index]
+	offset := it.offset
+	if opLen := opLength(op); length >= opLen-offset {
+		length = opLen - offset
+		it.index++
+		it.offset = 0
+	} else {
+		it.offset += length
+	}
+
+	switch opType(op) {
+	case opDelete:
+		return &Op{Delete: length}
+	case opRetain:
+		return &Op{Retain: length, Attributes: op.Attributes}
+	}
+
+	runes := []rune(op.Insert)
+	return &Op{Insert: string(runes[offset : offset+length]), Attributes: op.Attributes}
+}
+
+// NewDelta is a constructor for Delta.
+func NewDelta() *Delta {
+	return &Delta{Ops: make([]*Op, 0)}
+}
+
+// Insert appends insert operation to a delta.
+func (d *Delta) Insert(text string, attributes interface{}) *Delta {
+	if text == "" {
+		return d
+	}
+
+	return d.push(&Op{Insert: text, Attributes: attributes})
+}
+
+// Delete appends delete operation to a delta.
+func (d *Delta) Delete(length int) *Delta {
+	if length <= 0 {
+		return d
+	}
+
+	return d.push(&Op{Delete: length})
+}
+
+// Retain appends retain operation to a delta.
+func (d *Delta) Retain(length int, attributes interface{}) *Delta {
+	if length <= 0 {
+		return d
+	}
+
+	return d.push(&Op{Retain: length, Attributes: attributes})
+}
+
+// Length returns sum of lengths of all operations in a delta, for a document it's a length of it's text.
+func (d *Delta) Length() int {
+	if d == nil {
+		return 0
+	}
+
+	var length int
+	for _, op := range d.Ops {
+		length += opLength(op)
+	}
+
+	return length
+}
+
+// push appends a copy of operation to a delta, merging it with the last operation if possible
+func (d *Delta) push(newOp *Op) *Delta {
+	op := *newOp
+
+	index := len(d.Ops)
+	if index > 0 {
+		last := d.Ops[index-1]
+		if opType(&op) == opDelete && opType(last) == opDelete {
+			last.Delete += op.Delete
+			return d
+		}
+
+		// inserts always go before deletes
+		if opType(last) == opDelete && opType(&op) == opInsert {
+			index--
+			if index == 0 {
+				d.Ops = append([]*Op{&op}, d.Ops...)
+				return d
+			}
+			last = d.Ops[index-1]
+		}
+
+		if attributesEqual(op.Attributes, last.Attributes) {
+			if opType(&op) == opInsert && opType(last) == opInsert {
+				last.Insert += op.Insert
+				return d
+			} else if opType(&op) == opRetain && opType(last) == opRetain {
+				last.Retain += op.Retain
+				return d
+			}
+		}
+	}
+
+	d.Ops = append(d.Ops, nil)
+	copy(d.Ops[index+1:], d.Ops[index:])
+	d.Ops[index] = &op
+
+	return d
+}
+
+// chop removes trailing retain without attributes, as it doesn't change anything
+func (d *Delta) chop() *Delta {
+	if n := len(d.Ops); n > 0 {
+		last := d.Ops[n-1]
+		if opType(last) == opRetain && toAttributes(last.Attributes) == nil {
+			d.Ops = d.Ops[:n-1]
+		}
+	}
+
+	return d
+}
+
+// Compose returns a delta, that is equivalent to applying d and then other. Neither of deltas is changed.
+//
+// Composing a document with a change returns changed document.
+func (d *Delta) Compose(other *Delta) *Delta {
+	thisIter := newOpIterator(d)
+	otherIter := newOpIterator(other)
+	delta := NewDelta()
+
+	for thisIter.hasNext() || otherIter.hasNext() {
+		if otherIter.peekType() == opInsert {
+			delta.push(otherIter.next(opInfinity))
+		} else if thisIter.peekType() == opDelete {
+			delta.push(thisIter.next(opInfinity))
+		} else {
+			length := minInt(thisIter.peekLength(), otherIter.peekLength())
+			thisOp := thisIter.next(length)
+			otherOp := otherIter.next(length)
+
+			if opType(otherOp) == opRetain {
+				newOp := &Op{}
+				if opType(thisOp) == opRetain {
+					newOp.Retain = length
+				} else {
+					newOp.Insert = thisOp.Insert
+				}
+
+				if attrs := composeAttributes(thisOp.Attributes, otherOp.Attributes, opType(thisOp) == opRetain); attrs != nil {
+					newOp.Attributes = attrs
+				}
+				delta.push(newOp)
+			} else if opType(otherOp) == opDelete && opType(thisOp) == opRetain {
+				delta.push(otherOp)
+			}
+			// insert followed by delete of the same text cancel each other
+		}
+	}
+
+	return delta.chop()
+}
+
+// Transform returns other delta transformed against d, so it can be applied after d.
+//
+// If priority is true, then d is considered to happen first and it's inserts go before inserts of other at the same position.
+func (d *Delta) Transform(other *Delta, priority bool) *Delta {
+	thisIter := newOpIterator(d)
+	otherIter := newOpIterator(other)
+	delta := NewDelta()
+
+	for thisIter.hasNext() || otherIter.hasNext() {
+		if thisIter.peekType() == opInsert && (priority || otherIter.peekType() != opInsert) {
+			delta.Retain(opLength(thisIter.next(opInfinity)), nil)
+		} else if otherIter.peekType() == opInsert {
+			delta.push(otherIter.next(opInfinity))
+		} else {
+			length := minInt(thisIter.peekLength(), otherIter.peekLength())
+			thisOp := thisIter.next(length)
+			otherOp := otherIter.next(length)
+
+			if opType(thisOp) == opDelete {
+				// text is already deleted by d, so other's delete or retain is redundant
+				continue
+			} else if opType(otherOp) == opDelete {
+				delta.push(otherOp)
+			} else {
+				delta.Retain(length, transformAttributes(thisOp.Attributes, otherOp.Attributes, priority))
+			}
+		}
+	}
+
+	return delta.chop()
+}
+
+// toAttributes converts attributes of an operation to map, empty attributes are returned as nil
+func toAttributes(attributes interface{}) map[string]interface{} {
+	attrs, ok := attributes.(map[string]interface{})
+	if !ok || len(attrs) == 0 {
+		return nil
+	}
+
+	return attrs
+}
+
+func attributesEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(toAttributes(a), toAttributes(b))
+}
+
+// composeAttributes applies attributes b over attributes a, nil values of b remove attributes unless keepNull is true
+func composeAttributes(a, b interface{}, keepNull bool) interface{} {
+	attrsA, attrsB := toAttributes(a), toAttributes(b)
+
+	attrs := make(map[string]interface{})
+	for k, v := range attrsB {
+		if v != nil || keepNull {
+			attrs[k] = v
+		}
+	}
+	for k, v := range attrsA {
+		if _, ok := attrsB[k]; !ok && v != nil {
+			attrs[k] = v
+		}
+	}
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	return attrs
+}
+
+// transformAttributes transforms attributes b against attributes a, if a has priority then it's attributes win
+func transformAttributes(a, b interface{}, priority bool) interface{} {
+	attrsA, attrsB := toAttributes(a), toAttributes(b)
+	if attrsA == nil || attrsB == nil || !priority {
+		if attrsB == nil {
+			return nil
+		}
+		return attrsB
+	}
+
+	attrs := make(map[string]interface{})
+	for k, v := range attrsB {
+		if _, ok := attrsA[k]; !ok {
+			attrs[k] = v
+		}
+	}
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	return attrs
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,322 @@
+package crud
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// types of reactions to posts
+const (
+	ReactionLike  = "LIKE"
+	ReactionLove  = "LOVE"
+	ReactionLaugh = "LAUGH"
+	ReactionWow   = "WOW"
+	ReactionSad   = "SAD"
+	ReactionClap  = "CLAP"
+)
+
+var (
+	// Reactions is a list of reactions, that can be put on posts
+	Reactions = []string{
+		ReactionLike,
+		ReactionLove,
+		ReactionLaugh,
+		ReactionWow,
+		ReactionSad,
+		ReactionClap,
+	}
+)
+
+var (
+	// ErrPostEmpty is used when post or comment has neither text nor attachments
+	ErrPostEmpty = errors.New("post must have text or attachments")
+
+	// ErrReactionType is used when reaction isn't supported
+	ErrReactionType = errors.Errorf("reaction must be one of: %s", strings.Join(Reactions, ", "))
+)
+
+// mentionPattern matches @username mentions, username can't end with a dot, so a mention can end a sentence
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]*[\w-])`)
+
+// Reaction is a number of reactions of the same type on a post
+type Reaction struct {
+	Type  string `json:"type"` // Enum: LIKE, LOVE, LAUGH, WOW, SAD, CLAP
+	Count int    `json:"count"`
+	Mine  bool   `json:"mine,omitempty"` // current user has put this reaction
+}
+
+// Post is a payload of POST library item, posts are published to a feed of a workspace or community
+type Post struct {
+	Delta        *Delta       `json:"delta"`
+	Attachments  []*MediaItem `json:"attachments,omitempty"`
+	Mentions     []string     `json:"mentions,omitempty"` // uuids of mentioned users, they get MENTIONS notification
+	Reactions    []*Reaction  `json:"reactions,omitempty"`
+	CommentCount int          `json:"commentCount,omitempty"`
+}
+
+// Comment is a comment to a post
+type Comment struct {
+	UUID      string      `json:"uuid"`
+	User      *PublicUser `json:"user"`
+	Delta     *Delta      `json:"delta"`
+	Mentions  []string    `json:"mentions,omitempty"` // uuids of mentioned users
+	CreatedAt int         `json:"createdAt,omitempty"`
+	UpdatedAt int         `json:"updatedAt,omitempty"`
+}
+
+type ResponseComment struct {
+	Data *Comment `json:"data"`
+}
+
+type ResponseComments struct {
+	Data []*Comment `json:"data"`
+}
+
+// FeedQuery is used to page through a feed, empty fields are ignored
+type FeedQuery struct {
+	Before string // uuid of post, only older posts are returned
+	Limit  int    // maximum number of posts in a page
+}
+
+// ReactionCount returns number of reactions of a type on a post
+func (p *Post) ReactionCount(reaction string) int {
+	for _, r := range p.Reactions {
+		if r.Type == reaction {
+			return r.Count
+		}
+	}
+
+	return 0
+}
+
+// ParseMentions returns usernames mentioned in text by @username, each username is returned once, without @
+func ParseMentions(text string) []string {
+	usernames := make([]string, 0)
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if !stringInSlice(m[1], usernames) {
+			usernames = append(usernames, m[1])
+		}
+	}
+
+	return usernames
+}
+
+// ResolveMentions finds users mentioned in text by @username, unknown usernames are skipped and returned as unresolved,
+// so they are left as plain text, like @ in an email address
+func ResolveMentions(text, token string) (users []*PublicUser, unresolved []string, err error) {
+	usernames := ParseMentions(text)
+
+	users = make([]*PublicUser, 0, len(usernames))
+	for _, username := range usernames {
+		user, err := FindUserByUsername(username, token)
+		if errors.Is(err, ErrUserNotFound) {
+			unresolved = append(unresolved, username)
+			continue
+		} else if err != nil {
+			return nil, nil, errors.Wrapf(err, "@%s", username)
+		}
+		users = append(users, user)
+	}
+
+	return users, unresolved, nil
+}
+
+// NewMentionDelta builds a document delta from text, mentions of users are inserted with mention attribute,
+// holding uuid and username of a user. Mentions of other usernames are left as plain text
+func NewMentionDelta(text string, users []*PublicUser) *Delta {
+	delta := NewDelta()
+
+	last := 0
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		// mention starts with @ right before username
+		start, end := m[2]-1, m[3]
+
+		user := findMentioned(text[m[2]:m[3]], users)
+		if user == nil {
+			continue
+		}
+
+		delta.Insert(text[last:start], nil)
+		delta.Insert(text[start:end], map[string]interface{}{
+			"mention": map[string]interface{}{
+				"uuid":     user.UUID,
+				"username": user.UserName,
+			},
+		})
+		last = end
+	}
+	delta.Insert(text[last:], nil)
+
+	if !strings.HasSuffix(delta.Text(), "\n") {
+		delta.Insert("\n", nil)
+	}
+
+	return delta
+}
+
+// mentionedUUIDs returns uuids of users, that are mentioned in text
+func mentionedUUIDs(text string, users []*PublicUser) []string {
+	uuids := make([]string, 0, len(users))
+	for _, username := range ParseMentions(text) {
+		if user := findMentioned(username, users); user != nil && !stringInSlice(user.UUID, uuids) {
+			uuids = append(uuids, user.UUID)
+		}
+	}
+
+	return uuids
+}
+
+func findMentioned(username string, users []*PublicUser) *PublicUser {
+	for _, user := range users {
+		if strings.EqualFold(user.UserName, username) {
+			return user
+		}
+	}
+
+	return nil
+}
+
+// NewPost is a constructor for POST library item, mentions are users resolved by ResolveMentions and can be nil
+func NewPost(text string, mentions []*PublicUser, attachments []*MediaItem, user *PrivateUser) (*LibraryItem, error) {
+	if strings.TrimSpace(text) == "" && len(attachments) == 0 {
+		return nil, ErrPostEmpty
+	}
+
+	return &LibraryItem{
+		Type:  LibraryPost,
+		UUID:  uuid.New().String(),
+		User:  user.ToPublic(),
+		Owner: user.UUID,
+		Post: &Post{
+			Delta:       NewMentionDelta(text, mentions),
+			Attachments: attachments,
+			Mentions:    mentionedUUIDs(text, mentions),
+		},
+	}, nil
+}
+
+// NewComment is a constructor for Comment, mentions are users resolved by ResolveMentions and can be nil
+func NewComment(text string, mentions []*PublicUser, user *PrivateUser) (*Comment, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("comment text can't be empty")
+	}
+
+	return &Comment{
+		UUID:     uuid.New().String(),
+		User:     user.ToPublic(),
+		Delta:    NewMentionDelta(text, mentions),
+		Mentions: mentionedUUIDs(text, mentions),
+	}, nil
+}
+
+// PublishPost publishes a post to a feed of workspace or community, members get GROUP_ADD_CONTENT notification.
+// X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/feed
+//
+// method: post
+func PublishPost(workspaceUUID string, post *LibraryItem, token string) (*ResponseLibraryItem, error) {
+	if post.Type != LibraryPost || post.Post == nil {
+		return nil, errors.Wrapf(ErrLibraryType, "%s isn't a post", post.Type)
+	}
+
+	resp := &ResponseLibraryItem{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/workspaces/%s/feed", workspaceUUID), token, post, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListFeed lists a page of posts in a feed of workspace or community, posts are sorted from newest to oldest.
+// X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/feed?before={uuid}&limit={limit}
+//
+// method: get
+func ListFeed(workspaceUUID string, query *FeedQuery, token string) (*ResponseLibraryItems, error) {
+	values := url.Values{}
+	if query != nil {
+		if query.Before != "" {
+			values.Set("before", query.Before)
+		}
+		if query.Limit > 0 {
+			values.Set("limit", strconv.Itoa(query.Limit))
+		}
+	}
+
+	path := fmt.Sprintf("/v1/workspaces/%s/feed", workspaceUUID)
+	if len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+
+	resp := &ResponseLibraryItems{}
+	err := makeAPIRequest("GET", path, token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// AddComment adds a comment to a post, author of a post gets notification. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}/comments
+//
+// method: post
+func AddComment(postUUID string, comment *Comment, token string) (*ResponseComment, error) {
+	resp := &ResponseComment{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/library/items/%s/comments", postUUID), token, comment, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListComments lists comments of a post, from oldest to newest. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}/comments
+//
+// method: get
+func ListComments(postUUID, token string) (*ResponseComments, error) {
+	resp := &ResponseComments{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/library/items/%s/comments", postUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// React puts or removes a reaction of current user on a post. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}/reactions/{reaction}
+//
+// method: put to react, delete to remove reaction
+func React(postUUID, reaction string, remove bool, token string) (*ResponseLibraryItem, error) {
+	reaction = strings.ToUpper(reaction)
+	if !stringInSlice(reaction, Reactions) {
+		return nil, errors.Wrapf(ErrReactionType, "%s", reaction)
+	}
+
+	method := "PUT"
+	if remove {
+		method = "DELETE"
+	}
+
+	resp := &ResponseLibraryItem{}
+	err := makeAPIRequest(method, fmt.Sprintf("/v1/library/items/%s/reactions/%s", postUUID, reaction), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,42 @@
+package crud
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrLanguageNotFound is used when language with specified iso code isn't supported by Acroplia
+	ErrLanguageNotFound = errors.New("language not supported")
+)
+
+type ResponseLanguages struct {
+	Data []*Language `json:"data"`
+}
+
+// ListLanguages lists languages supported by Acroplia. X-Auth-Token is needed for this request
+//
+// path: /v1/languages
+//
+// method: get
+func ListLanguages(token string) (*ResponseLanguages, error) {
+	resp := &ResponseLanguages{}
+	err := makeAPIRequest("GET", "/v1/languages", token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// FindLanguage finds a language by it's iso code, case of iso code is ignored.
+func (r *ResponseLanguages) FindLanguage(isoCode string) (*Language, error) {
+	for _, lang := range r.Data {
+		if strings.EqualFold(lang.ISOCode, isoCode) {
+			return lang, nil
+		}
+	}
+
+	return nil, errors.Wrapf(ErrLanguageNotFound, "%s", isoCode)
+}
@@ -0,0 +1,311 @@
+package crud
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Types of library items
+const (
+	LibraryUndefined      = "UNDEFINED"
+	LibraryTest           = "TEST"
+	LibraryTextpad        = "TEXTPAD"
+	LibraryCanvas         = "CANVAS"
+	LibraryCanvasDocument = "CANVAS_DOCUMENT"
+	LibraryFolder         = "FOLDER"
+	LibraryCollection     = "COLLECTION"
+	LibraryLink           = "LINK"
+	LibraryWiki           = "WIKI"
+	LibraryTaskList       = "TASK_LIST"
+	LibraryPost           = "POST"
+)
+
+var (
+	// CreatableLibraryTypes is a list of library item types, that can be created by the tool
+	CreatableLibraryTypes = []string{
+		LibraryTextpad,
+		LibraryFolder,
+		LibraryCollection,
+		LibraryLink,
+		LibraryTaskList,
+	}
+)
+
+var (
+	// ErrLibraryType is used when library item type isn't supported by the tool
+	ErrLibraryType = errors.New("unsupported library item type")
+
+	// ErrLibraryParent is used when parent of library item isn't a folder
+	ErrLibraryParent = errors.New("parent of library item must be a folder")
+)
+
+// Link is a payload of LINK library item
+type Link struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// TaskListItem is a single task in TASK_LIST library item
+type TaskListItem struct {
+	UUID string `json:"uuid"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// TaskList is a payload of TASK_LIST library item
+type TaskList struct {
+	Items []*TaskListItem `json:"items"`
+}
+
+// Collection is a payload of COLLECTION library item, it holds uuids of collected library items
+type Collection struct {
+	Items []string `json:"items"`
+}
+
+// LibraryItem is an item stored in user's library, payload of item depends on it's type
+type LibraryItem struct {
+	Type           string      `json:"type"` // Enum: [ UNDEFINED, TEST, TEXTPAD, CANVAS, CANVAS_DOCUMENT, FOLDER, COLLECTION, LINK, WIKI, TASK_LIST, POST ]
+	UUID           string      `json:"uuid"`
+	Title          string      `json:"title"`
+	Subtitle       string      `json:"subTitle"`
+	User           *PublicUser `json:"user"`
+	Owner          string      `json:"owner"`
+	CreatedAt      int         `json:"createdAt,omitempty"`
+	UpdatedAt      int         `json:"updatedAt,omitempty"`
+	ImageMediaItem *MediaItem  `json:"imageMediaItem,omitempty"`
+	Tags           []string    `json:"tags,omitempty"`
+	Lang           *Language   `json:"language,omitempty"`
+	Version        int         `json:"version,omitempty"`
+	Path           []*PathItem `json:"path,omitempty"`
+
+	Delta      *Delta      `json:"delta,omitempty"`      // TEXTPAD
+	Link       *Link       `json:"link,omitempty"`       // LINK
+	TaskList   *TaskList   `json:"taskList,omitempty"`   // TASK_LIST
+	Collection *Collection `json:"collection,omitempty"` // COLLECTION
+	Quiz       *Quiz       `json:"test,omitempty"`       // TEST
+	Post       *Post       `json:"post,omitempty"`       // POST
+}
+
+type ResponseLibraryItem struct {
+	Data *LibraryItem `json:"data"`
+}
+
+type ResponseLibraryItems struct {
+	Data []*LibraryItem `json:"data"`
+}
+
+// LibraryFilter is used to filter library items when listing them, empty fields are ignored
+type LibraryFilter struct {
+	Type   string
+	Parent string // uuid of parent folder
+}
+
+// NewLibraryItem is a constructor for LibraryItem, it sets an empty payload based on item type.
+func NewLibraryItem(itemType, title, subtitle string, user *PrivateUser) (*LibraryItem, error) {
+	item := &LibraryItem{
+		Type:     itemType,
+		UUID:     uuid.New().String(),
+		Title:    title,
+		Subtitle: subtitle,
+		User:     user.ToPublic(),
+		Owner:    user.UUID,
+	}
+
+	switch itemType {
+	case LibraryTextpad:
+		item.Delta = &Delta{
+			Ops: []*Op{
+				{
+					Insert: "\n",
+				},
+			},
+		}
+	case LibraryFolder:
+	case LibraryCollection:
+		item.Collection = &Collection{Items: make([]string, 0)}
+	case LibraryLink:
+		item.Link = &Link{}
+	case LibraryTaskList:
+		item.TaskList = &TaskList{Items: make([]*TaskListItem, 0)}
+	default:
+		return nil, errors.Wrapf(ErrLibraryType, "%s", itemType)
+	}
+
+	return item, nil
+}
+
+// NewFolder is a constructor for FOLDER library item.
+func NewFolder(title, subtitle string, user *PrivateUser) *LibraryItem {
+	item, _ := NewLibraryItem(LibraryFolder, title, subtitle, user)
+	return item
+}
+
+// NewCollection is a constructor for COLLECTION library item, items are uuids of library items.
+func NewCollection(title, subtitle string, items []string, user *PrivateUser) *LibraryItem {
+	item, _ := NewLibraryItem(LibraryCollection, title, subtitle, user)
+	item.Collection.Items = append(item.Collection.Items, items...)
+	return item
+}
+
+// NewLink is a constructor for LINK library item.
+func NewLink(title, subtitle, link string, user *PrivateUser) *LibraryItem {
+	item, _ := NewLibraryItem(LibraryLink, title, subtitle, user)
+	item.Link.URL = link
+	return item
+}
+
+// NewTaskList is a constructor for TASK_LIST library item, each task is added as not done.
+func NewTaskList(title, subtitle string, tasks []string, user *PrivateUser) *LibraryItem {
+	item, _ := NewLibraryItem(LibraryTaskList, title, subtitle, user)
+	for _, task := range tasks {
+		item.TaskList.Items = append(item.TaskList.Items, &TaskListItem{
+			UUID: uuid.New().String(),
+			Text: task,
+		})
+	}
+	return item
+}
+
+// SetParent nests library item under parent folder, by setting it's path to parent's path followed by parent itself.
+func (l *LibraryItem) SetParent(parent *LibraryItem) error {
+	if parent.Type != LibraryFolder {
+		return ErrLibraryParent
+	}
+
+	l.Path = make([]*PathItem, 0, len(parent.Path)+1)
+	l.Path = append(l.Path, parent.Path...)
+	l.Path = append(l.Path, &PathItem{
+		UUID:  parent.UUID,
+		Title: parent.Title,
+	})
+
+	return nil
+}
+
+// Create creates a library item. X-Auth-Token is needed for this request
+//
+// path: /v1/library/{user_uuid}
+//
+// method: post
+func (l *LibraryItem) Create(token string) (*ResponseLibraryItem, error) {
+	resp := &ResponseLibraryItem{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/library/%s", l.User.UUID), token, l, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SetTags replaces tags of library item, tags are trimmed and duplicate or empty tags are dropped.
+func (l *LibraryItem) SetTags(tags []string) {
+	l.Tags = make([]string, 0, len(tags))
+
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+
+		l.Tags = append(l.Tags, tag)
+	}
+}
+
+// AddTags adds tags to library item, tags that item already has are skipped.
+func (l *LibraryItem) AddTags(tags ...string) {
+	l.SetTags(append(l.Tags, tags...))
+}
+
+// RemoveTags removes tags from library item.
+func (l *LibraryItem) RemoveTags(tags ...string) {
+	remove := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		remove[strings.TrimSpace(tag)] = true
+	}
+
+	kept := make([]string, 0, len(l.Tags))
+	for _, tag := range l.Tags {
+		if !remove[tag] {
+			kept = append(kept, tag)
+		}
+	}
+	l.Tags = kept
+}
+
+// libraryItemUpdate is a body of update request, tags set by SetTags, AddTags or RemoveTags are sent even if they are empty,
+// so last tag can be removed, while item with nil tags keeps tags it has
+type libraryItemUpdate struct {
+	*LibraryItem
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// Update updates title, subtitle, tags, language and payload of a library item. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}
+//
+// method: put
+func (l *LibraryItem) Update(token string) (*ResponseLibraryItem, error) {
+	body := &libraryItemUpdate{LibraryItem: l}
+	if l.Tags != nil {
+		body.Tags = &l.Tags
+	}
+
+	resp := &ResponseLibraryItem{}
+	err := makeAPIRequest("PUT", fmt.Sprintf("/v1/library/items/%s", l.UUID), token, body, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetLibraryItem gets a library item by it's uuid. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}
+//
+// method: get
+func GetLibraryItem(itemUUID, token string) (*ResponseLibraryItem, error) {
+	resp := &ResponseLibraryItem{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/library/items/%s", itemUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListLibrary lists library items of a user, filter can be nil. X-Auth-Token is needed for this request
+//
+// path: /v1/library/{user_uuid}
+//
+// method: get
+func ListLibrary(userUUID, token string, filter *LibraryFilter) (*ResponseLibraryItems, error) {
+	query := url.Values{}
+	if filter != nil {
+		if filter.Type != "" {
+			query.Set("type", filter.Type)
+		}
+		if filter.Parent != "" {
+			query.Set("parent", filter.Parent)
+		}
+	}
+
+	path := fmt.Sprintf("/v1/library/%s", userUUID)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp := &ResponseLibraryItems{}
+	err := makeAPIRequest("GET", path, token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -16,8 +16,6 @@ const (
 	Undefined = platform("UNDEFINED")
 	IOS       = platform("IOS")
 	Android   = platform("ANDROID")
-
-	acropliaAPIURL = "https://api-stage.acroplia.com/api"
 )
 
 var (
@@ -0,0 +1,202 @@
+package crud
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// types of media items
+const (
+	MediaImage    = "IMAGE"
+	MediaAudio    = "AUDIO"
+	MediaVideo    = "VIDEO"
+	MediaDocument = "DOCUMENT"
+)
+
+type ResponseMediaItem struct {
+	Data *MediaItem `json:"data"`
+}
+
+// mediaExtensions are common media extensions, that may be missing in system's mime types
+var mediaExtensions = map[string]string{
+	".jpg":  MediaImage,
+	".jpeg": MediaImage,
+	".png":  MediaImage,
+	".gif":  MediaImage,
+	".mp3":  MediaAudio,
+	".m4a":  MediaAudio,
+	".ogg":  MediaAudio,
+	".wav":  MediaAudio,
+	".mp4":  MediaVideo,
+	".mov":  MediaVideo,
+	".webm": MediaVideo,
+}
+
+// MediaType returns type of media item for a file, based on it's extension. Unknown files are documents.
+func MediaType(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if mediaType, ok := mediaExtensions[ext]; ok {
+		return mediaType
+	}
+
+	contentType := mime.TypeByExtension(ext)
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return MediaImage
+	case strings.HasPrefix(contentType, "audio/"):
+		return MediaAudio
+	case strings.HasPrefix(contentType, "video/"):
+		return MediaVideo
+	}
+
+	return MediaDocument
+}
+
+// FileName returns original file name of a media item, if it's known.
+func (m *MediaItem) FileName() string {
+	if m.Data == nil || m.Data.Source == nil {
+		return ""
+	}
+
+	return m.Data.Source.OriginalFileName
+}
+
+// FileSize returns size of a media item in bytes, if it's known.
+func (m *MediaItem) FileSize() int {
+	if m.Data == nil || m.Data.Source == nil {
+		return 0
+	}
+
+	return m.Data.Source.FileSize
+}
+
+// UploadMedia uploads a local file to Acroplia, uploaded media item can be attached to messages. X-Auth-Token is needed for this request
+//
+// path: /v1/media
+//
+// method: post, multipart/form-data with type and file fields
+func UploadMedia(path, token string) (*ResponseMediaItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening file")
+	}
+
+	// file is streamed to request body, so it isn't read into memory at once
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		defer f.Close()
+
+		pw.CloseWithError(writeMediaForm(mw, f, path))
+	}()
+
+	// uploading takes more time than other requests
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", acropliaAPIURL+"/v1/media", pr)
+	if err != nil {
+		pr.Close()
+		return nil, errors.Wrap(err, "creating new post request")
+	}
+
+	req.Header = http.Header{
+		"Content-Type": []string{mw.FormDataContentType()},
+		"X-Auth-Token": []string{token},
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "making post request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, errors.Errorf("request failed with status code %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	mediaResponse := &ResponseMediaItem{}
+	err = json.NewDecoder(resp.Body).Decode(mediaResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding response")
+	}
+
+	return mediaResponse, nil
+}
+
+// writeMediaForm writes type and file fields of media upload form and closes it
+func writeMediaForm(mw *multipart.Writer, f io.Reader, path string) error {
+	if err := mw.WriteField("type", MediaType(path)); err != nil {
+		return errors.Wrap(err, "writing type field")
+	}
+
+	fw, err := mw.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return errors.Wrap(err, "creating file field")
+	}
+	if _, err := io.Copy(fw, f); err != nil {
+		return errors.Wrap(err, "reading file")
+	}
+
+	return errors.Wrap(mw.Close(), "closing multipart writer")
+}
+
+// DownloadMedia downloads media file from url to a local file. X-Auth-Token is sent only if url is on Acroplia API host,
+// as media of private chats may need it, media on other hosts, ex: CDN, never gets it
+func DownloadMedia(mediaURL, path, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", mediaURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "creating new get request")
+	}
+	if token != "" && isAPIURL(req.URL) {
+		req.Header.Set("X-Auth-Token", token)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "making get request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return errors.Errorf("request failed with status code %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "creating file")
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return errors.Wrap(err, "writing file")
+	}
+
+	return errors.Wrap(f.Close(), "closing file")
+}
+
+// isAPIURL reports whether u has the same scheme and host as Acroplia API
+func isAPIURL(u *url.URL) bool {
+	api, err := url.Parse(acropliaAPIURL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Scheme, api.Scheme) && strings.EqualFold(u.Host, api.Host)
+}
@@ -0,0 +1,183 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GrantableRoles are roles, that admins can grant to or revoke from members of a workspace or community
+var GrantableRoles = []string{
+	RoleWorkspaceGuest,
+	RoleWorkspaceMember,
+	RoleWorkspaceAdmin,
+	RoleCommunityGuest,
+	RoleCommunityMember,
+	RoleCommunityAdmin,
+	RoleCommunityTaskManager,
+	RoleModerator,
+}
+
+var (
+	// ErrRoleType is used when role type can't be granted to a member
+	ErrRoleType = errors.Errorf("role must be one of: %s", strings.Join(GrantableRoles, ", "))
+
+	// ErrInvitationEmpty is used when neither email nor username of invited user is set
+	ErrInvitationEmpty = errors.New("email or username of invited user must be set")
+)
+
+// Member is a user, that is a member of a workspace or community, with roles in it
+type Member struct {
+	User     *PublicUser `json:"user"`
+	Email    string      `json:"email,omitempty"` // visible only to admins
+	Roles    []*Role     `json:"roles"`
+	JoinedAt int         `json:"joinedAt,omitempty"`
+}
+
+type ResponseMember struct {
+	Data *Member `json:"data"`
+}
+
+type ResponseMembers struct {
+	Data []*Member `json:"data"`
+}
+
+// HasRole checks if member has a role of roleType
+func (m *Member) HasRole(roleType string) bool {
+	for _, role := range m.Roles {
+		if role.Type == roleType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoleTypes returns types of member roles
+func (m *Member) RoleTypes() []string {
+	types := make([]string, 0, len(m.Roles))
+	for _, role := range m.Roles {
+		types = append(types, role.Type)
+	}
+
+	return types
+}
+
+// ValidateRole checks if roleType can be granted to a member, before sending it to Acroplia
+func ValidateRole(roleType string) error {
+	for _, role := range GrantableRoles {
+		if role == roleType {
+			return nil
+		}
+	}
+
+	return ErrRoleType
+}
+
+// Invitation is an invite of a user to a workspace or community, either by email or by username
+type Invitation struct {
+	Email    string `json:"email,omitempty"`
+	UserName string `json:"userName,omitempty"`
+	Role     string `json:"role,omitempty"` // role of user after joining, by default member role of workspace type
+}
+
+// NewInvitation is a constructor for Invitation, user is an email or a username with or without leading @
+func NewInvitation(user, roleType string) (*Invitation, error) {
+	user = strings.TrimSpace(user)
+	if user == "" || user == "@" {
+		return nil, ErrInvitationEmpty
+	}
+
+	if roleType != "" {
+		if err := ValidateRole(roleType); err != nil {
+			return nil, err
+		}
+	}
+
+	invitation := &Invitation{Role: roleType}
+	if !strings.HasPrefix(user, "@") && strings.Contains(user, "@") {
+		invitation.Email = user
+	} else {
+		invitation.UserName = strings.TrimPrefix(user, "@")
+	}
+
+	return invitation, nil
+}
+
+// ListMembers lists members of a workspace or community with their roles. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/members
+//
+// method: get
+func ListMembers(workspaceUUID, token string) (*ResponseMembers, error) {
+	resp := &ResponseMembers{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/workspaces/%s/members", workspaceUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// InviteMember invites a user to a workspace or community, only admins can invite. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/members/invite
+//
+// method: post
+func InviteMember(workspaceUUID string, invitation *Invitation, token string) (*ResponseMember, error) {
+	resp := &ResponseMember{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/workspaces/%s/members/invite", workspaceUUID), token, invitation, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// RemoveMember removes a user from a workspace or community, only admins can remove members. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/members/{user_uuid}
+//
+// method: delete
+func RemoveMember(workspaceUUID, userUUID, token string) error {
+	return makeAPIRequest("DELETE", fmt.Sprintf("/v1/workspaces/%s/members/%s", workspaceUUID, userUUID), token, nil, nil)
+}
+
+// GrantRole grants a role to a member of a workspace or community, only admins can grant roles. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/members/{user_uuid}/roles
+//
+// method: post
+func GrantRole(workspaceUUID, userUUID, roleType, token string) (*ResponseMember, error) {
+	if err := ValidateRole(roleType); err != nil {
+		return nil, err
+	}
+
+	resp := &ResponseMember{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/workspaces/%s/members/%s/roles", workspaceUUID, userUUID), token, &Role{Type: roleType}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// RevokeRole revokes a role from a member of a workspace or community, only admins can revoke roles. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/members/{user_uuid}/roles/{role}
+//
+// method: delete
+func RevokeRole(workspaceUUID, userUUID, roleType, token string) (*ResponseMember, error) {
+	if err := ValidateRole(roleType); err != nil {
+		return nil, err
+	}
+
+	resp := &ResponseMember{}
+	err := makeAPIRequest("DELETE", fmt.Sprintf("/v1/workspaces/%s/members/%s/roles/%s", workspaceUUID, userUUID, roleType), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,7 +28,7 @@ type UserMessageEntity struct {
 	CreatedAt      int            `json:"createdAt,omitempty"`
 	UpdatedAt      int            `json:"updatedAt,omitempty"`
 	ReadMarks      map[string]int `json:"readMarks,omitempty"`
-	Attachments    []struct{}     `json:"attachments"`
+	Attachments    []*MediaItem   `json:"attachments"`
 	ReplyToMessage string         `json:"replyToMessage,omitempty"`
 }
 
@@ -36,6 +36,11 @@ type RespUserMessageEntity struct {
 	Data *UserMessageEntity `json:"data"`
 }
 
+// ReadAt returns timestamp, when a user has read a message, 0 means message isn't read by the user
+func (m *UserMessageEntity) ReadAt(userUUID string) int {
+	return m.ReadMarks[userUUID]
+}
+
 // NewMessage is a constructor for message to be sent in Acroplia
 func NewMessage(text string, user *PrivateUser) *UserMessageEntity {
 	return &UserMessageEntity{
@@ -44,10 +49,19 @@ func NewMessage(text string, user *PrivateUser) *UserMessageEntity {
 		Status:      "SENDING",
 		User:        user.ToPublic(),
 		Text:        text,
-		Attachments: make([]struct{}, 0),
+		Attachments: make([]*MediaItem, 0),
 	}
 }
 
+// NewMediaMessage is a constructor for message with attachments, text is a caption and can be empty
+func NewMediaMessage(text string, attachments []*MediaItem, user *PrivateUser) *UserMessageEntity {
+	m := NewMessage(text, user)
+	m.Type = "USER_MEDIA"
+	m.Attachments = attachments
+
+	return m
+}
+
 // SendMessage sends message to specified chat uuid. X-Auth-Token is needed for this request.
 //
 // path: /v1/workspaces/{chat_uuid}/chat
@@ -85,8 +99,7 @@ func (m *UserMessageEntity) SendMessage(chatUUID string, token string) (*RespUse
 
 	if resp.StatusCode == 500 {
 		return nil, ErrMessageInternal
-	} else if resp.StatusCode != 500 {
-		io.Copy(os.Stdout, resp.Body)
+	} else if resp.StatusCode != 200 {
 		return nil, errors.Errorf("request failed with status code %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
@@ -98,3 +111,181 @@ func (m *UserMessageEntity) SendMessage(chatUUID string, token string) (*RespUse
 
 	return messageResponse, nil
 }
+
+// NewReply is a constructor for message, that replies to another message
+func NewReply(text, replyTo string, user *PrivateUser) *UserMessageEntity {
+	m := NewMessage(text, user)
+	m.ReplyToMessage = replyTo
+
+	return m
+}
+
+// GetMessage gets a message of a chat. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{chat_uuid}/chat/{message_uuid}
+//
+// method: get
+func GetMessage(chatUUID, messageUUID, token string) (*RespUserMessageEntity, error) {
+	resp := &RespUserMessageEntity{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/workspaces/%s/chat/%s", chatUUID, messageUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// EditMessage changes text of a message, only author of a message can edit it, otherwise ErrForbidden is returned. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{chat_uuid}/chat/{message_uuid}
+//
+// method: put
+func EditMessage(chatUUID, messageUUID, text, token string) (*RespUserMessageEntity, error) {
+	data := struct {
+		Text string `json:"text"`
+	}{text}
+
+	resp := &RespUserMessageEntity{}
+	err := makeAPIRequest("PUT", fmt.Sprintf("/v1/workspaces/%s/chat/%s", chatUUID, messageUUID), token, data, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DeleteMessage deletes a message, it stays in chat history with DELETED type.
+// Only author of a message can delete it, otherwise ErrForbidden is returned. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{chat_uuid}/chat/{message_uuid}
+//
+// method: delete
+func DeleteMessage(chatUUID, messageUUID, token string) error {
+	return makeAPIRequest("DELETE", fmt.Sprintf("/v1/workspaces/%s/chat/%s", chatUUID, messageUUID), token, nil, nil)
+}
+
+type ResponseUserMessageEntities struct {
+	Data []*UserMessageEntity `json:"data"`
+}
+
+// MessageQuery is used to page through chat history, empty fields are ignored
+type MessageQuery struct {
+	Before string // uuid of message, only older messages are returned
+	After  string // uuid of message, only newer messages are returned
+	Limit  int    // maximum number of messages in a page
+}
+
+// ListMessages lists a page of chat history, messages are sorted from newest to oldest. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{chat_uuid}/chat?before={uuid}&after={uuid}&limit={limit}
+//
+// method: get
+func ListMessages(chatUUID string, query *MessageQuery, token string) (*ResponseUserMessageEntities, error) {
+	values := url.Values{}
+	if query != nil {
+		if query.Before != "" {
+			values.Set("before", query.Before)
+		}
+		if query.After != "" {
+			values.Set("after", query.After)
+		}
+		if query.Limit > 0 {
+			values.Set("limit", strconv.Itoa(query.Limit))
+		}
+	}
+
+	path := fmt.Sprintf("/v1/workspaces/%s/chat", chatUUID)
+	if len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+
+	resp := &ResponseUserMessageEntities{}
+	err := makeAPIRequest("GET", path, token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// MessageIterator iterates over chat history from newest to oldest message, pages are fetched transparently.
+//
+//	it := crud.NewMessageIterator(chatUUID, token)
+//	for it.Next() {
+//		msg := it.Message()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type MessageIterator struct {
+	ChatUUID string
+	PageSize int       // number of messages fetched at once
+	Since    time.Time // iteration stops at first message older than since, zero value means whole history
+	Until    time.Time // messages newer than until are skipped, zero value means now
+
+	token  string
+	page   []*UserMessageEntity
+	before string
+	last   bool
+	msg    *UserMessageEntity
+	err    error
+}
+
+// NewMessageIterator is a constructor for MessageIterator.
+func NewMessageIterator(chatUUID, token string) *MessageIterator {
+	return &MessageIterator{
+		ChatUUID: chatUUID,
+		PageSize: 50,
+		token:    token,
+	}
+}
+
+// Next advances iterator to next message, it returns false when there are no more messages or an error occurred.
+func (it *MessageIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+
+		if len(it.page) == 0 {
+			if it.last {
+				return false
+			}
+
+			resp, err := ListMessages(it.ChatUUID, &MessageQuery{Before: it.before, Limit: it.PageSize}, it.token)
+			if err != nil {
+				it.err = errors.Wrap(err, "listing messages")
+				return false
+			}
+
+			it.page = resp.Data
+			it.last = len(resp.Data) < it.PageSize
+			if len(it.page) == 0 {
+				return false
+			}
+			it.before = it.page[len(it.page)-1].UUID
+		}
+
+		it.msg, it.page = it.page[0], it.page[1:]
+
+		created := time.Unix(0, int64(it.msg.CreatedAt)*int64(time.Millisecond))
+		if !it.Since.IsZero() && created.Before(it.Since) {
+			it.page, it.last = nil, true
+			return false
+		}
+		if !it.Until.IsZero() && created.After(it.Until) {
+			continue
+		}
+
+		return true
+	}
+}
+
+// Message returns current message of iterator.
+func (it *MessageIterator) Message() *UserMessageEntity {
+	return it.msg
+}
+
+// Err returns an error, that stopped iteration.
+func (it *MessageIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,263 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/jszwec/csvutil"
+	"github.com/pkg/errors"
+)
+
+// statuses of broadcast delivery
+const (
+	BroadcastSent   = "SENT"
+	BroadcastFailed = "FAILED"
+	BroadcastDryRun = "DRY_RUN"
+)
+
+// BroadcastRecipient is a recipient of broadcast read from csv file.
+//
+// Recipient is identified by chat_uuid, username or fullname column, in that order. All columns are available in message template.
+type BroadcastRecipient struct {
+	Row    int // number of row in csv file, starting from 1
+	Fields map[string]string
+}
+
+// Name returns the way recipient is identified, it's used in reports
+func (r *BroadcastRecipient) Name() string {
+	for _, column := range []string{"chat_uuid", "username", "fullname"} {
+		if v := r.Fields[column]; v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// BroadcastResult is an outcome of sending broadcast message to a recipient
+type BroadcastResult struct {
+	Row         int    `csv:"row" json:"row"`
+	Recipient   string `csv:"recipient" json:"recipient"`
+	ChatUUID    string `csv:"chat_uuid" json:"chatUuid,omitempty"`
+	MessageUUID string `csv:"message_uuid" json:"messageUuid,omitempty"`
+	Status      string `csv:"status" json:"status"` // Enum: SENT, FAILED, DRY_RUN
+	Error       string `csv:"error" json:"error,omitempty"`
+	Text        string `csv:"-" json:"text"` // rendered message
+}
+
+// ReadBroadcastRecipients reads recipients from csv file with a header.
+func ReadBroadcastRecipients(path string) ([]*BroadcastRecipient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening recipients")
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err == io.EOF {
+		return []*BroadcastRecipient{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading recipients header")
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	recipients := make([]*BroadcastRecipient, 0)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "reading recipient %d", len(recipients)+1)
+		}
+
+		recipient := &BroadcastRecipient{Row: len(recipients) + 1, Fields: make(map[string]string, len(header))}
+		for i, column := range header {
+			recipient.Fields[column] = strings.TrimSpace(record[i])
+		}
+		if recipient.Name() == "" {
+			return nil, errors.Errorf("recipient %d has neither chat_uuid, username nor fullname", recipient.Row)
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// ParseBroadcastTemplate parses message template from a file, missing columns are reported as errors.
+func ParseBroadcastTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+
+	return tmpl.Option("missingkey=error"), nil
+}
+
+// BroadcastReportWriter writes delivery report to csv file as soon as each message is sent. It's safe for concurrent use.
+type BroadcastReportWriter struct {
+	mu  sync.Mutex
+	f   *os.File
+	w   *csv.Writer
+	enc *csvutil.Encoder
+}
+
+// NewBroadcastReportWriter creates report file, existing file is overwritten.
+func NewBroadcastReportWriter(path string) (*BroadcastReportWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating report")
+	}
+
+	w := csv.NewWriter(f)
+
+	return &BroadcastReportWriter{f: f, w: w, enc: csvutil.NewEncoder(w)}, nil
+}
+
+// Write appends result to report file.
+func (rw *BroadcastReportWriter) Write(result *BroadcastResult) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	err := rw.enc.Encode(result)
+	if err != nil {
+		return errors.Wrap(err, "encoding result")
+	}
+
+	rw.w.Flush()
+	return rw.w.Error()
+}
+
+// Close closes report file.
+func (rw *BroadcastReportWriter) Close() error {
+	return rw.f.Close()
+}
+
+// Broadcast sends a message rendered from template to each recipient concurrently
+type Broadcast struct {
+	User        *PrivateUser
+	Token       string
+	Template    *template.Template
+	Concurrency int                    // maximum number of messages sent at the same time
+	Interval    time.Duration          // minimum interval between sent messages, zero means no rate limit
+	DryRun      bool                   // render messages and find recipients, but don't open chats and send messages
+	OnResult    func(*BroadcastResult) // called for each recipient, can be nil
+}
+
+// NewBroadcast is a constructor for Broadcast.
+func NewBroadcast(user *PrivateUser, token string, tmpl *template.Template, concurrency int) *Broadcast {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Broadcast{
+		User:        user,
+		Token:       token,
+		Template:    tmpl,
+		Concurrency: concurrency,
+	}
+}
+
+// Send sends messages to recipients, failures of single recipients are reported in results.
+func (b *Broadcast) Send(recipients []*BroadcastRecipient) []*BroadcastResult {
+	// rate limit is shared by all workers
+	var limit <-chan time.Time
+	if b.Interval > 0 && !b.DryRun {
+		ticker := time.NewTicker(b.Interval)
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	results := make([]*BroadcastResult, len(recipients))
+	sem := make(chan struct{}, b.Concurrency)
+	wg := &sync.WaitGroup{}
+	for i, recipient := range recipients {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, recipient *BroadcastRecipient) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := &BroadcastResult{Row: recipient.Row, Recipient: recipient.Name()}
+			if err := b.send(recipient, result, limit); err != nil {
+				result.Status = BroadcastFailed
+				result.Error = err.Error()
+			}
+			results[i] = result
+
+			if b.OnResult != nil {
+				b.OnResult(result)
+			}
+		}(i, recipient)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// send renders message for a recipient, resolves it's chat and sends message, result is filled on the way
+func (b *Broadcast) send(recipient *BroadcastRecipient, result *BroadcastResult, limit <-chan time.Time) error {
+	buf := &bytes.Buffer{}
+	if err := b.Template.Execute(buf, recipient.Fields); err != nil {
+		return errors.Wrap(err, "rendering template")
+	}
+	result.Text = buf.String()
+
+	result.ChatUUID = recipient.Fields["chat_uuid"]
+	if result.ChatUUID == "" {
+		var user *PublicUser
+		var err error
+		if username := recipient.Fields["username"]; username != "" {
+			user, err = FindUserByUsername(username, b.Token)
+		} else {
+			user, err = FindUserByFullname(recipient.Fields["fullname"], b.Token)
+		}
+		if err != nil {
+			return errors.Wrap(err, "finding recipient")
+		}
+
+		if b.DryRun {
+			result.Status = BroadcastDryRun
+			return nil
+		}
+
+		chat, err := OpenDirectChat(user.UUID, b.Token)
+		if err != nil {
+			return errors.Wrap(err, "opening chat")
+		}
+		result.ChatUUID = chat.UUID
+	}
+
+	if b.DryRun {
+		result.Status = BroadcastDryRun
+		return nil
+	}
+
+	if limit != nil {
+		<-limit
+	}
+
+	msg := NewMessage(result.Text, b.User)
+	resp, err := msg.SendMessage(result.ChatUUID, b.Token)
+	if errors.Is(err, ErrMessageInternal) {
+		// server fails, but message is sent anyway
+		result.MessageUUID = msg.UUID
+	} else if err != nil {
+		return errors.Wrap(err, "sending message")
+	} else {
+		result.MessageUUID = resp.Data.UUID
+	}
+	result.Status = BroadcastSent
+
+	return nil
+}
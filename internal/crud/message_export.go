@@ -0,0 +1,240 @@
+package crud
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/jszwec/csvutil"
+	"github.com/pkg/errors"
+)
+
+// supported formats of chat export
+const (
+	ExportJSONL = "jsonl"
+	ExportCSV   = "csv"
+	ExportHTML  = "html"
+)
+
+var (
+	// ErrExportFormat is used when export format isn't supported
+	ErrExportFormat = errors.Errorf("export format must be one of: %s, %s, %s", ExportJSONL, ExportCSV, ExportHTML)
+)
+
+// ExportAttachment is an attachment of exported message
+type ExportAttachment struct {
+	UUID string `json:"uuid"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	URL  string `json:"url"`
+	Path string `json:"path,omitempty"` // path to downloaded file, if attachments are downloaded
+}
+
+// ExportMessage is a message of exported chat, with author and attachments flattened for archiving
+type ExportMessage struct {
+	UUID        string              `json:"uuid" csv:"uuid"`
+	Type        string              `json:"type" csv:"type"`
+	CreatedAt   string              `json:"createdAt" csv:"created_at"`
+	UpdatedAt   string              `json:"updatedAt,omitempty" csv:"updated_at"`
+	AuthorUUID  string              `json:"authorUuid" csv:"author_uuid"`
+	Author      string              `json:"author" csv:"author"`
+	Username    string              `json:"username" csv:"username"`
+	ReplyTo     string              `json:"replyTo,omitempty" csv:"reply_to"`
+	Text        string              `json:"text" csv:"text"`
+	Attachments []*ExportAttachment `json:"attachments" csv:"-"`
+	Links       string              `json:"-" csv:"attachments"` // attachment paths or urls separated by space
+}
+
+// NewExportMessage converts a message to exported message, timestamps are formatted as RFC3339.
+func NewExportMessage(m *UserMessageEntity) *ExportMessage {
+	e := &ExportMessage{
+		UUID:        m.UUID,
+		Type:        m.Type,
+		CreatedAt:   formatMillis(m.CreatedAt),
+		UpdatedAt:   formatMillis(m.UpdatedAt),
+		ReplyTo:     m.ReplyToMessage,
+		Text:        m.Text,
+		Attachments: make([]*ExportAttachment, 0, len(m.Attachments)),
+	}
+
+	if m.User != nil {
+		e.AuthorUUID = m.User.UUID
+		e.Author = m.User.FullName()
+		e.Username = m.User.UserName
+	}
+
+	for _, item := range m.Attachments {
+		a := &ExportAttachment{
+			UUID: item.UUID,
+			Type: item.Type,
+			Name: item.FileName(),
+			Size: item.FileSize(),
+		}
+		if item.Data != nil && item.Data.Source != nil {
+			a.URL = item.Data.Source.URL
+		}
+		e.Attachments = append(e.Attachments, a)
+	}
+
+	return e
+}
+
+// Link returns path to downloaded attachment, or it's url if it isn't downloaded.
+func (a *ExportAttachment) Link() string {
+	if a.Path != "" {
+		return a.Path
+	}
+
+	return a.URL
+}
+
+// Download downloads attachment into dir, file is prefixed by attachment uuid, so files with the same name don't collide.
+func (a *ExportAttachment) Download(dir, token string) error {
+	if a.URL == "" {
+		return errors.Errorf("attachment %s has no url", a.UUID)
+	}
+	if a.UUID == "" || a.UUID != filepath.Base(a.UUID) || strings.ContainsAny(a.UUID, `/\`) {
+		return errors.Errorf("attachment uuid %q can't be used as file name", a.UUID)
+	}
+
+	name := a.Name
+	if name == "" {
+		name = "file"
+	}
+
+	path := filepath.Join(dir, a.UUID+"_"+filepath.Base(name))
+	if err := DownloadMedia(a.URL, path, token); err != nil {
+		return errors.Wrapf(err, "downloading attachment %s", a.UUID)
+	}
+	a.Path = path
+
+	return nil
+}
+
+func formatMillis(ms int) string {
+	if ms == 0 {
+		return ""
+	}
+
+	return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+}
+
+// MessageExportWriter writes exported messages in one of export formats
+type MessageExportWriter interface {
+	Write(msg *ExportMessage) error
+
+	// Close finishes export, it doesn't close underlying writer
+	Close() error
+}
+
+// NewMessageExportWriter is a constructor for MessageExportWriter of specified format, title is used only by html transcript.
+func NewMessageExportWriter(w io.Writer, format, title string) (MessageExportWriter, error) {
+	switch format {
+	case ExportJSONL:
+		return &jsonlExportWriter{enc: json.NewEncoder(w)}, nil
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		return &csvExportWriter{cw: cw, enc: csvutil.NewEncoder(cw)}, nil
+	case ExportHTML:
+		hw := &htmlExportWriter{w: w}
+		if err := htmlTranscript.ExecuteTemplate(w, "header", title); err != nil {
+			return nil, errors.Wrap(err, "writing html header")
+		}
+		return hw, nil
+	}
+
+	return nil, ErrExportFormat
+}
+
+type jsonlExportWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlExportWriter) Write(msg *ExportMessage) error {
+	return errors.Wrap(j.enc.Encode(msg), "encoding message")
+}
+
+func (j *jsonlExportWriter) Close() error {
+	return nil
+}
+
+type csvExportWriter struct {
+	cw      *csv.Writer
+	enc     *csvutil.Encoder
+	written bool
+}
+
+func (c *csvExportWriter) Write(msg *ExportMessage) error {
+	links := make([]string, 0, len(msg.Attachments))
+	for _, a := range msg.Attachments {
+		links = append(links, a.Link())
+	}
+	msg.Links = strings.Join(links, " ")
+	c.written = true
+
+	return errors.Wrap(c.enc.Encode(msg), "encoding message")
+}
+
+func (c *csvExportWriter) Close() error {
+	// header is written with first message, so empty export still has to get it
+	if !c.written {
+		if err := c.enc.EncodeHeader(ExportMessage{}); err != nil {
+			return errors.Wrap(err, "encoding header")
+		}
+	}
+	c.cw.Flush()
+
+	return errors.Wrap(c.cw.Error(), "flushing csv")
+}
+
+type htmlExportWriter struct {
+	w io.Writer
+}
+
+func (h *htmlExportWriter) Write(msg *ExportMessage) error {
+	return errors.Wrap(htmlTranscript.ExecuteTemplate(h.w, "message", msg), "writing html message")
+}
+
+func (h *htmlExportWriter) Close() error {
+	return errors.Wrap(htmlTranscript.ExecuteTemplate(h.w, "footer", nil), "writing html footer")
+}
+
+// htmlTranscript is a template of html transcript, it's split in parts so messages are written one by one
+var htmlTranscript = template.Must(template.New("transcript").Parse(`
+{{- define "header" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ . }}</title>
+<style>
+body { font-family: sans-serif; max-width: 800px; margin: auto; }
+.message { border-bottom: 1px solid #ddd; padding: 8px 0; }
+.meta { color: #777; font-size: 0.9em; }
+.deleted { color: #aaa; font-style: italic; }
+.text { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{ . }}</h1>
+{{ end -}}
+
+{{- define "message" -}}
+<div class="message" id="{{ .UUID }}">
+<div class="meta"><b>{{ .Author }}</b> @{{ .Username }} &middot; {{ .CreatedAt }}{{ if .UpdatedAt }} &middot; edited {{ .UpdatedAt }}{{ end }}{{ if .ReplyTo }} &middot; reply to <a href="#{{ .ReplyTo }}">message</a>{{ end }}</div>
+{{ if eq .Type "DELETED" }}<div class="deleted">message was deleted</div>{{ else }}<div class="text">{{ .Text }}</div>{{ end }}
+{{ range .Attachments }}<div class="attachment"><a href="{{ .Link }}">{{ .Name }}</a> ({{ .Type }}, {{ .Size }} bytes)</div>
+{{ end -}}
+</div>
+{{ end -}}
+
+{{- define "footer" -}}
+</body>
+</html>
+{{ end -}}
+`))
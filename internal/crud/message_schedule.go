@@ -0,0 +1,289 @@
+package crud
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	json "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// statuses of scheduled messages
+const (
+	SchedulePending = "PENDING"
+	ScheduleSending = "SENDING" // message stays in this status, if scheduler is killed while sending it
+	ScheduleSent    = "SENT"
+	ScheduleFailed  = "FAILED"
+)
+
+var (
+	// ErrQueueLocked is used when message queue stays locked by another process for too long
+	ErrQueueLocked = errors.New("message queue is locked by another process")
+
+	// ErrScheduleInterval is used when scheduler is run with interval, that isn't positive
+	ErrScheduleInterval = errors.New("scheduler interval must be positive")
+)
+
+// ScheduledMessage is a message waiting in queue to be sent at due time
+type ScheduledMessage struct {
+	ID          string    `json:"id"`
+	ChatUUID    string    `json:"chatUuid"`
+	Text        string    `json:"text"`
+	At          time.Time `json:"at"` // due time
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt"` // time of next retry after failure
+	LastError   string    `json:"lastError,omitempty"`
+	MessageUUID string    `json:"messageUuid,omitempty"` // uuid of sent message
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// NewScheduledMessage is a constructor for ScheduledMessage.
+func NewScheduledMessage(chatUUID, text string, at time.Time) *ScheduledMessage {
+	return &ScheduledMessage{
+		ID:       uuid.New().String(),
+		ChatUUID: chatUUID,
+		Text:     text,
+		At:       at,
+		Status:   SchedulePending,
+	}
+}
+
+// due reports whether message has to be sent at now
+func (m *ScheduledMessage) due(now time.Time) bool {
+	return m.Status == SchedulePending && !m.At.After(now) && !m.NextAttempt.After(now)
+}
+
+// MessageQueue is a queue of scheduled messages stored in a local json file.
+//
+// Each operation reads and writes the whole file under a lock file, so scheduling
+// messages is safe while scheduler is running in another process.
+type MessageQueue struct {
+	Path string
+}
+
+// NewMessageQueue is a constructor for MessageQueue, file is created on first write.
+func NewMessageQueue(path string) *MessageQueue {
+	return &MessageQueue{Path: path}
+}
+
+// List returns all messages of a queue sorted by due time.
+func (q *MessageQueue) List() ([]*ScheduledMessage, error) {
+	var messages []*ScheduledMessage
+	err := q.update(func(m []*ScheduledMessage) ([]*ScheduledMessage, bool) {
+		messages = m
+		return m, false
+	})
+
+	return messages, err
+}
+
+// Add adds messages to a queue.
+func (q *MessageQueue) Add(messages ...*ScheduledMessage) error {
+	return q.update(func(m []*ScheduledMessage) ([]*ScheduledMessage, bool) {
+		return append(m, messages...), true
+	})
+}
+
+// Save replaces stored message with the same id.
+func (q *MessageQueue) Save(msg *ScheduledMessage) error {
+	return q.update(func(m []*ScheduledMessage) ([]*ScheduledMessage, bool) {
+		for i := range m {
+			if m[i].ID == msg.ID {
+				m[i] = msg
+				return m, true
+			}
+		}
+
+		return m, false
+	})
+}
+
+// update reads queue, passes it to fn and writes it back if fn reports changes
+func (q *MessageQueue) update(fn func([]*ScheduledMessage) ([]*ScheduledMessage, bool)) error {
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	messages := make([]*ScheduledMessage, 0)
+	data, err := ioutil.ReadFile(q.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "reading queue")
+	} else if len(data) > 0 {
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return errors.Wrap(err, "decoding queue")
+		}
+	}
+
+	messages, changed := fn(messages)
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].At.Before(messages[j].At)
+	})
+	if !changed {
+		return nil
+	}
+
+	data, err = json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding queue")
+	}
+
+	// write to temporary file first, so queue isn't corrupted if process is killed while writing
+	tmp := q.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, "writing queue")
+	}
+
+	return errors.Wrap(os.Rename(tmp, q.Path), "replacing queue")
+}
+
+// staleLockAge is an age of lock file, after which it's considered to be left by a killed process.
+// Lock is held only while queue file is read and written, so it's never held that long
+const staleLockAge = 30 * time.Second
+
+// lock creates lock file next to queue file, it waits up to 5 seconds for other process to release it.
+// Stale lock file is removed
+func (q *MessageQueue) lock() (func(), error) {
+	if dir := filepath.Dir(q.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrap(err, "creating queue directory")
+		}
+	}
+
+	path := q.Path + ".lock"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		} else if !os.IsExist(err) {
+			return nil, errors.Wrap(err, "creating lock file")
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.Wrap(ErrQueueLocked, path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Scheduler sends scheduled messages from a queue at their due time
+type Scheduler struct {
+	Queue *MessageQueue
+
+	// Send sends a message and returns uuid of sent message, by default message is sent by SendMessage
+	Send func(msg *ScheduledMessage) (string, error)
+
+	Interval    time.Duration // how often queue is checked for due messages
+	MaxAttempts int           // failed message is retried until it fails this many times
+	RetryDelay  time.Duration // delay before first retry, it's doubled after each failure
+
+	OnResult func(msg *ScheduledMessage) // called after each attempt to send a message, can be nil
+	OnError  func(err error)             // called when queue can't be checked, ex: it's locked by another process, can be nil
+}
+
+// NewScheduler is a constructor for Scheduler, messages are sent on behalf of user.
+func NewScheduler(queue *MessageQueue, user *PrivateUser, token string) *Scheduler {
+	return &Scheduler{
+		Queue: queue,
+		Send: func(msg *ScheduledMessage) (string, error) {
+			m := NewMessage(msg.Text, user)
+			resp, err := m.SendMessage(msg.ChatUUID, token)
+			if errors.Is(err, ErrMessageInternal) {
+				// server fails, but message is sent anyway
+				return m.UUID, nil
+			} else if err != nil {
+				return "", err
+			}
+
+			return resp.Data.UUID, nil
+		},
+		Interval:    10 * time.Second,
+		MaxAttempts: 5,
+		RetryDelay:  30 * time.Second,
+	}
+}
+
+// Run sends due messages every Interval, until ctx is done. Queue is read on each check, so messages scheduled meanwhile are picked up.
+// Failed check is reported to OnError and queue is checked again on next tick.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.Interval <= 0 {
+		return errors.Wrapf(ErrScheduleInterval, "%s", s.Interval)
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(time.Now()); err != nil && s.OnError != nil {
+			s.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends messages, that are due at now. Message is saved with SENDING status before it's sent and with
+// its outcome right after, so messages aren't sent twice if scheduler is restarted. Message interrupted while
+// sending stays SENDING and isn't retried, as it could be delivered already.
+func (s *Scheduler) RunOnce(now time.Time) error {
+	messages, err := s.Queue.List()
+	if err != nil {
+		return errors.Wrap(err, "listing queue")
+	}
+
+	for _, msg := range messages {
+		if !msg.due(now) {
+			continue
+		}
+
+		msg.Attempts++
+		msg.Status = ScheduleSending
+		if err := s.Queue.Save(msg); err != nil {
+			return errors.Wrapf(err, "saving message %s", msg.ID)
+		}
+
+		messageUUID, err := s.Send(msg)
+		if err != nil {
+			msg.LastError = err.Error()
+			if msg.Attempts >= s.MaxAttempts {
+				msg.Status = ScheduleFailed
+			} else {
+				msg.Status = SchedulePending
+				msg.NextAttempt = now.Add(s.RetryDelay << uint(msg.Attempts-1))
+			}
+		} else {
+			msg.Status = ScheduleSent
+			msg.MessageUUID = messageUUID
+			msg.SentAt = now
+			msg.LastError = ""
+		}
+
+		if err := s.Queue.Save(msg); err != nil {
+			return errors.Wrapf(err, "saving message %s", msg.ID)
+		}
+
+		if s.OnResult != nil {
+			s.OnResult(msg)
+		}
+	}
+
+	return nil
+}
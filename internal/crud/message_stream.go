@@ -0,0 +1,147 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// types of message events
+const (
+	MessageEventNew     = "NEW"
+	MessageEventEdited  = "EDITED"
+	MessageEventDeleted = "DELETED"
+)
+
+// MessageEvent is a change of chat history, events of a chat are ordered by their cursors
+type MessageEvent struct {
+	Cursor  int                `json:"cursor"`
+	Type    string             `json:"type"` // Enum: NEW, EDITED, DELETED
+	Message *UserMessageEntity `json:"message"`
+}
+
+type ResponseMessageEvents struct {
+	Data   []*MessageEvent `json:"data"`
+	Cursor int             `json:"cursor"` // cursor of last event of a chat, it's returned even if there are no new events
+}
+
+// ListMessageEvents waits for events of a chat after specified cursor, empty list is returned if there are no events till server's timeout.
+// Zero cursor means only events happened after the request. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{chat_uuid}/chat/events?after={cursor}
+//
+// method: get
+func ListMessageEvents(ctx context.Context, chatUUID string, cursor int, token string) (*ResponseMessageEvents, error) {
+	resp := &ResponseMessageEvents{}
+	err := makeAPIRequestWithContext(ctx, "GET", fmt.Sprintf("/v1/workspaces/%s/chat/events?after=%d", chatUUID, cursor), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// MessageStream follows chat history live, like tail -f.
+//
+// It long-polls Acroplia push channel, reconnects with exponential backoff on failures and
+// resumes from the last seen event, so events aren't lost or repeated.
+type MessageStream struct {
+	ChatUUID string
+
+	// Cursor is a cursor of last seen event, zero value means that only new events are streamed
+	Cursor int
+
+	// OnEvent is called for each event in order
+	OnEvent func(event *MessageEvent)
+
+	// PollTimeout is a timeout of waiting for events from server
+	PollTimeout time.Duration
+
+	// Backoff is a delay before first reconnect, it's doubled after each failure up to MaxBackoff.
+	// It's also a delay before next poll, when server returns no events without waiting for them
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Retries is a number of failures in a row, after which streaming stops, zero means reconnect forever
+	Retries int
+
+	// List waits for events after cursor, by default events are received by ListMessageEvents
+	List func(ctx context.Context, chatUUID string, cursor int) (*ResponseMessageEvents, error)
+}
+
+// NewMessageStream is a constructor for MessageStream.
+func NewMessageStream(chatUUID, token string, onEvent func(event *MessageEvent)) *MessageStream {
+	return &MessageStream{
+		ChatUUID:    chatUUID,
+		OnEvent:     onEvent,
+		PollTimeout: 60 * time.Second,
+		Backoff:     time.Second,
+		MaxBackoff:  30 * time.Second,
+		List: func(ctx context.Context, chatUUID string, cursor int) (*ResponseMessageEvents, error) {
+			return ListMessageEvents(ctx, chatUUID, cursor, token)
+		},
+	}
+}
+
+// Run streams events, until ctx is done or server fails more than Retries times in a row.
+// Expired token, missing permission or missing chat aren't retried.
+func (s *MessageStream) Run(ctx context.Context) error {
+	backoff := s.Backoff
+	for failures := 0; ; {
+		started := time.Now()
+		pollCtx, cancel := context.WithTimeout(ctx, s.PollTimeout)
+		resp, err := s.List(pollCtx, s.ChatUUID, s.Cursor)
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) || errors.Is(err, ErrNotFound) {
+			return errors.Wrap(err, "receiving message events")
+		} else if err != nil {
+			failures++
+			if s.Retries > 0 && failures > s.Retries {
+				return errors.Wrap(err, "receiving message events")
+			}
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+			if backoff > s.MaxBackoff {
+				backoff = s.MaxBackoff
+			}
+			continue
+		}
+		failures, backoff = 0, s.Backoff
+
+		for _, event := range resp.Data {
+			// events may be repeated after reconnect
+			if event.Cursor <= s.Cursor {
+				continue
+			}
+			s.Cursor = event.Cursor
+
+			if s.OnEvent != nil {
+				s.OnEvent(event)
+			}
+		}
+
+		// start following from current position of a chat, events may be paged, so cursor isn't moved past returned events
+		if len(resp.Data) == 0 && resp.Cursor > s.Cursor {
+			s.Cursor = resp.Cursor
+		}
+
+		// server answered without waiting for events, ex: chat is empty, so it isn't polled in a busy loop
+		if len(resp.Data) == 0 && time.Since(started) < s.Backoff {
+			select {
+			case <-time.After(s.Backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
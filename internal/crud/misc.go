@@ -1,11 +1,38 @@
 package crud
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"net/http"
+	"time"
 
+	json "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 )
 
+var (
+	// ErrNotFound is used when requested resource doesn't exist in Acroplia
+	ErrNotFound = errors.New("not found")
+
+	// ErrForbidden is used when user isn't allowed to perform request, ex: editing message of another user
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrUnauthorized is used when token of user is missing or expired, so user has to login again
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// DefaultAPIURL is a base url of Acroplia API used by default
+const DefaultAPIURL = "https://api-stage.acroplia.com/api"
+
+// acropliaAPIURL is a base url of Acroplia API, it can be changed by SetAPIURL
+var acropliaAPIURL = DefaultAPIURL
+
+// SetAPIURL changes base url of Acroplia API, it's used to point the tool to another environment or to a local server in tests.
+func SetAPIURL(url string) {
+	acropliaAPIURL = url
+}
+
 // CheckInternetConnection is a utility function that checks if user has internet connection, if there is no connection then tool won't run.
 func CheckInternetConnection() error {
 	resp, err := http.Get(acropliaLoginURL)
@@ -19,3 +46,67 @@ func CheckInternetConnection() error {
 
 	return nil
 }
+
+// makeAPIRequest sends data in json format to specified api path and decodes json response into out.
+//
+// data and out can be nil, X-Auth-Token header is set only if token isn't empty.
+func makeAPIRequest(method, path, token string, data, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return makeAPIRequestWithContext(ctx, method, path, token, data, out)
+}
+
+// makeAPIRequestWithContext is the same as makeAPIRequest, but request is bound to ctx instead of default timeout
+func makeAPIRequestWithContext(ctx context.Context, method, path, token string, data, out interface{}) error {
+	client := &http.Client{}
+
+	var body io.Reader
+	if data != nil {
+		buf := &bytes.Buffer{}
+		err := json.NewEncoder(buf).Encode(data)
+		if err != nil {
+			return errors.Wrap(err, "encoding request data to json")
+		}
+		body = buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, acropliaAPIURL+path, body)
+	if err != nil {
+		return errors.Wrapf(err, "creating new %s request", method)
+	}
+
+	req.Header = http.Header{
+		"Content-Type": []string{"application/json"},
+	}
+	if token != "" {
+		req.Header.Set("X-Auth-Token", token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "making %s request", method)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.Wrapf(ErrNotFound, "%s %s", method, path)
+	} else if resp.StatusCode == http.StatusForbidden {
+		return errors.Wrapf(ErrForbidden, "%s %s", method, path)
+	} else if resp.StatusCode == http.StatusUnauthorized {
+		return errors.Wrapf(ErrUnauthorized, "%s %s", method, path)
+	} else if resp.StatusCode != 200 {
+		return errors.Errorf("request failed with status code %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return errors.Wrap(err, "decoding response")
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package crud
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// statuses of presence changes
+const (
+	PresenceOnline       = "ONLINE"
+	PresenceOffline      = "OFFLINE"
+	PresenceTutorOnline  = "TUTOR_ONLINE"  // tutor is available for students
+	PresenceTutorOffline = "TUTOR_OFFLINE" // tutor isn't available for students, but can be online
+)
+
+var (
+	// ErrPresenceInterval is used when presence is polled with interval, that isn't positive
+	ErrPresenceInterval = errors.New("presence interval must be positive")
+)
+
+// PresenceChange is a change of user's online or tutor online status
+type PresenceChange struct {
+	User    *PublicUser `json:"user"`
+	Status  string      `json:"status"` // Enum: ONLINE, OFFLINE, TUTOR_ONLINE, TUTOR_OFFLINE
+	At      time.Time   `json:"at"`
+	Initial bool        `json:"initial,omitempty"` // status at the start of watching, it isn't a transition
+}
+
+// GetPresence gets current online and tutor online statuses of users. X-Auth-Token is needed for this request
+//
+// path: /v1/users/presence?uuid={uuid}&uuid={uuid}
+//
+// method: get
+func GetPresence(ctx context.Context, userUUIDs []string, token string) (*ResponsePublicUsers, error) {
+	resp := &ResponsePublicUsers{}
+	err := makeAPIRequestWithContext(ctx, "GET", "/v1/users/presence?"+url.Values{"uuid": userUUIDs}.Encode(), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PresenceWatcher polls presence of users and reports their transitions, like user going online or tutor becoming available.
+//
+// Failed polls are retried with exponential backoff, statuses are compared with the last successful poll, so transitions aren't repeated.
+type PresenceWatcher struct {
+	Users []string // uuids of watched users
+
+	// OnChange is called for each change in order, first poll reports current statuses as initial changes
+	OnChange func(change *PresenceChange)
+
+	// Interval is a delay between polls
+	Interval time.Duration
+
+	// Backoff is a delay before first retry, it's doubled after each failure up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Retries is a number of failures in a row, after which watching stops, zero means retry forever
+	Retries int
+
+	// List gets current statuses of users, by default statuses are received by GetPresence
+	List func(ctx context.Context, userUUIDs []string) ([]*PublicUser, error)
+
+	last map[string]*PublicUser
+}
+
+// NewPresenceWatcher is a constructor for PresenceWatcher.
+func NewPresenceWatcher(userUUIDs []string, token string, onChange func(change *PresenceChange)) *PresenceWatcher {
+	return &PresenceWatcher{
+		Users:      userUUIDs,
+		OnChange:   onChange,
+		Interval:   30 * time.Second,
+		Backoff:    time.Second,
+		MaxBackoff: time.Minute,
+		List: func(ctx context.Context, userUUIDs []string) ([]*PublicUser, error) {
+			resp, err := GetPresence(ctx, userUUIDs, token)
+			if err != nil {
+				return nil, err
+			}
+
+			return resp.Data, nil
+		},
+	}
+}
+
+// Run polls presence every Interval, until ctx is done or polling fails more than Retries times in a row.
+// Expired token or missing permission isn't retried.
+func (w *PresenceWatcher) Run(ctx context.Context) error {
+	if w.Interval <= 0 {
+		return errors.Wrapf(ErrPresenceInterval, "%s", w.Interval)
+	}
+
+	backoff := w.Backoff
+	for failures := 0; ; {
+		err := w.Poll(ctx)
+
+		delay := w.Interval
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+			return err
+		} else if err != nil {
+			failures++
+			if w.Retries > 0 && failures > w.Retries {
+				return err
+			}
+
+			delay = backoff
+			backoff *= 2
+			if backoff > w.MaxBackoff {
+				backoff = w.MaxBackoff
+			}
+		} else {
+			failures, backoff = 0, w.Backoff
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Poll gets current statuses once and reports changes since the last successful poll.
+func (w *PresenceWatcher) Poll(ctx context.Context) error {
+	users, err := w.List(ctx, w.Users)
+	if err != nil {
+		return errors.Wrap(err, "getting presence")
+	}
+
+	now := time.Now()
+	initial := w.last == nil
+	if initial {
+		w.last = make(map[string]*PublicUser, len(users))
+	}
+
+	for _, user := range users {
+		prev, seen := w.last[user.UUID]
+		w.last[user.UUID] = user
+
+		if !seen {
+			w.report(&PresenceChange{User: user, Status: onlineStatus(user.Online), At: now, Initial: true})
+			if user.Tutor {
+				w.report(&PresenceChange{User: user, Status: tutorStatus(user.TutorOnline), At: now, Initial: true})
+			}
+
+			continue
+		}
+
+		if prev.Online != user.Online {
+			w.report(&PresenceChange{User: user, Status: onlineStatus(user.Online), At: now})
+		}
+		if user.Tutor && (prev.TutorOnline != user.TutorOnline || !prev.Tutor) {
+			w.report(&PresenceChange{User: user, Status: tutorStatus(user.TutorOnline), At: now})
+		}
+	}
+
+	return nil
+}
+
+func (w *PresenceWatcher) report(change *PresenceChange) {
+	if w.OnChange != nil {
+		w.OnChange(change)
+	}
+}
+
+func onlineStatus(online bool) string {
+	if online {
+		return PresenceOnline
+	}
+
+	return PresenceOffline
+}
+
+func tutorStatus(online bool) string {
+	if online {
+		return PresenceTutorOnline
+	}
+
+	return PresenceTutorOffline
+}
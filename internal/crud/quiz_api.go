@@ -0,0 +1,347 @@
+package crud
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// types of quiz questions
+const (
+	QuestionSingleChoice   = "SINGLE_CHOICE"
+	QuestionMultipleChoice = "MULTIPLE_CHOICE"
+	QuestionText           = "TEXT" // student types an answer, it's compared with correct answer ignoring case
+)
+
+var (
+	// ErrQuizEmpty is used when quiz definition file has no quizzes
+	ErrQuizEmpty = errors.New("quiz definition has no quizzes")
+)
+
+// QuizChoice is a choice of a question, that student can pick
+type QuizChoice struct {
+	UUID    string `json:"uuid"`
+	Text    string `json:"text"`
+	Correct bool   `json:"correct"`
+}
+
+// QuizQuestion is a single question of a quiz
+type QuizQuestion struct {
+	UUID    string        `json:"uuid"`
+	Type    string        `json:"type"` // Enum: SINGLE_CHOICE, MULTIPLE_CHOICE, TEXT
+	Text    string        `json:"text"`
+	Choices []*QuizChoice `json:"choices,omitempty"` // SINGLE_CHOICE, MULTIPLE_CHOICE
+	Answer  string        `json:"answer,omitempty"`  // TEXT
+	Points  int           `json:"points"`
+}
+
+// Quiz is a payload of TEST library item
+type Quiz struct {
+	Questions []*QuizQuestion `json:"questions"`
+	PassScore int             `json:"passScore,omitempty"` // percent of max score needed to pass
+	TimeLimit int             `json:"timeLimit,omitempty"` // in seconds, zero means no limit
+	Shuffle   bool            `json:"shuffle,omitempty"`   // questions are shown in random order
+}
+
+// MaxScore returns sum of points of all questions
+func (q *Quiz) MaxScore() int {
+	score := 0
+	for _, question := range q.Questions {
+		score += question.Points
+	}
+
+	return score
+}
+
+// QuizQuestionDefinition is a question of quiz definition, it's type is chosen by it's fields:
+// choices with one correct answer is SINGLE_CHOICE, with several correct answers is MULTIPLE_CHOICE
+// and answer without choices is TEXT
+type QuizQuestionDefinition struct {
+	Text    string   `mapstructure:"text"`
+	Choices []string `mapstructure:"choices"`
+	Correct []string `mapstructure:"correct"` // texts of correct choices
+	Answer  string   `mapstructure:"answer"`
+	Points  int      `mapstructure:"points"` // by default 1
+}
+
+// QuizDefinition describes a quiz in yaml or toml file:
+//
+//	quizzes:
+//	  - title: Photosynthesis
+//	    pass_score: 60
+//	    time_limit: 20m
+//	    questions:
+//	      - text: What do plants produce?
+//	        choices: [Oxygen, Nitrogen, Helium]
+//	        correct: [Oxygen]
+//	      - text: Name the green pigment
+//	        answer: chlorophyll
+//	        points: 2
+type QuizDefinition struct {
+	Title     string                    `mapstructure:"title"`
+	Subtitle  string                    `mapstructure:"subtitle"`
+	PassScore int                       `mapstructure:"pass_score"`
+	TimeLimit string                    `mapstructure:"time_limit"` // duration, ex: 20m
+	Shuffle   bool                      `mapstructure:"shuffle"`
+	Questions []*QuizQuestionDefinition `mapstructure:"questions"`
+}
+
+// LoadQuizDefinitions reads quiz definitions from a file, format is chosen by file extension.
+// All quizzes are validated, so a mistake is found before any quiz is created
+func LoadQuizDefinitions(path string) ([]*QuizDefinition, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Wrap(err, "reading quiz definition")
+	}
+
+	defs := struct {
+		Quizzes []*QuizDefinition `mapstructure:"quizzes"`
+	}{}
+	if err := v.Unmarshal(&defs); err != nil {
+		return nil, errors.Wrap(err, "decoding quiz definition")
+	}
+
+	if len(defs.Quizzes) == 0 {
+		return nil, ErrQuizEmpty
+	}
+
+	for i, def := range defs.Quizzes {
+		if _, err := def.Quiz(); err != nil {
+			return nil, errors.Wrapf(err, "quiz %d", i+1)
+		}
+	}
+
+	return defs.Quizzes, nil
+}
+
+// Quiz converts definition to quiz payload, questions and choices get new uuids
+func (d *QuizDefinition) Quiz() (*Quiz, error) {
+	if d.Title == "" {
+		return nil, errors.New("title can't be empty")
+	}
+	if len(d.Questions) == 0 {
+		return nil, errors.Errorf("%s has no questions", d.Title)
+	}
+	if d.PassScore < 0 || d.PassScore > 100 {
+		return nil, errors.Errorf("%s pass score must be a percent from 0 to 100", d.Title)
+	}
+
+	quiz := &Quiz{
+		Questions: make([]*QuizQuestion, 0, len(d.Questions)),
+		PassScore: d.PassScore,
+		Shuffle:   d.Shuffle,
+	}
+
+	if d.TimeLimit != "" {
+		limit, err := time.ParseDuration(d.TimeLimit)
+		if err != nil || limit < 0 {
+			return nil, errors.Errorf("%s has invalid time limit %q", d.Title, d.TimeLimit)
+		}
+		quiz.TimeLimit = int(limit.Seconds())
+	}
+
+	for i, def := range d.Questions {
+		question, err := def.question()
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s question %d", d.Title, i+1)
+		}
+		quiz.Questions = append(quiz.Questions, question)
+	}
+
+	return quiz, nil
+}
+
+// question converts question definition to quiz question and checks it's correct answers
+func (d *QuizQuestionDefinition) question() (*QuizQuestion, error) {
+	if strings.TrimSpace(d.Text) == "" {
+		return nil, errors.New("text can't be empty")
+	}
+
+	q := &QuizQuestion{
+		UUID:   uuid.New().String(),
+		Text:   d.Text,
+		Points: d.Points,
+	}
+	if q.Points == 0 {
+		q.Points = 1
+	} else if q.Points < 0 {
+		return nil, errors.New("points can't be negative")
+	}
+
+	if len(d.Choices) == 0 {
+		if strings.TrimSpace(d.Answer) == "" {
+			return nil, errors.New("question must have either choices or answer")
+		}
+		q.Type = QuestionText
+		q.Answer = strings.TrimSpace(d.Answer)
+
+		return q, nil
+	}
+
+	if len(d.Choices) < 2 {
+		return nil, errors.New("question must have at least 2 choices")
+	}
+	if len(d.Correct) == 0 {
+		return nil, errors.New("question must have at least 1 correct choice")
+	}
+
+	correct := make(map[string]bool, len(d.Correct))
+	for _, c := range d.Correct {
+		correct[c] = true
+	}
+
+	for _, c := range d.Choices {
+		q.Choices = append(q.Choices, &QuizChoice{UUID: uuid.New().String(), Text: c, Correct: correct[c]})
+		delete(correct, c)
+	}
+	for c := range correct {
+		return nil, errors.Errorf("correct answer %q isn't one of choices", c)
+	}
+
+	q.Type = QuestionSingleChoice
+	if len(d.Correct) > 1 {
+		q.Type = QuestionMultipleChoice
+	}
+
+	return q, nil
+}
+
+// NewQuiz is a constructor for TEST library item built from quiz definition
+func NewQuiz(def *QuizDefinition, user *PrivateUser) (*LibraryItem, error) {
+	quiz, err := def.Quiz()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LibraryItem{
+		Type:     LibraryTest,
+		UUID:     uuid.New().String(),
+		Title:    def.Title,
+		Subtitle: def.Subtitle,
+		User:     user.ToPublic(),
+		Owner:    user.UUID,
+		Quiz:     quiz,
+	}, nil
+}
+
+// AssignQuiz assigns quiz to users and workspaces, each assignee gets own attempt. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}/assignments
+//
+// method: post
+func AssignQuiz(quizUUID string, req *AssignmentRequest, token string) (*ResponseAssignments, error) {
+	return assignLibraryItem(quizUUID, req, token)
+}
+
+// QuizAnswer is an answer of a student to a question
+type QuizAnswer struct {
+	QuestionUUID string   `json:"questionUuid"`
+	Choices      []string `json:"choices,omitempty"` // uuids of picked choices
+	Text         string   `json:"text,omitempty"`
+	Correct      bool     `json:"correct"`
+	Points       int      `json:"points"`
+}
+
+// QuizResult is a submitted attempt of a student
+type QuizResult struct {
+	UUID        string        `json:"uuid"`
+	QuizUUID    string        `json:"quizUuid"`
+	Student     *PublicUser   `json:"student"`
+	Score       int           `json:"score"`
+	MaxScore    int           `json:"maxScore"`
+	Passed      bool          `json:"passed"`
+	Answers     []*QuizAnswer `json:"answers"`
+	StartedAt   int           `json:"startedAt,omitempty"`
+	SubmittedAt int           `json:"submittedAt,omitempty"`
+}
+
+type ResponseQuizResults struct {
+	Data []*QuizResult `json:"data"`
+}
+
+// Percent returns score as percent of max score
+func (r *QuizResult) Percent() float64 {
+	if r.MaxScore == 0 {
+		return 0
+	}
+
+	return float64(r.Score) * 100 / float64(r.MaxScore)
+}
+
+// ListQuizResults lists submitted attempts of all students, only author of a quiz can list them. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}/results
+//
+// method: get
+func ListQuizResults(quizUUID, token string) (*ResponseQuizResults, error) {
+	resp := &ResponseQuizResults{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/library/items/%s/results", quizUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// WriteQuizResultsCSV writes a row per student with their score and points for each question of quiz in q1, q2, ... columns.
+// Unanswered questions are left empty
+func WriteQuizResultsCSV(w io.Writer, quiz *Quiz, results []*QuizResult) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"student_uuid", "username", "name", "score", "max_score", "percent", "passed", "submitted_at"}
+	for i := range quiz.Questions {
+		header = append(header, fmt.Sprintf("q%d", i+1))
+	}
+	if err := cw.Write(header); err != nil {
+		return errors.Wrap(err, "writing header")
+	}
+
+	for _, r := range results {
+		row := make([]string, 0, len(header))
+		if r.Student != nil {
+			row = append(row, r.Student.UUID, r.Student.UserName, r.Student.FullName())
+		} else {
+			row = append(row, "", "", "")
+		}
+
+		submittedAt := ""
+		if r.SubmittedAt != 0 {
+			submittedAt = time.Unix(0, int64(r.SubmittedAt)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		}
+		row = append(row,
+			strconv.Itoa(r.Score),
+			strconv.Itoa(r.MaxScore),
+			strconv.FormatFloat(r.Percent(), 'f', 1, 64),
+			strconv.FormatBool(r.Passed),
+			submittedAt,
+		)
+
+		points := make(map[string]int, len(r.Answers))
+		for _, a := range r.Answers {
+			points[a.QuestionUUID] = a.Points
+		}
+		for _, q := range quiz.Questions {
+			if p, ok := points[q.UUID]; ok {
+				row = append(row, strconv.Itoa(p))
+			} else {
+				row = append(row, "")
+			}
+		}
+
+		if err := cw.Write(row); err != nil {
+			return errors.Wrap(err, "writing result")
+		}
+	}
+
+	cw.Flush()
+
+	return errors.Wrap(cw.Error(), "writing results")
+}
@@ -0,0 +1,180 @@
+package crud
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// SearchMessage is a type of search results, that are chat messages. Other results are library items of TEXTPAD or FOLDER type
+const SearchMessage = "MESSAGE"
+
+var (
+	// SearchableTypes is a list of content types, that can be searched
+	SearchableTypes = []string{
+		LibraryTextpad,
+		LibraryFolder,
+		SearchMessage,
+	}
+)
+
+var (
+	// ErrSearchType is used when searched content type isn't supported
+	ErrSearchType = errors.Errorf("search type must be one of: %s", strings.Join(SearchableTypes, ", "))
+
+	// ErrSearchRange is used when start of searched date range is after it's end
+	ErrSearchRange = errors.New("start of date range must be before it's end")
+)
+
+// Highlight is a part of search result snippet, that matches query
+type Highlight struct {
+	Offset int `json:"offset"` // offset in runes from start of snippet
+	Length int `json:"length"` // length in runes
+}
+
+// SearchResult is a single textpad, folder or message matching search query
+type SearchResult struct {
+	Type       string       `json:"type"` // Enum: TEXTPAD, FOLDER, MESSAGE
+	UUID       string       `json:"uuid"`
+	Title      string       `json:"title,omitempty"` // title of library item or chat of a message
+	Snippet    string       `json:"snippet"`
+	Highlights []*Highlight `json:"highlights,omitempty"`
+	Path       []*PathItem  `json:"path,omitempty"`     // parent folders of library item
+	ChatUUID   string       `json:"chatUuid,omitempty"` // MESSAGE
+	User       *PublicUser  `json:"user,omitempty"`     // owner of library item or author of message
+	Score      float64      `json:"score,omitempty"`
+	CreatedAt  int          `json:"createdAt,omitempty"`
+	UpdatedAt  int          `json:"updatedAt,omitempty"`
+}
+
+type ResponseSearch struct {
+	Data  []*SearchResult `json:"data"`
+	Page  int             `json:"page,omitempty"`
+	Size  int             `json:"size,omitempty"`
+	Total int             `json:"total,omitempty"` // number of results on all pages
+}
+
+// SearchFilter is used to filter and page search results, empty fields are ignored
+type SearchFilter struct {
+	Types []string  // types of content, all types are searched by default
+	Since time.Time // only content created or updated since
+	Until time.Time // only content created or updated until
+	Lang  *Language // language used to stem query words, ex: photosynthesis matches photosynthetic
+	Page  int       // number of page, starting from 1
+	Size  int       // number of results on a page
+}
+
+// HighlightedSnippet returns snippet with each highlighted match wrapped by before and after marks, ex: ** and **
+func (r *SearchResult) HighlightedSnippet(before, after string) string {
+	runes := []rune(r.Snippet)
+
+	sb := &strings.Builder{}
+	last := 0
+	for _, h := range r.Highlights {
+		start, end := h.Offset, h.Offset+h.Length
+		if start < last || end > len(runes) || h.Length <= 0 {
+			continue
+		}
+
+		sb.WriteString(string(runes[last:start]))
+		sb.WriteString(before)
+		sb.WriteString(string(runes[start:end]))
+		sb.WriteString(after)
+		last = end
+	}
+	sb.WriteString(string(runes[last:]))
+
+	return sb.String()
+}
+
+// Breadcrumbs returns titles of parent folders and result itself joined by sep, ex: Biology / Week 1 / Lesson 1
+func (r *SearchResult) Breadcrumbs(sep string) string {
+	titles := make([]string, 0, len(r.Path)+1)
+	for _, p := range r.Path {
+		titles = append(titles, p.Title)
+	}
+	if r.Title != "" {
+		titles = append(titles, r.Title)
+	}
+
+	return strings.Join(titles, sep)
+}
+
+// TruncateSnippet shortens snippet to at most n runes around it's first highlight, highlights are shifted accordingly
+func (r *SearchResult) TruncateSnippet(n int) {
+	if n <= 0 || utf8.RuneCountInString(r.Snippet) <= n {
+		return
+	}
+
+	runes := []rune(r.Snippet)
+	start := 0
+	if len(r.Highlights) > 0 {
+		start = r.Highlights[0].Offset - n/4
+		if start < 0 {
+			start = 0
+		}
+	}
+	if start+n > len(runes) {
+		start = len(runes) - n
+	}
+
+	highlights := make([]*Highlight, 0, len(r.Highlights))
+	for _, h := range r.Highlights {
+		if h.Offset >= start && h.Offset+h.Length <= start+n {
+			highlights = append(highlights, &Highlight{Offset: h.Offset - start, Length: h.Length})
+		}
+	}
+
+	r.Snippet = string(runes[start : start+n])
+	r.Highlights = highlights
+}
+
+// Search searches textpads, folders and chat messages available to a user, filter can be nil. X-Auth-Token is needed for this request
+//
+// path: /v1/search?query={query}&type={type}&since={since}&until={until}&lang={search_config}&page={page}&size={size}
+//
+// method: get
+func Search(query, token string, filter *SearchFilter) (*ResponseSearch, error) {
+	values := url.Values{"query": []string{query}}
+	if filter != nil {
+		for _, t := range filter.Types {
+			t = strings.ToUpper(t)
+			if !stringInSlice(t, SearchableTypes) {
+				return nil, errors.Wrapf(ErrSearchType, "%s", t)
+			}
+			values.Add("type", t)
+		}
+
+		if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Since.After(filter.Until) {
+			return nil, ErrSearchRange
+		}
+		if !filter.Since.IsZero() {
+			values.Set("since", strconv.FormatInt(filter.Since.UnixNano()/int64(time.Millisecond), 10))
+		}
+		if !filter.Until.IsZero() {
+			values.Set("until", strconv.FormatInt(filter.Until.UnixNano()/int64(time.Millisecond), 10))
+		}
+
+		if filter.Lang != nil && filter.Lang.SearchConfig != "" {
+			values.Set("lang", filter.Lang.SearchConfig)
+		}
+		if filter.Page > 0 {
+			values.Set("page", strconv.Itoa(filter.Page))
+		}
+		if filter.Size > 0 {
+			values.Set("size", strconv.Itoa(filter.Size))
+		}
+	}
+
+	resp := &ResponseSearch{}
+	err := makeAPIRequest("GET", "/v1/search?"+values.Encode(), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
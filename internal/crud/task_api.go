@@ -0,0 +1,173 @@
+package crud
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// types of assignees
+const (
+	AssigneeUser      = "USER"
+	AssigneeWorkspace = "WORKSPACE" // every member of a workspace or community gets own assignment
+)
+
+// statuses of assignments
+const (
+	AssignmentOpen = "OPEN"
+	AssignmentDone = "DONE"
+)
+
+var (
+	// ErrAssigneeEmpty is used when task list or quiz is assigned to nobody
+	ErrAssigneeEmpty = errors.New("task list or test must be assigned to at least one user or workspace")
+
+	// ErrTaskNotFound is used when task isn't found in an assignment
+	ErrTaskNotFound = errors.New("task not found")
+)
+
+// Assignment is a copy of task list assigned to a user, user marks tasks of assignment as done
+type Assignment struct {
+	UUID         string          `json:"uuid"`
+	TaskListUUID string          `json:"taskListUuid"`
+	Title        string          `json:"title"`
+	Status       string          `json:"status"` // Enum: OPEN, DONE
+	Assignee     *PublicUser     `json:"assignee"`
+	AssignedBy   *PublicUser     `json:"assignedBy,omitempty"`
+	Workspace    string          `json:"workspace,omitempty"` // uuid of workspace, if task list was assigned to a workspace
+	Items        []*TaskListItem `json:"items"`
+	DueAt        int             `json:"dueAt,omitempty"`
+	CreatedAt    int             `json:"createdAt,omitempty"`
+	CompletedAt  int             `json:"completedAt,omitempty"`
+}
+
+type ResponseAssignment struct {
+	Data *Assignment `json:"data"`
+}
+
+type ResponseAssignments struct {
+	Data []*Assignment `json:"data"`
+}
+
+// Assignee is a user or workspace, that task list is assigned to
+type Assignee struct {
+	Type string `json:"type"` // Enum: USER, WORKSPACE
+	UUID string `json:"uuid"`
+}
+
+// AssignmentRequest assigns task list to users and workspaces
+type AssignmentRequest struct {
+	Assignees []*Assignee `json:"assignees"`
+	DueAt     int         `json:"dueAt,omitempty"` // unix timestamp in milliseconds
+}
+
+// AssignmentFilter is used to filter listed assignments, empty fields are ignored
+type AssignmentFilter struct {
+	Status string
+}
+
+// Progress returns number of done tasks and number of all tasks
+func (a *Assignment) Progress() (done, total int) {
+	for _, item := range a.Items {
+		if item.Done {
+			done++
+		}
+	}
+
+	return done, len(a.Items)
+}
+
+// FindTask finds a task by it's uuid or by it's number in assignment, starting from 1
+func (a *Assignment) FindTask(task string) (*TaskListItem, error) {
+	for _, item := range a.Items {
+		if item.UUID == task {
+			return item, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(task); err == nil && n >= 1 && n <= len(a.Items) {
+		return a.Items[n-1], nil
+	}
+
+	return nil, errors.Wrapf(ErrTaskNotFound, "%s", task)
+}
+
+// AssignTaskList assigns task list to users and workspaces, each assignee gets own assignment. X-Auth-Token is needed for this request
+//
+// path: /v1/library/items/{uuid}/assignments
+//
+// method: post
+func AssignTaskList(taskListUUID string, req *AssignmentRequest, token string) (*ResponseAssignments, error) {
+	return assignLibraryItem(taskListUUID, req, token)
+}
+
+// assignLibraryItem assigns task list or quiz to users and workspaces
+func assignLibraryItem(itemUUID string, req *AssignmentRequest, token string) (*ResponseAssignments, error) {
+	if len(req.Assignees) == 0 {
+		return nil, ErrAssigneeEmpty
+	}
+
+	resp := &ResponseAssignments{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/library/items/%s/assignments", itemUUID), token, req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListAssignments lists assignments of a user, filter can be nil. X-Auth-Token is needed for this request
+//
+// path: /v1/users/{user_uuid}/assignments?status={status}
+//
+// method: get
+func ListAssignments(userUUID, token string, filter *AssignmentFilter) (*ResponseAssignments, error) {
+	path := fmt.Sprintf("/v1/users/%s/assignments", userUUID)
+	if filter != nil && filter.Status != "" {
+		path += "?status=" + filter.Status
+	}
+
+	resp := &ResponseAssignments{}
+	err := makeAPIRequest("GET", path, token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetAssignment gets an assignment by it's uuid. X-Auth-Token is needed for this request
+//
+// path: /v1/assignments/{uuid}
+//
+// method: get
+func GetAssignment(assignmentUUID, token string) (*ResponseAssignment, error) {
+	resp := &ResponseAssignment{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/assignments/%s", assignmentUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SetTaskDone marks a task of an assignment as done or not done, assignment is done when all of it's tasks are done.
+// X-Auth-Token is needed for this request
+//
+// path: /v1/assignments/{uuid}/tasks/{task_uuid}
+//
+// method: put
+func SetTaskDone(assignmentUUID, taskUUID string, done bool, token string) (*ResponseAssignment, error) {
+	data := struct {
+		Done bool `json:"done"`
+	}{done}
+
+	resp := &ResponseAssignment{}
+	err := makeAPIRequest("PUT", fmt.Sprintf("/v1/assignments/%s/tasks/%s", assignmentUUID, taskUUID), token, data, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,382 @@
+package crud
+
+import (
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	json "github.com/json-iterator/go"
+	"github.com/jszwec/csvutil"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrFolderPath is used when folder path of manifest row has empty folder title, ex: Biology//Week 1
+	ErrFolderPath = errors.New("folder path can't have empty folder titles")
+)
+
+// TextpadManifestRow is a single textpad described in bulk manifest
+type TextpadManifestRow struct {
+	Row         int    `csv:"-" json:"-"` // number of row in manifest, starting from 1
+	Title       string `csv:"title" json:"title"`
+	Subtitle    string `csv:"subtitle,omitempty" json:"subtitle"`
+	Folder      string `csv:"folder,omitempty" json:"folder"`            // path of parent folder, ex: Biology/Week 1
+	Lang        string `csv:"lang,omitempty" json:"lang"`                // iso code of textpad language
+	ContentFile string `csv:"content_file,omitempty" json:"contentFile"` // path to plain text file with textpad content, relative to manifest
+}
+
+// TextpadBulkResult is an outcome of creating textpad from a manifest row
+type TextpadBulkResult struct {
+	Row   int    `csv:"row"`
+	Title string `csv:"title"`
+	UUID  string `csv:"uuid"`
+	Error string `csv:"error"`
+}
+
+// ReadTextpadManifest reads textpad manifest from csv or json file, format is chosen by file extension.
+// Relative paths of content files are resolved from directory of manifest.
+func ReadTextpadManifest(path string) ([]*TextpadManifestRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening manifest")
+	}
+	defer f.Close()
+
+	rows := make([]*TextpadManifestRow, 0)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		dec, err := csvutil.NewDecoder(csv.NewReader(f))
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "reading manifest header")
+		}
+
+		for {
+			row := &TextpadManifestRow{}
+			if err := dec.Decode(row); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, errors.Wrapf(err, "decoding manifest row %d", len(rows)+1)
+			}
+
+			rows = append(rows, row)
+		}
+	case ".json":
+		err = json.NewDecoder(f).Decode(&rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding manifest")
+		}
+	default:
+		return nil, errors.Errorf("manifest must be either csv or json file, got %s", path)
+	}
+
+	for i, row := range rows {
+		if row == nil {
+			return nil, errors.Errorf("manifest row %d is empty", i+1)
+		}
+
+		row.Row = i + 1
+		if row.ContentFile != "" && !filepath.IsAbs(row.ContentFile) {
+			row.ContentFile = filepath.Join(filepath.Dir(path), row.ContentFile)
+		}
+	}
+
+	return rows, nil
+}
+
+// ReadTextpadBulkResults reads results of previous bulk creation, results are mapped by manifest row.
+//
+// If results file doesn't exist, then empty map is returned. Later results of the same row override earlier ones.
+func ReadTextpadBulkResults(path string) (map[int]*TextpadBulkResult, error) {
+	results := make(map[int]*TextpadBulkResult)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return results, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "opening results")
+	}
+	defer f.Close()
+
+	dec, err := csvutil.NewDecoder(csv.NewReader(f))
+	if err == io.EOF {
+		return results, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading results header")
+	}
+
+	for {
+		result := &TextpadBulkResult{}
+		if err := dec.Decode(result); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "decoding result")
+		}
+
+		results[result.Row] = result
+	}
+
+	return results, nil
+}
+
+// TextpadBulkResultWriter appends results of bulk creation to csv file as soon as they are ready,
+// so results aren't lost if bulk creation is interrupted. It's safe for concurrent use.
+type TextpadBulkResultWriter struct {
+	mu  sync.Mutex
+	f   *os.File
+	w   *csv.Writer
+	enc *csvutil.Encoder
+}
+
+// NewTextpadBulkResultWriter opens results file for appending, header is written only to an empty file.
+func NewTextpadBulkResultWriter(path string) (*TextpadBulkResultWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening results")
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "getting results file info")
+	}
+
+	w := csv.NewWriter(f)
+	enc := csvutil.NewEncoder(w)
+	enc.AutoHeader = info.Size() == 0
+
+	return &TextpadBulkResultWriter{f: f, w: w, enc: enc}, nil
+}
+
+// Write appends result to results file.
+func (rw *TextpadBulkResultWriter) Write(result *TextpadBulkResult) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	err := rw.enc.Encode(result)
+	if err != nil {
+		return errors.Wrap(err, "encoding result")
+	}
+
+	rw.w.Flush()
+	return rw.w.Error()
+}
+
+// Close closes results file.
+func (rw *TextpadBulkResultWriter) Close() error {
+	return rw.f.Close()
+}
+
+// TextpadBulk creates textpads described in manifest rows concurrently
+type TextpadBulk struct {
+	User        *PrivateUser
+	Token       string
+	Concurrency int                      // maximum number of textpads created at the same time
+	Skip        map[int]bool             // manifest rows that are already created
+	OnResult    func(*TextpadBulkResult) // called for each created or failed row, can be nil
+
+	folders   map[string]*LibraryItem // folders by their paths
+	languages *ResponseLanguages
+}
+
+// NewTextpadBulk is a constructor for TextpadBulk.
+func NewTextpadBulk(user *PrivateUser, token string, concurrency int) *TextpadBulk {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &TextpadBulk{
+		User:        user,
+		Token:       token,
+		Concurrency: concurrency,
+		Skip:        make(map[int]bool),
+	}
+}
+
+// Create creates textpads for manifest rows, that aren't skipped.
+//
+// Folders from manifest are resolved by their paths before creating textpads and missing folders are created.
+// Error is returned only if folders or languages can't be fetched, failures of single rows are reported in results.
+func (b *TextpadBulk) Create(rows []*TextpadManifestRow) ([]*TextpadBulkResult, error) {
+	pending := make([]*TextpadManifestRow, 0, len(rows))
+	for _, row := range rows {
+		if !b.Skip[row.Row] {
+			pending = append(pending, row)
+		}
+	}
+
+	if len(pending) == 0 {
+		return []*TextpadBulkResult{}, nil
+	}
+
+	if err := b.prepare(pending); err != nil {
+		return nil, err
+	}
+
+	results := make([]*TextpadBulkResult, len(pending))
+	sem := make(chan struct{}, b.Concurrency)
+	wg := &sync.WaitGroup{}
+	for i, row := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, row *TextpadManifestRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := &TextpadBulkResult{Row: row.Row, Title: row.Title}
+			uuid, err := b.createRow(row)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.UUID = uuid
+			}
+			results[i] = result
+
+			if b.OnResult != nil {
+				b.OnResult(result)
+			}
+		}(i, row)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// prepare fetches languages and resolves folders used by manifest rows
+func (b *TextpadBulk) prepare(rows []*TextpadManifestRow) error {
+	var needLanguages, needFolders bool
+	for _, row := range rows {
+		needLanguages = needLanguages || row.Lang != ""
+		needFolders = needFolders || row.Folder != ""
+	}
+
+	if needLanguages {
+		resp, err := ListLanguages(b.Token)
+		if err != nil {
+			return errors.Wrap(err, "listing languages")
+		}
+		b.languages = resp
+	}
+
+	b.folders = make(map[string]*LibraryItem)
+	if !needFolders {
+		return nil
+	}
+
+	resp, err := ListLibrary(b.User.UUID, b.Token, &LibraryFilter{Type: LibraryFolder})
+	if err != nil {
+		return errors.Wrap(err, "listing folders")
+	}
+	for _, folder := range resp.Data {
+		b.folders[folderPath(folder)] = folder
+	}
+
+	// create missing folders one by one, so parents are created before their children,
+	// rows with invalid folder paths are failed when they are created
+	for _, row := range rows {
+		titles, err := splitFolderPath(row.Folder)
+		if row.Folder == "" || err != nil {
+			continue
+		}
+
+		var parent *LibraryItem
+		path := ""
+		for _, title := range titles {
+			if path != "" {
+				path += "/"
+			}
+			path += title
+
+			folder, ok := b.folders[path]
+			if !ok {
+				folder = NewFolder(title, "", b.User)
+				if parent != nil {
+					folder.SetParent(parent)
+				}
+
+				_, err := folder.Create(b.Token)
+				if err != nil {
+					return errors.Wrapf(err, "creating folder %s", path)
+				}
+				b.folders[path] = folder
+			}
+			parent = folder
+		}
+	}
+
+	return nil
+}
+
+// createRow creates a single textpad and returns it's uuid
+func (b *TextpadBulk) createRow(row *TextpadManifestRow) (string, error) {
+	if row.Title == "" {
+		return "", errors.New("title can't be empty")
+	}
+
+	textpad := NewTextpad(row.Title, row.Subtitle, b.User)
+
+	if row.Lang != "" {
+		lang, err := b.languages.FindLanguage(row.Lang)
+		if err != nil {
+			return "", err
+		}
+		textpad.Lang = lang
+	}
+
+	if row.Folder != "" {
+		titles, err := splitFolderPath(row.Folder)
+		if err != nil {
+			return "", err
+		}
+		textpad.SetParent(b.folders[strings.Join(titles, "/")])
+	}
+
+	if row.ContentFile != "" {
+		content, err := ioutil.ReadFile(row.ContentFile)
+		if err != nil {
+			return "", errors.Wrap(err, "reading content file")
+		}
+
+		text := string(content)
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		textpad.Delta = &Delta{Ops: []*Op{{Insert: text}}}
+	}
+
+	resp, err := textpad.Create(b.Token)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Data.UUID, nil
+}
+
+// splitFolderPath splits folder path of manifest row to titles of folders, leading and trailing slashes are ignored
+func splitFolderPath(path string) ([]string, error) {
+	titles := strings.Split(strings.Trim(path, "/"), "/")
+	for _, title := range titles {
+		if strings.TrimSpace(title) == "" {
+			return nil, errors.Wrapf(ErrFolderPath, "%s", path)
+		}
+	}
+
+	return titles, nil
+}
+
+// folderPath joins titles of folder's path and folder itself by slash
+func folderPath(folder *LibraryItem) string {
+	titles := make([]string, 0, len(folder.Path)+1)
+	for _, p := range folder.Path {
+		titles = append(titles, p.Title)
+	}
+	titles = append(titles, folder.Title)
+
+	return strings.Join(titles, "/")
+}
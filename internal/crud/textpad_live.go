@@ -0,0 +1,333 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// TextpadChange is a single change of textpad content made by a user
+type TextpadChange struct {
+	ID      string      `json:"id"`      // uuid generated by author of a change
+	Version int         `json:"version"` // version of textpad, that change is based on when submitted, or version after change is applied when received
+	User    *PublicUser `json:"user,omitempty"`
+	Delta   *Delta      `json:"delta"`
+}
+
+type ResponseTextpadChanges struct {
+	Data []*TextpadChange `json:"data"`
+}
+
+// ListTextpadChanges waits for changes of a textpad made after specified version, empty list is returned if there are no changes till server's timeout.
+// X-Auth-Token is needed for this request
+//
+// path: /v1/textpads/{uuid}/changes?since={version}
+//
+// method: get
+func ListTextpadChanges(ctx context.Context, textpadUUID string, version int, token string) (*ResponseTextpadChanges, error) {
+	resp := &ResponseTextpadChanges{}
+	err := makeAPIRequestWithContext(ctx, "GET", fmt.Sprintf("/v1/textpads/%s/changes?since=%d", textpadUUID, version), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SubmitTextpadChange submits a change of textpad content, server transforms it against changes made after it's version.
+// Changes with the same id are applied only once. Change is acknowledged when it's received back by ListTextpadChanges. X-Auth-Token is needed for this request
+//
+// path: /v1/textpads/{uuid}/changes
+//
+// method: post
+func SubmitTextpadChange(ctx context.Context, textpadUUID string, change *TextpadChange, token string) error {
+	return makeAPIRequestWithContext(ctx, "POST", fmt.Sprintf("/v1/textpads/%s/changes", textpadUUID), token, change, nil)
+}
+
+// LiveTextpad is a client for collaborative editing of a textpad.
+//
+// Local changes are applied to document immediately and sent to server one at a time,
+// remote changes are transformed against local changes that aren't acknowledged yet, so document stays consistent with server.
+// It's safe for concurrent use.
+type LiveTextpad struct {
+	UUID string
+
+	// OnChange is called for each remote change, after it's transformed and applied to document, can be nil
+	OnChange func(change *TextpadChange)
+
+	// PollTimeout is a timeout of waiting for changes from server
+	PollTimeout time.Duration
+
+	token string
+	user  *PublicUser
+
+	mu          sync.Mutex
+	doc         *Delta
+	version     int
+	outstanding *TextpadChange // sent to server, but not acknowledged
+	resend      bool           // outstanding change has to be sent (again)
+	buffer      *Delta         // not sent to server yet
+	notify      chan struct{}
+}
+
+// OpenLiveTextpad gets current content of a textpad and opens it for collaborative editing.
+func OpenLiveTextpad(textpadUUID string, user *PrivateUser, token string) (*LiveTextpad, error) {
+	resp, err := GetLibraryItem(textpadUUID, token)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting textpad")
+	}
+
+	doc := resp.Data.Delta
+	if doc == nil {
+		doc = NewDelta().Insert("\n", nil)
+	}
+
+	return &LiveTextpad{
+		UUID:        textpadUUID,
+		PollTimeout: 60 * time.Second,
+		token:       token,
+		user:        user.ToPublic(),
+		doc:         doc,
+		version:     resp.Data.Version,
+		notify:      make(chan struct{}, 1),
+	}, nil
+}
+
+// Text returns current text of a document.
+func (l *LiveTextpad) Text() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.doc.Text()
+}
+
+// Document returns current content of a document.
+func (l *LiveTextpad) Document() *Delta {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return NewDelta().Compose(l.doc)
+}
+
+// Version returns last version of a textpad received from server.
+func (l *LiveTextpad) Version() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.version
+}
+
+// Submit applies local change to a document and schedules it for sending to server.
+func (l *LiveTextpad) Submit(delta *Delta) {
+	l.mu.Lock()
+	l.submit(delta)
+	l.mu.Unlock()
+
+	l.wake()
+}
+
+// Append inserts text at the end of a document, before it's final new line.
+func (l *LiveTextpad) Append(text string) {
+	l.mu.Lock()
+	l.submit(NewDelta().Retain(l.doc.Length()-1, nil).Insert(text, nil))
+	l.mu.Unlock()
+
+	l.wake()
+}
+
+// submit must be called with lock held
+func (l *LiveTextpad) submit(delta *Delta) {
+	l.doc = l.doc.Compose(delta)
+	if l.buffer == nil {
+		l.buffer = delta
+	} else {
+		l.buffer = l.buffer.Compose(delta)
+	}
+}
+
+// wake signals that there may be changes to send
+func (l *LiveTextpad) wake() {
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends local changes and receives remote changes, until ctx is done or server fails repeatedly.
+func (l *LiveTextpad) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, 2)
+
+	// send local changes
+	go func() {
+		backoff := time.Second
+		for failures := 0; ; {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case <-l.notify:
+			}
+
+			if err := l.flush(ctx); err != nil {
+				failures++
+				if failures > 5 {
+					errc <- err
+					return
+				}
+
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+				}
+				backoff *= 2
+
+				l.wake()
+				continue
+			}
+			failures, backoff = 0, time.Second
+		}
+	}()
+
+	// receive remote changes
+	go func() {
+		backoff := time.Second
+		for failures := 0; ; {
+			pollCtx, pollCancel := context.WithTimeout(ctx, l.PollTimeout)
+			resp, err := ListTextpadChanges(pollCtx, l.UUID, l.Version(), l.token)
+			pollCancel()
+
+			if ctx.Err() != nil {
+				errc <- ctx.Err()
+				return
+			} else if err != nil {
+				failures++
+				if failures > 5 {
+					errc <- errors.Wrap(err, "receiving changes")
+					return
+				}
+
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+				}
+				backoff *= 2
+				continue
+			}
+			failures, backoff = 0, time.Second
+
+			for _, change := range resp.Data {
+				l.receive(change)
+			}
+		}
+	}()
+
+	return <-errc
+}
+
+// flush sends buffered local changes, if previously sent change is acknowledged, or resends change that failed to be sent
+func (l *LiveTextpad) flush(ctx context.Context) error {
+	l.mu.Lock()
+	if l.outstanding == nil {
+		if l.buffer == nil {
+			l.mu.Unlock()
+			return nil
+		}
+
+		l.outstanding = &TextpadChange{
+			ID:    uuid.New().String(),
+			User:  l.user,
+			Delta: l.buffer,
+		}
+		l.buffer = nil
+		l.resend = true
+	}
+
+	if !l.resend {
+		l.mu.Unlock()
+		return nil
+	}
+
+	// outstanding change is always based on the last received version, as it's transformed against remote changes
+	change := &TextpadChange{
+		ID:      l.outstanding.ID,
+		Version: l.version,
+		User:    l.outstanding.User,
+		Delta:   l.outstanding.Delta,
+	}
+	l.resend = false
+	l.mu.Unlock()
+
+	sendCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := SubmitTextpadChange(sendCtx, l.UUID, change, l.token)
+	if err != nil {
+		// change is sent again with the same id, so server won't apply it twice
+		l.mu.Lock()
+		if l.outstanding != nil && l.outstanding.ID == change.ID {
+			l.resend = true
+		}
+		l.mu.Unlock()
+
+		return errors.Wrap(err, "submitting change")
+	}
+
+	return nil
+}
+
+// receive handles a change received from server, it's either an acknowledgement of local change or a remote change
+func (l *LiveTextpad) receive(change *TextpadChange) {
+	l.mu.Lock()
+
+	if change.Version <= l.version {
+		l.mu.Unlock()
+		return
+	}
+
+	// acknowledgement of local change, it's already applied to document
+	if l.outstanding != nil && change.ID == l.outstanding.ID {
+		l.version = change.Version
+		l.outstanding = nil
+		l.mu.Unlock()
+
+		l.wake()
+		return
+	}
+
+	// server applied remote change before local changes, so they are transformed against each other
+	remote := change.Delta
+	if l.outstanding != nil {
+		local := l.outstanding.Delta
+		l.outstanding = &TextpadChange{
+			ID:    l.outstanding.ID,
+			User:  l.outstanding.User,
+			Delta: remote.Transform(local, true),
+		}
+		remote = local.Transform(remote, false)
+	}
+	if l.buffer != nil {
+		local := l.buffer
+		l.buffer = remote.Transform(local, true)
+		remote = local.Transform(remote, false)
+	}
+
+	l.doc = l.doc.Compose(remote)
+	l.version = change.Version
+	l.mu.Unlock()
+
+	if l.OnChange != nil {
+		l.OnChange(&TextpadChange{
+			ID:      change.ID,
+			Version: change.Version,
+			User:    change.User,
+			Delta:   remote,
+		})
+	}
+}
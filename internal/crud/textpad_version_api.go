@@ -0,0 +1,119 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextpadVersion is a saved state of textpad content
+type TextpadVersion struct {
+	Version   int         `json:"version"`
+	User      *PublicUser `json:"user"`
+	CreatedAt int         `json:"createdAt"`
+	Delta     *Delta      `json:"delta,omitempty"`
+}
+
+type ResponseTextpadVersion struct {
+	Data *TextpadVersion `json:"data"`
+}
+
+type ResponseTextpadVersions struct {
+	Data []*TextpadVersion `json:"data"`
+}
+
+// ListTextpadVersions lists versions of a textpad, deltas are omitted by server. X-Auth-Token is needed for this request
+//
+// path: /v1/textpads/{uuid}/versions
+//
+// method: get
+func ListTextpadVersions(textpadUUID, token string) (*ResponseTextpadVersions, error) {
+	resp := &ResponseTextpadVersions{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/textpads/%s/versions", textpadUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetTextpadVersion gets a specific version of a textpad with it's delta. X-Auth-Token is needed for this request
+//
+// path: /v1/textpads/{uuid}/versions/{version}
+//
+// method: get
+func GetTextpadVersion(textpadUUID string, version int, token string) (*ResponseTextpadVersion, error) {
+	resp := &ResponseTextpadVersion{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/textpads/%s/versions/%d", textpadUUID, version), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// RestoreTextpadVersion restores content of a textpad to specified version, restoring creates a new version. X-Auth-Token is needed for this request
+//
+// path: /v1/textpads/{uuid}/versions/{version}/restore
+//
+// method: post
+func RestoreTextpadVersion(textpadUUID string, version int, token string) (*ResponseTextpad, error) {
+	resp := &ResponseTextpad{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/textpads/%s/versions/%d/restore", textpadUUID, version), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DiffVersions renders a line by line diff between texts of two textpad versions.
+//
+// Removed lines are prefixed with "-", added lines with "+" and unchanged lines with a space.
+func DiffVersions(from, to *TextpadVersion) string {
+	a := strings.Split(strings.TrimSuffix(from.Delta.Text(), "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(to.Delta.Text(), "\n"), "\n")
+
+	// lcs[i][j] is a length of longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "--- version %d\n+++ version %d\n", from.Version, to.Version)
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			fmt.Fprintf(sb, " %s\n", a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			fmt.Fprintf(sb, "-%s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(sb, "+%s\n", b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		fmt.Fprintf(sb, "-%s\n", a[i])
+	}
+	for ; j < len(b); j++ {
+		fmt.Fprintf(sb, "+%s\n", b[j])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,198 @@
+package crud
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// userSearchPageSize is a number of users fetched at once, when searching a user by username or full name
+const userSearchPageSize = 50
+
+var (
+	// ErrUserAmbiguous is used when several users match searched full name
+	ErrUserAmbiguous = errors.New("several users match")
+)
+
+// AmbiguousUserError is returned when several users match searched full name, it holds all matched users
+type AmbiguousUserError struct {
+	Fullname string
+	Users    []*PublicUser
+}
+
+func (e *AmbiguousUserError) Error() string {
+	return fmt.Sprintf("%d users match %s", len(e.Users), e.Fullname)
+}
+
+// Is makes AmbiguousUserError match ErrUserAmbiguous
+func (e *AmbiguousUserError) Is(target error) bool {
+	return target == ErrUserAmbiguous
+}
+
+type ResponsePublicUser struct {
+	Data *PublicUser `json:"data"`
+}
+
+type ResponsePublicUsers struct {
+	Data  []*PublicUser `json:"data"`
+	Page  int           `json:"page,omitempty"`
+	Size  int           `json:"size,omitempty"`
+	Total int           `json:"total,omitempty"` // number of users matching search on all pages
+}
+
+// UserFilter is used to filter and page searched users, empty fields are ignored
+type UserFilter struct {
+	Page   int   // number of page, starting from 1
+	Size   int   // number of users on a page
+	Online *bool // only users, that are online or offline
+	Tutor  *bool // only tutors or not tutors
+	Guest  *bool // only guests or registered users
+}
+
+// FullName returns first and last names of a user separated by space
+func (u *PublicUser) FullName() string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// SearchUsers searches users by their names, username or email, filter can be nil. X-Auth-Token is needed for this request
+//
+// path: /v1/users/search?query={query}&page={page}&size={size}&online={online}&tutor={tutor}&guest={guest}
+//
+// method: get
+func SearchUsers(query, token string, filter *UserFilter) (*ResponsePublicUsers, error) {
+	values := url.Values{"query": []string{query}}
+	if filter != nil {
+		if filter.Page > 0 {
+			values.Set("page", strconv.Itoa(filter.Page))
+		}
+		if filter.Size > 0 {
+			values.Set("size", strconv.Itoa(filter.Size))
+		}
+		if filter.Online != nil {
+			values.Set("online", strconv.FormatBool(*filter.Online))
+		}
+		if filter.Tutor != nil {
+			values.Set("tutor", strconv.FormatBool(*filter.Tutor))
+		}
+		if filter.Guest != nil {
+			values.Set("guest", strconv.FormatBool(*filter.Guest))
+		}
+	}
+
+	resp := &ResponsePublicUsers{}
+	err := makeAPIRequest("GET", "/v1/users/search?"+values.Encode(), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetUser gets public profile of a user by it's uuid. X-Auth-Token is needed for this request
+//
+// path: /v1/users/{uuid}
+//
+// method: get
+func GetUser(userUUID, token string) (*ResponsePublicUser, error) {
+	resp := &ResponsePublicUser{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/users/%s", userUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// FindUser finds a user by uuid or by username, username must start with @.
+func FindUser(user, token string) (*PublicUser, error) {
+	if strings.HasPrefix(user, "@") {
+		return FindUserByUsername(user, token)
+	}
+
+	resp, err := GetUser(user, token)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// FindUserByUsername finds a user with exactly the same username, case of username and leading @ are ignored.
+// Search results are paged until the user is found.
+func FindUserByUsername(username, token string) (*PublicUser, error) {
+	username = strings.TrimPrefix(username, "@")
+
+	var found *PublicUser
+	err := searchUserPages(username, token, func(user *PublicUser) bool {
+		if strings.EqualFold(user.UserName, username) {
+			found = user
+		}
+
+		return found != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return found, nil
+}
+
+// FindUserByFullname finds a user with exactly the same full name, case of full name is ignored.
+//
+// All pages of search results are checked, if several users share full name, then AmbiguousUserError with all of them is returned.
+func FindUserByFullname(fullname, token string) (*PublicUser, error) {
+	fullname = strings.Join(strings.Fields(fullname), " ")
+
+	matched := make([]*PublicUser, 0, 1)
+	err := searchUserPages(fullname, token, func(user *PublicUser) bool {
+		if strings.EqualFold(user.FullName(), fullname) {
+			matched = append(matched, user)
+		}
+
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(matched) {
+	case 0:
+		return nil, ErrUserNotFound
+	case 1:
+		return matched[0], nil
+	}
+
+	return nil, &AmbiguousUserError{Fullname: fullname, Users: matched}
+}
+
+// searchUserPages searches users page by page and passes each of them to match, until match returns true or all users are fetched.
+// If server doesn't report total, pages are fetched until a page shorter than page size is returned
+func searchUserPages(query, token string, match func(user *PublicUser) bool) error {
+	fetched := 0
+	for page := 1; ; page++ {
+		resp, err := SearchUsers(query, token, &UserFilter{Page: page, Size: userSearchPageSize})
+		if err != nil {
+			return errors.Wrapf(err, "searching page %d", page)
+		}
+
+		for _, user := range resp.Data {
+			if match(user) {
+				return nil
+			}
+		}
+
+		n := len(resp.Data)
+		fetched += n
+		if n == 0 || (resp.Total > 0 && fetched >= resp.Total) || (resp.Total == 0 && n < userSearchPageSize) {
+			return nil
+		}
+	}
+}
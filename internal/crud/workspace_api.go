@@ -0,0 +1,191 @@
+package crud
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// types of workspaces
+const (
+	WorkspaceTypeWorkspace = "WORKSPACE"
+	WorkspaceTypeCommunity = "COMMUNITY"
+)
+
+// roles of workspace and community members
+const (
+	RoleWorkspaceGuest       = "WORKSPACE_GUEST"
+	RoleWorkspaceMember      = "WORKSPACE_MEMBER"
+	RoleWorkspaceAdmin       = "WORKSPACE_ADMIN"
+	RoleCommunityGuest       = "COMMUNITY_GUEST"
+	RoleCommunityMember      = "COMMUNITY_MEMBER"
+	RoleCommunityAdmin       = "COMMUNITY_ADMIN"
+	RoleCommunityTaskManager = "COMMUNITY_TASK_MANAGER"
+	RoleModerator            = "MODERATOR"
+	RolePaidMember           = "PAID_MEMBER" // member with paid membership, roles are granted by billing only
+	RoleNodeOwner            = "NODE_OWNER"  // author of paid content
+	RoleNodeBuyer            = "NODE_BUYER"  // user, who bought paid content
+)
+
+var (
+	// ErrWorkspaceType is used when workspace type isn't supported
+	ErrWorkspaceType = errors.Errorf("workspace type must be either %s or %s", WorkspaceTypeWorkspace, WorkspaceTypeCommunity)
+)
+
+// Workspace is a space for a group of users, like a course or a class, it has it's own chat and library.
+// Community is a public workspace, that users can join by themselves
+type Workspace struct {
+	UUID         string      `json:"uuid"`
+	Type         string      `json:"type"` // Enum: WORKSPACE, COMMUNITY
+	Title        string      `json:"title"`
+	Description  string      `json:"description,omitempty"`
+	Owner        *PublicUser `json:"owner,omitempty"`
+	Archived     bool        `json:"archived"`
+	MembersCount int         `json:"membersCount,omitempty"`
+	CreatedAt    int         `json:"createdAt,omitempty"`
+	UpdatedAt    int         `json:"updatedAt,omitempty"`
+}
+
+type ResponseWorkspace struct {
+	Data *Workspace `json:"data"`
+}
+
+type ResponseWorkspaces struct {
+	Data []*Workspace `json:"data"`
+}
+
+// WorkspaceFilter is used to filter listed workspaces, empty fields are ignored
+type WorkspaceFilter struct {
+	Type     string
+	Archived bool // list archived workspaces instead of active ones
+}
+
+// NewWorkspace is a constructor for workspace or community to be created in Acroplia
+func NewWorkspace(workspaceType, title, description string) (*Workspace, error) {
+	if workspaceType != WorkspaceTypeWorkspace && workspaceType != WorkspaceTypeCommunity {
+		return nil, ErrWorkspaceType
+	}
+
+	return &Workspace{
+		Type:        workspaceType,
+		Title:       title,
+		Description: description,
+	}, nil
+}
+
+// Create creates workspace in Acroplia. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces
+//
+// method: post
+func (w *Workspace) Create(token string) (*ResponseWorkspace, error) {
+	resp := &ResponseWorkspace{}
+	err := makeAPIRequest("POST", "/v1/workspaces", token, w, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListWorkspaces lists workspaces and communities of current user. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces?type={type}&archived={archived}
+//
+// method: get
+func ListWorkspaces(token string, filter *WorkspaceFilter) (*ResponseWorkspaces, error) {
+	values := url.Values{}
+	if filter != nil {
+		if filter.Type != "" {
+			values.Set("type", filter.Type)
+		}
+		if filter.Archived {
+			values.Set("archived", strconv.FormatBool(filter.Archived))
+		}
+	}
+
+	path := "/v1/workspaces"
+	if len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+
+	resp := &ResponseWorkspaces{}
+	err := makeAPIRequest("GET", path, token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetWorkspace gets a workspace by it's uuid. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}
+//
+// method: get
+func GetWorkspace(workspaceUUID, token string) (*ResponseWorkspace, error) {
+	resp := &ResponseWorkspace{}
+	err := makeAPIRequest("GET", fmt.Sprintf("/v1/workspaces/%s", workspaceUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Update updates title and description of a workspace, only admins can update it. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}
+//
+// method: put
+func (w *Workspace) Update(token string) (*ResponseWorkspace, error) {
+	data := struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}{w.Title, w.Description}
+
+	resp := &ResponseWorkspace{}
+	err := makeAPIRequest("PUT", fmt.Sprintf("/v1/workspaces/%s", w.UUID), token, data, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// RenameWorkspace changes title of a workspace, only admins can rename it. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}
+//
+// method: put
+func RenameWorkspace(workspaceUUID, title, token string) (*ResponseWorkspace, error) {
+	data := struct {
+		Title string `json:"title"`
+	}{title}
+
+	resp := &ResponseWorkspace{}
+	err := makeAPIRequest("PUT", fmt.Sprintf("/v1/workspaces/%s", workspaceUUID), token, data, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ArchiveWorkspace archives a workspace, archived workspace is read only and is hidden from workspace list.
+// Only admins can archive it. X-Auth-Token is needed for this request
+//
+// path: /v1/workspaces/{uuid}/archive
+//
+// method: post
+func ArchiveWorkspace(workspaceUUID, token string) (*ResponseWorkspace, error) {
+	resp := &ResponseWorkspace{}
+	err := makeAPIRequest("POST", fmt.Sprintf("/v1/workspaces/%s/archive", workspaceUUID), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
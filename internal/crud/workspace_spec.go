@@ -0,0 +1,639 @@
+package crud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// actions of provisioning plan
+const (
+	PlanCreate = "CREATE"
+	PlanUpdate = "UPDATE"
+	PlanDelete = "DELETE"
+)
+
+// kinds of resources changed by provisioning plan
+const (
+	PlanKindWorkspace = "WORKSPACE"
+	PlanKindMember    = "MEMBER"
+	PlanKindFolder    = "FOLDER"
+	PlanKindTextpad   = "TEXTPAD"
+)
+
+// statuses of applied plan actions
+const (
+	PlanStatusApplied = "APPLIED"
+	PlanStatusFailed  = "FAILED"
+	PlanStatusSkipped = "SKIPPED"
+)
+
+// SpecMember is a desired member of a workspace
+type SpecMember struct {
+	User  string   `mapstructure:"user"`  // email or username with or without leading @
+	Roles []string `mapstructure:"roles"` // roles in addition to member role of workspace type, that member always has
+}
+
+// SpecWorkspace is a desired workspace or community, it's matched with existing one by type and title
+type SpecWorkspace struct {
+	Type        string        `mapstructure:"type"` // by default WORKSPACE
+	Title       string        `mapstructure:"title"`
+	Description string        `mapstructure:"description"`
+	Members     []*SpecMember `mapstructure:"members"`
+	Prune       bool          `mapstructure:"prune"` // remove members, that aren't in spec
+}
+
+// SpecFolder is a desired library folder, it's matched with existing one by path
+type SpecFolder struct {
+	Path     string `mapstructure:"path"` // titles of parent folders and folder itself, ex: Biology/Week 1
+	Subtitle string `mapstructure:"subtitle"`
+}
+
+// SpecTextpad is a desired seed textpad, it's matched with existing one by folder and title
+type SpecTextpad struct {
+	Title    string   `mapstructure:"title"`
+	Subtitle string   `mapstructure:"subtitle"`
+	Folder   string   `mapstructure:"folder"` // path of parent folder, missing folders are created
+	Text     string   `mapstructure:"text"`
+	Tags     []string `mapstructure:"tags"`
+}
+
+// Spec describes workspaces, their members and library layout, that should exist in Acroplia.
+// It's read from yaml or toml file:
+//
+//	workspaces:
+//	  - title: Biology 101
+//	    description: Autumn course
+//	    prune: true
+//	    members:
+//	      - user: "@ekaterina"
+//	        roles: [WORKSPACE_ADMIN]
+//	      - user: student@example.com
+//	folders:
+//	  - path: Biology 101/Week 1
+//	textpads:
+//	  - title: Syllabus
+//	    folder: Biology 101
+//	    text: Welcome to the course
+//
+// Library items are never deleted, even if they aren't in spec anymore.
+type Spec struct {
+	Workspaces []*SpecWorkspace `mapstructure:"workspaces"`
+	Folders    []*SpecFolder    `mapstructure:"folders"`
+	Textpads   []*SpecTextpad   `mapstructure:"textpads"`
+}
+
+// LoadSpec reads spec from a file, format is chosen by file extension.
+func LoadSpec(path string) (*Spec, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Wrap(err, "reading spec")
+	}
+
+	s := &Spec{}
+	if err := v.Unmarshal(s); err != nil {
+		return nil, errors.Wrap(err, "decoding spec")
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate checks spec and sets defaults, so spec errors are found before any request is made
+func (s *Spec) Validate() error {
+	workspaces := make(map[string]bool)
+	for i, ws := range s.Workspaces {
+		ws.Type = strings.ToUpper(ws.Type)
+		if ws.Type == "" {
+			ws.Type = WorkspaceTypeWorkspace
+		}
+		if ws.Type != WorkspaceTypeWorkspace && ws.Type != WorkspaceTypeCommunity {
+			return errors.Wrapf(ErrWorkspaceType, "workspace %d", i+1)
+		}
+		if ws.Title == "" {
+			return errors.Errorf("workspace %d has empty title", i+1)
+		}
+		if workspaces[workspaceKey(ws.Type, ws.Title)] {
+			return errors.Errorf("workspace %s is described twice", ws.Title)
+		}
+		workspaces[workspaceKey(ws.Type, ws.Title)] = true
+
+		users := make(map[string]bool)
+		for _, m := range ws.Members {
+			user := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(m.User), "@"))
+			if user == "" {
+				return errors.Wrapf(ErrInvitationEmpty, "workspace %s", ws.Title)
+			}
+			if users[user] {
+				return errors.Errorf("member %s of workspace %s is described twice", m.User, ws.Title)
+			}
+			users[user] = true
+
+			for j, role := range m.Roles {
+				m.Roles[j] = strings.ToUpper(role)
+				if err := ValidateRole(m.Roles[j]); err != nil {
+					return errors.Wrapf(err, "member %s of workspace %s", m.User, ws.Title)
+				}
+			}
+		}
+	}
+
+	for i, folder := range s.Folders {
+		folder.Path = strings.Trim(folder.Path, "/")
+		if folder.Path == "" {
+			return errors.Errorf("folder %d has empty path", i+1)
+		}
+	}
+
+	for i, textpad := range s.Textpads {
+		textpad.Folder = strings.Trim(textpad.Folder, "/")
+		if textpad.Title == "" {
+			return errors.Errorf("textpad %d has empty title", i+1)
+		}
+	}
+
+	return nil
+}
+
+// PlanAction is a single change of provisioning plan
+type PlanAction struct {
+	Action  string `json:"action"` // Enum: CREATE, UPDATE, DELETE
+	Kind    string `json:"kind"`   // Enum: WORKSPACE, MEMBER, FOLDER, TEXTPAD
+	Target  string `json:"target"` // human readable name of changed resource, ex: Biology 101/@ekaterina
+	Details string `json:"details,omitempty"`
+	Status  string `json:"status,omitempty"` // Enum: APPLIED, FAILED, SKIPPED, set only when plan is applied
+	Error   string `json:"error,omitempty"`
+
+	apply func() error
+}
+
+// Plan is a list of changes, that bring Acroplia to the state described by spec. Changes are applied in order
+type Plan struct {
+	Actions []*PlanAction `json:"actions"`
+}
+
+// Empty checks if Acroplia is already in the state described by spec
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+func (p *Plan) add(action, kind, target, details string, apply func() error) {
+	p.Actions = append(p.Actions, &PlanAction{
+		Action:  action,
+		Kind:    kind,
+		Target:  target,
+		Details: details,
+		apply:   apply,
+	})
+}
+
+// Provisioner computes and applies plans for specs on behalf of a user
+type Provisioner struct {
+	User  *PrivateUser
+	Token string
+
+	workspaces map[string]*Workspace   // key is type and title of workspace
+	folders    map[string]*LibraryItem // key is path of folder
+}
+
+// NewProvisioner is a constructor for Provisioner
+func NewProvisioner(user *PrivateUser, token string) *Provisioner {
+	return &Provisioner{
+		User:  user,
+		Token: token,
+	}
+}
+
+// Plan compares spec with current state of Acroplia and returns changes, that are needed to reach spec.
+//
+// Nothing is changed in Acroplia, so it's safe to use it as a dry run.
+func (p *Provisioner) Plan(spec *Spec) (*Plan, error) {
+	plan := &Plan{Actions: make([]*PlanAction, 0)}
+
+	if err := p.planWorkspaces(plan, spec.Workspaces); err != nil {
+		return nil, err
+	}
+	if err := p.planLibrary(plan, spec.Folders, spec.Textpads); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// Apply applies plan actions in order and stops at the first failed action, remaining actions are skipped.
+//
+// onAction is called after each action, it can be nil. Plan is computed from server state,
+// so applying a new plan for the same spec continues from the failed action.
+func (p *Provisioner) Apply(plan *Plan, onAction func(action *PlanAction)) error {
+	var failed error
+	for _, action := range plan.Actions {
+		switch {
+		case failed != nil:
+			action.Status = PlanStatusSkipped
+		case action.apply == nil:
+			failed = errors.Errorf("%s %s %s wasn't planned by this provisioner", action.Action, action.Kind, action.Target)
+			action.Status, action.Error = PlanStatusFailed, failed.Error()
+		default:
+			if err := action.apply(); err != nil {
+				failed = errors.Wrapf(err, "%s %s %s", strings.ToLower(action.Action), strings.ToLower(action.Kind), action.Target)
+				action.Status, action.Error = PlanStatusFailed, err.Error()
+			} else {
+				action.Status = PlanStatusApplied
+			}
+		}
+
+		if onAction != nil {
+			onAction(action)
+		}
+	}
+
+	return failed
+}
+
+// planWorkspaces plans creation and updates of workspaces and their members
+func (p *Provisioner) planWorkspaces(plan *Plan, specs []*SpecWorkspace) error {
+	p.workspaces = make(map[string]*Workspace)
+	if len(specs) == 0 {
+		return nil
+	}
+
+	resp, err := ListWorkspaces(p.Token, nil)
+	if err != nil {
+		return errors.Wrap(err, "listing workspaces")
+	}
+	for _, ws := range resp.Data {
+		p.workspaces[workspaceKey(ws.Type, ws.Title)] = ws
+	}
+
+	for _, spec := range specs {
+		spec := spec
+		key := workspaceKey(spec.Type, spec.Title)
+
+		ws, ok := p.workspaces[key]
+		if !ok {
+			plan.add(PlanCreate, PlanKindWorkspace, spec.Title, spec.Type, func() error {
+				ws, err := NewWorkspace(spec.Type, spec.Title, spec.Description)
+				if err != nil {
+					return err
+				}
+
+				resp, err := ws.Create(p.Token)
+				if err != nil {
+					return err
+				}
+				p.workspaces[key] = resp.Data
+
+				return nil
+			})
+
+			for _, m := range spec.Members {
+				p.planInvite(plan, key, spec, m)
+			}
+
+			continue
+		}
+
+		if ws.Description != spec.Description {
+			plan.add(PlanUpdate, PlanKindWorkspace, spec.Title, "description", func() error {
+				ws.Description = spec.Description
+				_, err := ws.Update(p.Token)
+				return err
+			})
+		}
+
+		if err := p.planMembers(plan, key, ws, spec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planMembers plans invites, role changes and removals of existing workspace members
+func (p *Provisioner) planMembers(plan *Plan, key string, ws *Workspace, spec *SpecWorkspace) error {
+	resp, err := ListMembers(ws.UUID, p.Token)
+	if err != nil {
+		return errors.Wrapf(err, "listing members of %s", ws.Title)
+	}
+
+	matched := make(map[*Member]bool)
+	for _, m := range spec.Members {
+		existing := findMember(resp.Data, m.User)
+		if existing == nil {
+			p.planInvite(plan, key, spec, m)
+			continue
+		}
+		matched[existing] = true
+
+		userUUID := existing.User.UUID
+		target := spec.Title + "/" + m.User
+		roles := specRoles(spec.Type, m)
+		for _, role := range roles {
+			if existing.HasRole(role) {
+				continue
+			}
+
+			role := role
+			plan.add(PlanUpdate, PlanKindMember, target, "grant "+role, func() error {
+				_, err := GrantRole(ws.UUID, userUUID, role, p.Token)
+				return err
+			})
+		}
+		for _, role := range existing.RoleTypes() {
+			// current user never revokes own roles, so it doesn't lock itself out of workspace
+			if userUUID == p.User.UUID || stringInSlice(role, roles) || ValidateRole(role) != nil {
+				continue
+			}
+
+			role := role
+			plan.add(PlanUpdate, PlanKindMember, target, "revoke "+role, func() error {
+				_, err := RevokeRole(ws.UUID, userUUID, role, p.Token)
+				return err
+			})
+		}
+	}
+
+	if !spec.Prune {
+		return nil
+	}
+
+	for _, existing := range resp.Data {
+		if matched[existing] || existing.User == nil || existing.User.UUID == p.User.UUID {
+			continue
+		}
+
+		userUUID := existing.User.UUID
+		plan.add(PlanDelete, PlanKindMember, spec.Title+"/@"+existing.User.UserName, "", func() error {
+			return RemoveMember(ws.UUID, userUUID, p.Token)
+		})
+	}
+
+	return nil
+}
+
+// planInvite plans invite of a new member and grants of it's additional roles
+func (p *Provisioner) planInvite(plan *Plan, key string, spec *SpecWorkspace, m *SpecMember) {
+	roles := specRoles(spec.Type, m)
+
+	plan.add(PlanCreate, PlanKindMember, spec.Title+"/"+m.User, strings.Join(roles, ","), func() error {
+		ws := p.workspaces[key]
+		if ws == nil {
+			return errors.Errorf("workspace %s doesn't exist", spec.Title)
+		}
+
+		invitation, err := NewInvitation(m.User, roles[0])
+		if err != nil {
+			return err
+		}
+
+		resp, err := InviteMember(ws.UUID, invitation, p.Token)
+		if err != nil {
+			return err
+		}
+
+		for _, role := range roles[1:] {
+			if resp.Data.HasRole(role) {
+				continue
+			}
+			if _, err := GrantRole(ws.UUID, resp.Data.User.UUID, role, p.Token); err != nil {
+				return errors.Wrapf(err, "granting %s", role)
+			}
+		}
+
+		return nil
+	})
+}
+
+// planLibrary plans creation of missing folders and creation or updates of seed textpads
+func (p *Provisioner) planLibrary(plan *Plan, folders []*SpecFolder, textpads []*SpecTextpad) error {
+	p.folders = make(map[string]*LibraryItem)
+	if len(folders) == 0 && len(textpads) == 0 {
+		return nil
+	}
+
+	resp, err := ListLibrary(p.User.UUID, p.Token, &LibraryFilter{Type: LibraryFolder})
+	if err != nil {
+		return errors.Wrap(err, "listing folders")
+	}
+	for _, folder := range resp.Data {
+		p.folders[folderPath(folder)] = folder
+	}
+
+	// folders of textpads are created too, parents are always planned before their children
+	subtitles := make(map[string]string)
+	paths := make([]string, 0, len(folders)+len(textpads))
+	for _, folder := range folders {
+		subtitles[folder.Path] = folder.Subtitle
+		paths = append(paths, folder.Path)
+	}
+	for _, textpad := range textpads {
+		if textpad.Folder != "" {
+			paths = append(paths, textpad.Folder)
+		}
+	}
+
+	planned := make(map[string]bool)
+	for _, path := range paths {
+		titles := strings.Split(path, "/")
+		for i := range titles {
+			p.planFolder(plan, strings.Join(titles[:i+1], "/"), subtitles, planned)
+		}
+	}
+
+	resp, err = ListLibrary(p.User.UUID, p.Token, &LibraryFilter{Type: LibraryTextpad})
+	if err != nil {
+		return errors.Wrap(err, "listing textpads")
+	}
+	existing := make(map[string]*LibraryItem)
+	for _, textpad := range resp.Data {
+		existing[folderPath(textpad)] = textpad
+	}
+
+	for _, spec := range textpads {
+		spec := spec
+		target := strings.TrimPrefix(spec.Folder+"/"+spec.Title, "/")
+
+		textpad, ok := existing[target]
+		if !ok {
+			plan.add(PlanCreate, PlanKindTextpad, target, "", func() error {
+				textpad := NewTextpad(spec.Title, spec.Subtitle, p.User)
+				if spec.Folder != "" {
+					if err := textpad.SetParent(p.folders[spec.Folder]); err != nil {
+						return err
+					}
+				}
+				if spec.Text != "" {
+					textpad.Delta = &Delta{Ops: []*Op{{Insert: textpadText(spec.Text)}}}
+				}
+				textpad.SetTags(spec.Tags)
+
+				_, err := textpad.Create(p.Token)
+				return err
+			})
+
+			continue
+		}
+
+		// listed items don't contain delta, so textpad is fetched to compare it's text
+		if spec.Text != "" {
+			full, err := GetLibraryItem(textpad.UUID, p.Token)
+			if err != nil {
+				return errors.Wrapf(err, "getting textpad %s", target)
+			}
+			textpad = full.Data
+		}
+
+		changed := make([]string, 0, 3)
+		if textpad.Subtitle != spec.Subtitle {
+			changed = append(changed, "subtitle")
+		}
+		if spec.Text != "" && (textpad.Delta == nil || textpad.Delta.Text() != textpadText(spec.Text)) {
+			changed = append(changed, "text")
+		}
+		if spec.Tags != nil && !sameTags(textpad.Tags, spec.Tags) {
+			changed = append(changed, "tags")
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		plan.add(PlanUpdate, PlanKindTextpad, target, strings.Join(changed, ","), func() error {
+			textpad.Subtitle = spec.Subtitle
+			if spec.Text != "" {
+				textpad.Delta = &Delta{Ops: []*Op{{Insert: textpadText(spec.Text)}}}
+			}
+			if spec.Tags != nil {
+				textpad.SetTags(spec.Tags)
+			}
+
+			_, err := textpad.Update(p.Token)
+			return err
+		})
+	}
+
+	return nil
+}
+
+// planFolder plans creation of a missing folder or update of it's subtitle, each path is planned once
+func (p *Provisioner) planFolder(plan *Plan, path string, subtitles map[string]string, planned map[string]bool) {
+	if planned[path] {
+		return
+	}
+	planned[path] = true
+
+	subtitle, described := subtitles[path]
+
+	folder, ok := p.folders[path]
+	if ok {
+		if described && folder.Subtitle != subtitle {
+			plan.add(PlanUpdate, PlanKindFolder, path, "subtitle", func() error {
+				folder.Subtitle = subtitle
+				_, err := folder.Update(p.Token)
+				return err
+			})
+		}
+
+		return
+	}
+
+	titles := strings.Split(path, "/")
+	parent := strings.Join(titles[:len(titles)-1], "/")
+	plan.add(PlanCreate, PlanKindFolder, path, "", func() error {
+		folder := NewFolder(titles[len(titles)-1], subtitle, p.User)
+		if parent != "" {
+			if err := folder.SetParent(p.folders[parent]); err != nil {
+				return err
+			}
+		}
+
+		if _, err := folder.Create(p.Token); err != nil {
+			return err
+		}
+		p.folders[path] = folder
+
+		return nil
+	})
+}
+
+// findMember finds member by email or username, username can have leading @
+func findMember(members []*Member, user string) *Member {
+	user = strings.TrimSpace(user)
+	for _, m := range members {
+		if m.Email != "" && strings.EqualFold(m.Email, user) {
+			return m
+		}
+		if m.User != nil && strings.EqualFold(m.User.UserName, strings.TrimPrefix(user, "@")) {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// specRoles returns roles of spec member, member role of workspace type always goes first, so it's never revoked
+func specRoles(workspaceType string, m *SpecMember) []string {
+	memberRole := RoleWorkspaceMember
+	if workspaceType == WorkspaceTypeCommunity {
+		memberRole = RoleCommunityMember
+	}
+
+	roles := []string{memberRole}
+	for _, role := range m.Roles {
+		if !stringInSlice(role, roles) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+func workspaceKey(workspaceType, title string) string {
+	return fmt.Sprintf("%s/%s", workspaceType, title)
+}
+
+// textpadText returns text as it's stored in textpad, it always ends with a new line
+func textpadText(text string) string {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	return text
+}
+
+func sameTags(a, b []string) bool {
+	item := &LibraryItem{}
+	item.SetTags(b)
+
+	x := append([]string(nil), a...)
+	y := item.Tags
+	if len(x) != len(y) {
+		return false
+	}
+
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func stringInSlice(s string, slice []string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,207 @@
+package crud_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   int
+		currency string
+		expected string
+	}{
+		{0, "EUR", "0.00"},
+		{5, "EUR", "0.05"},
+		{1250, "usd", "12.50"},
+		{-1999, "EUR", "-19.99"},
+		{1250, "JPY", "1250"},
+		{-5, "KRW", "-5"},
+		{1250, "BHD", "1.250"},
+		{5, "KWD", "0.005"},
+		{1250, "XXX", "12.50"},
+	}
+
+	for _, test := range tests {
+		if got := crud.FormatAmount(test.amount, test.currency); got != test.expected {
+			t.Errorf("%d %s: expected %s, got %s", test.amount, test.currency, test.expected, got)
+		}
+	}
+}
+
+func TestAPIBilling(t *testing.T) {
+	memberships := make([]*crud.PaidMembership, 0)
+	for i := 1; i <= 5; i++ {
+		status := crud.MembershipActive
+		if i == 5 {
+			status = crud.MembershipExpired
+		}
+		memberships = append(memberships, &crud.PaidMembership{
+			UUID:      "membership-" + strconv.Itoa(i),
+			Workspace: "class-uuid",
+			User:      &crud.PublicUser{UUID: "student" + strconv.Itoa(i) + "-uuid", UserName: "student" + strconv.Itoa(i)},
+			Plan:      "Monthly",
+			Status:    status,
+			Amount:    999,
+			Currency:  "EUR",
+			StartedAt: 1593561600000 + i*int(24*time.Hour/time.Millisecond), // 2020-07-01 plus i days
+		})
+	}
+
+	purchases := []*crud.Purchase{
+		{UUID: "purchase-1", Node: "course-uuid", NodeTitle: "Biology course", Buyer: &crud.PublicUser{UUID: "student1-uuid", UserName: "student1"}, Amount: 4900, Currency: "EUR", PurchasedAt: 1593561600000},
+		{UUID: "purchase-2", Node: "course-uuid", NodeTitle: "Biology course", Buyer: &crud.PublicUser{UUID: "student2-uuid", UserName: "student2"}, Amount: 4900, Currency: "EUR", PurchasedAt: 1593648000000, RefundedAt: 1593734400000},
+		{UUID: "purchase-3", Node: "notes-uuid", Buyer: &crud.PublicUser{UUID: "student3-uuid", UserName: "student3"}, Amount: 500, Currency: "USD", PurchasedAt: 1593648000000},
+	}
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Auth-Token") != "admin-token" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		size, _ := strconv.Atoi(q.Get("size"))
+		if size == 0 {
+			size = 2
+		}
+		since, _ := strconv.Atoi(q.Get("since"))
+
+		switch r.URL.Path {
+		case "/v1/workspaces/class-uuid/billing/memberships":
+			matched := make([]*crud.PaidMembership, 0)
+			for _, m := range memberships {
+				if m.StartedAt >= since && (q.Get("status") == "" || q.Get("status") == m.Status) {
+					matched = append(matched, m)
+				}
+			}
+
+			resp := &crud.ResponsePaidMemberships{Data: make([]*crud.PaidMembership, 0), Page: page, Size: size, Total: len(matched)}
+			for i := (page - 1) * size; i < page*size && i < len(matched); i++ {
+				resp.Data = append(resp.Data, matched[i])
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/v1/workspaces/class-uuid/billing/purchases":
+			resp := &crud.ResponsePurchases{Data: make([]*crud.Purchase, 0), Page: page, Size: size, Total: len(purchases)}
+			for i := (page - 1) * size; i < page*size && i < len(purchases); i++ {
+				resp.Data = append(resp.Data, purchases[i])
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/v1/workspaces/legacy-uuid/billing/purchases":
+			// total isn't reported
+			resp := &crud.ResponsePurchases{Data: make([]*crud.Purchase, 0), Page: page, Size: size}
+			for i := (page - 1) * size; i < page*size && i < len(purchases); i++ {
+				resp.Data = append(resp.Data, purchases[i])
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// all pages are fetched by default
+	resp, err := crud.ListPaidMemberships("class-uuid", "admin-token", nil)
+	if err != nil {
+		t.Fatalf("failed to list memberships: %v", err)
+	}
+	if len(resp.Data) != 5 || requests != 3 {
+		t.Errorf("expected 5 memberships in 3 requests, got %d in %d", len(resp.Data), requests)
+	}
+
+	since := time.Date(2020, 7, 3, 0, 0, 0, 0, time.UTC)
+	resp, err = crud.ListPaidMemberships("class-uuid", "admin-token", &crud.BillingFilter{Since: since, Status: "active"})
+	if err != nil {
+		t.Fatalf("failed to list filtered memberships: %v", err)
+	}
+	if len(resp.Data) != 3 || resp.Data[0].UUID != "membership-2" {
+		t.Errorf("expected 3 active memberships since 2020-07-03, got %d", len(resp.Data))
+	}
+
+	resp, err = crud.ListPaidMemberships("class-uuid", "admin-token", &crud.BillingFilter{Page: 3})
+	if err != nil {
+		t.Fatalf("failed to list page: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].UUID != "membership-5" || resp.Total != 5 {
+		t.Errorf("expected only membership-5 on page 3 of 5 memberships, got %d", len(resp.Data))
+	}
+
+	if _, err := crud.ListPaidMemberships("class-uuid", "admin-token", &crud.BillingFilter{Status: "paused"}); !errors.Is(err, crud.ErrMembershipStatus) {
+		t.Errorf("expected ErrMembershipStatus, got %v", err)
+	}
+	if _, err := crud.ListPurchases("class-uuid", "admin-token", &crud.BillingFilter{Since: since, Until: since.Add(-time.Hour)}); !errors.Is(err, crud.ErrBillingRange) {
+		t.Errorf("expected ErrBillingRange, got %v", err)
+	}
+	if _, err := crud.ListPurchases("class-uuid", "student-token", nil); !errors.Is(err, crud.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for student, got %v", err)
+	}
+	if _, err := crud.ListPurchases("missing-uuid", "admin-token", nil); !errors.Is(err, crud.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	list, err := crud.ListPurchases("class-uuid", "admin-token", nil)
+	if err != nil {
+		t.Fatalf("failed to list purchases: %v", err)
+	}
+	if len(list.Data) != 3 {
+		t.Fatalf("expected 3 purchases, got %d", len(list.Data))
+	}
+
+	// without total pages are fetched until a short page
+	requests = 0
+	list, err = crud.ListPurchases("legacy-uuid", "admin-token", nil)
+	if err != nil {
+		t.Fatalf("failed to list purchases without total: %v", err)
+	}
+	if len(list.Data) != 3 || requests != 2 {
+		t.Errorf("expected 3 purchases without total in 2 requests, got %d in %d", len(list.Data), requests)
+	}
+
+	totals := crud.TotalAmounts(nil, list.Data)
+	if totals["EUR"] != 4900 || totals["USD"] != 500 {
+		t.Errorf("expected refunded purchase not to be counted, got %v", totals)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := crud.WritePurchasesCSV(buf, list.Data); err != nil {
+		t.Fatalf("failed to write purchases: %v", err)
+	}
+	rows, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read csv: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected header and 3 rows, got %d rows", len(rows))
+	}
+	if got := strings.Join(rows[0], ","); got != "uuid,workspace,node,node_type,node_title,buyer_uuid,buyer,owner_uuid,owner,amount,currency,purchased_at,refunded_at" {
+		t.Errorf("unexpected header: %s", got)
+	}
+	if got := strings.Join(rows[2][9:], ","); got != "49.00,EUR,2020-07-02T00:00:00Z,2020-07-03T00:00:00Z" {
+		t.Errorf("unexpected second row: %s", got)
+	}
+
+	buf.Reset()
+	if err := crud.WriteMembershipsCSV(buf, nil); err != nil {
+		t.Fatalf("failed to write memberships: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "uuid,workspace,user_uuid,username,name,plan,status,amount,currency,started_at,expires_at,canceled_at" {
+		t.Errorf("expected only header for no memberships, got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPIChatUnreadAndReadMarks(t *testing.T) {
+	// unread counts of chats, support chat is read up to a message
+	unread := map[string]int{"support-uuid": 3, "team-uuid": 0, "private-uuid": 1}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/chats/unread":
+			resp := &crud.ResponseChatUnreads{}
+			for _, uuid := range []string{"support-uuid", "team-uuid", "private-uuid"} {
+				resp.Data = append(resp.Data, &crud.ChatUnread{Chat: &crud.Chat{UUID: uuid}, UnreadCount: unread[uuid]})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "POST /v1/workspaces/support-uuid/chat/message-uuid/read":
+			unread["support-uuid"] = 0
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	resp, err := crud.ListUnreadCounts("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chats := resp.Unread(); len(chats) != 2 || chats[0].Chat.UUID != "support-uuid" || chats[0].UnreadCount != 3 {
+		t.Fatalf("expected 2 chats with unread messages starting with support-uuid, got %d", len(chats))
+	}
+
+	if err := crud.MarkChatRead("support-uuid", "message-uuid", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = crud.ListUnreadCounts("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chats := resp.Unread(); len(chats) != 1 || chats[0].Chat.UUID != "private-uuid" {
+		t.Fatalf("expected only private-uuid to have unread messages, got %d chats", len(chats))
+	}
+
+	// read marks of a message
+	msg := &crud.UserMessageEntity{ReadMarks: map[string]int{"user-uuid": 1593590400000}}
+	if msg.ReadAt("user-uuid") != 1593590400000 || msg.ReadAt("other-uuid") != 0 {
+		t.Fatalf("expected message to be read only by user-uuid, got %v", msg.ReadMarks)
+	}
+}
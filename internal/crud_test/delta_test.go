@@ -0,0 +1,89 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestDeltaCompose(t *testing.T) {
+	doc := crud.NewDelta().Insert("Hello World\n", nil)
+
+	// replace World with Acroplia and make it bold
+	change := crud.NewDelta().
+		Retain(6, nil).
+		Delete(5).
+		Insert("Acroplia", map[string]interface{}{"bold": true})
+
+	result := doc.Compose(change)
+	if result.Text() != "Hello Acroplia\n" {
+		t.Fatalf("expected text %q, got %q", "Hello Acroplia\n", result.Text())
+	}
+	if len(result.Ops) != 3 {
+		b, _ := json.Marshal(result)
+		t.Fatalf("expected 3 ops, got %s", b)
+	}
+
+	// original deltas aren't changed
+	if doc.Text() != "Hello World\n" {
+		t.Fatalf("expected original document to stay unchanged, got %q", doc.Text())
+	}
+}
+
+func TestDeltaTransformConverges(t *testing.T) {
+	doc := crud.NewDelta().Insert("Photosynthesis\n", nil)
+
+	cases := []struct {
+		name string
+		a, b *crud.Delta
+	}{
+		{
+			name: "inserts at the same position",
+			a:    crud.NewDelta().Retain(5, nil).Insert("AAA", nil),
+			b:    crud.NewDelta().Retain(5, nil).Insert("BBB", nil),
+		},
+		{
+			name: "insert inside deleted text",
+			a:    crud.NewDelta().Retain(2, nil).Delete(6),
+			b:    crud.NewDelta().Retain(4, nil).Insert("X", nil),
+		},
+		{
+			name: "overlapping deletes",
+			a:    crud.NewDelta().Retain(1, nil).Delete(5),
+			b:    crud.NewDelta().Retain(3, nil).Delete(6),
+		},
+		{
+			name: "append and format",
+			a:    crud.NewDelta().Retain(14, nil).Insert(" is a process", nil),
+			b:    crud.NewDelta().Retain(5, map[string]interface{}{"italic": true}),
+		},
+	}
+
+	for _, c := range cases {
+		// a is applied first on one side, b on the other side
+		left := doc.Compose(c.a).Compose(c.a.Transform(c.b, true))
+		right := doc.Compose(c.b).Compose(c.b.Transform(c.a, false))
+
+		l, _ := json.Marshal(left)
+		r, _ := json.Marshal(right)
+		if string(l) != string(r) {
+			t.Errorf("%s: documents diverged:\n%s\n%s", c.name, l, r)
+		}
+	}
+}
+
+func TestDeltaTransformPriority(t *testing.T) {
+	a := crud.NewDelta().Insert("A", nil)
+	b := crud.NewDelta().Insert("B", nil)
+
+	// a goes first, so b is shifted after it
+	if exp, got := 1, a.Transform(b, true).Ops[0].Retain; got != exp {
+		t.Fatalf("expected b to retain %d, got %d", exp, got)
+	}
+
+	// b goes first, so it stays at the beginning
+	if got := a.Transform(b, false).Ops[0].Insert; got != "B" {
+		t.Fatalf("expected b to insert at the beginning, got %+v", a.Transform(b, false).Ops[0])
+	}
+}
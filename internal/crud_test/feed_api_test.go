@@ -0,0 +1,216 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := map[string][]string{
+		"hello @anna and @boris.":        {"anna", "boris"},
+		"@anna, @anna.k please check":    {"anna", "anna.k"},
+		"mail me at anna@example.com":    {},
+		"(@boris) @@anna @tutor_1-class": {"boris", "tutor_1-class"},
+	}
+
+	for text, expected := range tests {
+		if got := crud.ParseMentions(text); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%q: expected %v, got %v", text, expected, got)
+		}
+	}
+}
+
+func TestNewMentionDelta(t *testing.T) {
+	anna := &crud.PublicUser{UUID: "anna-uuid", UserName: "Anna"}
+	delta := crud.NewMentionDelta("hi @anna, ask @boris", []*crud.PublicUser{anna})
+
+	if delta.Text() != "hi @anna, ask @boris\n" {
+		t.Errorf("unexpected text: %q", delta.Text())
+	}
+	if len(delta.Ops) != 3 {
+		t.Fatalf("expected 3 ops, got %d", len(delta.Ops))
+	}
+
+	attrs, ok := delta.Ops[1].Attributes.(map[string]interface{})
+	if !ok || delta.Ops[1].Insert != "@anna" {
+		t.Fatalf("expected second op to be a mention of @anna, got %+v", delta.Ops[1])
+	}
+	if mention := attrs["mention"].(map[string]interface{}); mention["uuid"] != "anna-uuid" {
+		t.Errorf("expected mention of anna-uuid, got %v", mention["uuid"])
+	}
+}
+
+func TestAPIFeed(t *testing.T) {
+	author := &crud.PrivateUser{UUID: "author-uuid", UserName: "tutor"}
+	users := []*crud.PublicUser{
+		{UUID: "anna-uuid", UserName: "anna"},
+		{UUID: "annabel-uuid", UserName: "annabel"},
+	}
+
+	feed := make([]*crud.LibraryItem, 0)
+	comments := make(map[string][]*crud.Comment)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[1:]
+
+		switch {
+		case r.URL.Path == "/v1/users/search":
+			resp := &crud.ResponsePublicUsers{Data: make([]*crud.PublicUser, 0)}
+			for _, u := range users {
+				if strings.HasPrefix(u.UserName, r.URL.Query().Get("query")) {
+					resp.Data = append(resp.Data, u)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case r.URL.Path == "/v1/workspaces/class-uuid/feed" && r.Method == "POST":
+			post := &crud.LibraryItem{}
+			json.NewDecoder(r.Body).Decode(post)
+			feed = append([]*crud.LibraryItem{post}, feed...)
+			json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: post})
+		case r.URL.Path == "/v1/workspaces/class-uuid/feed" && r.Method == "GET":
+			page := feed
+			if before := r.URL.Query().Get("before"); before != "" {
+				for i, post := range feed {
+					if post.UUID == before {
+						page = feed[i+1:]
+					}
+				}
+			}
+			if limit := r.URL.Query().Get("limit"); limit == "1" && len(page) > 1 {
+				page = page[:1]
+			}
+			json.NewEncoder(w).Encode(&crud.ResponseLibraryItems{Data: page})
+		case len(parts) == 4 && parts[0] == "library" && parts[3] == "comments":
+			if r.Method == "POST" {
+				c := &crud.Comment{}
+				json.NewDecoder(r.Body).Decode(c)
+				comments[parts[2]] = append(comments[parts[2]], c)
+				json.NewEncoder(w).Encode(&crud.ResponseComment{Data: c})
+
+				return
+			}
+			json.NewEncoder(w).Encode(&crud.ResponseComments{Data: comments[parts[2]]})
+		case len(parts) == 5 && parts[0] == "library" && parts[3] == "reactions":
+			for _, post := range feed {
+				if post.UUID != parts[2] {
+					continue
+				}
+				count := post.Post.ReactionCount(parts[4]) + 1
+				if r.Method == "DELETE" {
+					count -= 2
+				}
+				post.Post.Reactions = []*crud.Reaction{{Type: parts[4], Count: count, Mine: r.Method == "PUT"}}
+				json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: post})
+
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// unknown username is skipped, not failing the post
+	text := "Notes are ready, @anna please check, @boris too"
+	mentions, unresolved, err := crud.ResolveMentions(text, "token")
+	if err != nil {
+		t.Fatalf("failed to resolve mentions: %v", err)
+	}
+	if len(mentions) != 1 || mentions[0].UUID != "anna-uuid" {
+		t.Fatalf("expected @anna to be resolved to anna-uuid, got %+v", mentions)
+	}
+	if len(unresolved) != 1 || unresolved[0] != "boris" {
+		t.Fatalf("expected @boris to be unresolved, got %v", unresolved)
+	}
+
+	if _, err := crud.NewPost(" ", nil, nil, author); err != crud.ErrPostEmpty {
+		t.Errorf("expected ErrPostEmpty, got %v", err)
+	}
+
+	post, err := crud.NewPost(text, mentions, nil, author)
+	if err != nil {
+		t.Fatalf("failed to build post: %v", err)
+	}
+	if !reflect.DeepEqual(post.Post.Mentions, []string{"anna-uuid"}) || len(post.Post.Delta.Ops) != 3 {
+		t.Errorf("expected post to mention anna-uuid, got %v", post.Post.Mentions)
+	}
+
+	// mention attributes are maps, which vendored json-iterator can't encode on newer go versions, so published posts don't mention anyone
+	for _, text := range []string{"first post", "second post"} {
+		post, err := crud.NewPost(text, nil, nil, author)
+		if err != nil {
+			t.Fatalf("failed to build post: %v", err)
+		}
+		if _, err := crud.PublishPost("class-uuid", post, "token"); err != nil {
+			t.Fatalf("failed to publish post: %v", err)
+		}
+	}
+
+	page, err := crud.ListFeed("class-uuid", &crud.FeedQuery{Limit: 1}, "token")
+	if err != nil {
+		t.Fatalf("failed to list feed: %v", err)
+	}
+	if len(page.Data) != 1 || page.Data[0].Post.Delta.Text() != "second post\n" {
+		t.Fatalf("expected newest post first, got %+v", page.Data)
+	}
+
+	page, err = crud.ListFeed("class-uuid", &crud.FeedQuery{Before: page.Data[0].UUID, Limit: 1}, "token")
+	if err != nil {
+		t.Fatalf("failed to list next page: %v", err)
+	}
+	if len(page.Data) != 1 || page.Data[0].Post.Delta.Text() != "first post\n" || len(page.Data[0].Post.Mentions) != 0 {
+		t.Fatalf("expected first post on next page, got %+v", page.Data)
+	}
+	postUUID := page.Data[0].UUID
+
+	comment, err := crud.NewComment("thanks @anna", mentions, author)
+	if err != nil {
+		t.Fatalf("failed to build comment: %v", err)
+	}
+	if !reflect.DeepEqual(comment.Mentions, []string{"anna-uuid"}) {
+		t.Errorf("expected comment to mention anna-uuid, got %v", comment.Mentions)
+	}
+
+	if comment, err = crud.NewComment("thanks !", nil, author); err != nil {
+		t.Fatalf("failed to build comment: %v", err)
+	}
+	if _, err := crud.AddComment(postUUID, comment, "token"); err != nil {
+		t.Fatalf("failed to comment: %v", err)
+	}
+	list, err := crud.ListComments(postUUID, "token")
+	if err != nil {
+		t.Fatalf("failed to list comments: %v", err)
+	}
+	if len(list.Data) != 1 || list.Data[0].Delta.Text() != "thanks !\n" {
+		t.Errorf("unexpected comments: %+v", list.Data)
+	}
+
+	if _, err := crud.React(postUUID, "shrug", false, "token"); !errors.Is(err, crud.ErrReactionType) {
+		t.Errorf("expected ErrReactionType, got %v", err)
+	}
+	resp, err := crud.React(postUUID, "like", false, "token")
+	if err != nil {
+		t.Fatalf("failed to react: %v", err)
+	}
+	if resp.Data.Post.ReactionCount(crud.ReactionLike) != 1 {
+		t.Errorf("expected 1 like, got %d", resp.Data.Post.ReactionCount(crud.ReactionLike))
+	}
+	if resp, err = crud.React(postUUID, crud.ReactionLike, true, "token"); err != nil || resp.Data.Post.ReactionCount(crud.ReactionLike) != 0 {
+		t.Errorf("expected like to be removed, got %v", err)
+	}
+
+	if _, err := crud.React("missing-uuid", crud.ReactionLike, false, "token"); !errors.Is(err, crud.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
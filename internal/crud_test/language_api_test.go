@@ -0,0 +1,96 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPITextpadLanguageAndTags(t *testing.T) {
+	languages := []*crud.Language{
+		{ID: 1, UUID: "en-uuid", ISOCode: "en", Title: "English", SearchConfig: "english"},
+		{ID: 2, UUID: "ru-uuid", ISOCode: "ru", Title: "Russian", SearchConfig: "russian"},
+	}
+
+	var updated *crud.LibraryItem
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/languages":
+			json.NewEncoder(w).Encode(&crud.ResponseLanguages{Data: languages})
+		case "PUT /v1/library/items/textpad-uuid":
+			updated = &crud.LibraryItem{}
+			json.NewDecoder(r.Body).Decode(updated)
+			json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: updated})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	langResp, err := crud.ListLanguages("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// iso code is validated against server's language list
+	lang, err := langResp.FindLanguage("RU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lang.UUID != "ru-uuid" {
+		t.Fatalf("expected language ru-uuid, got %s", lang.UUID)
+	}
+
+	_, err = langResp.FindLanguage("xx")
+	if !errors.Is(err, crud.ErrLanguageNotFound) {
+		t.Fatalf("expected %v, got %v", crud.ErrLanguageNotFound, err)
+	}
+
+	// manage tags and update textpad
+	textpad := crud.NewTextpad("Title", "", &crud.PrivateUser{UUID: "user-uuid"})
+	textpad.UUID = "textpad-uuid"
+	textpad.Lang = lang
+	textpad.SetTags([]string{" biology ", "lesson", "", "biology"})
+	textpad.AddTags("homework", "lesson")
+	textpad.RemoveTags("lesson")
+
+	_, err = textpad.Update("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expTags := []string{"biology", "homework"}
+	if !reflect.DeepEqual(updated.Tags, expTags) {
+		t.Fatalf("expected tags %v, got %v", expTags, updated.Tags)
+	}
+	if updated.Lang == nil || updated.Lang.ISOCode != "ru" {
+		t.Fatalf("expected language ru, got %+v", updated.Lang)
+	}
+
+	// removing last tags sends empty tags, so server clears them
+	textpad.RemoveTags("biology", "homework")
+	_, err = textpad.Update("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Tags == nil || len(updated.Tags) != 0 {
+		t.Fatalf("expected empty tags, got %v", updated.Tags)
+	}
+
+	// item, which tags weren't set, doesn't send tags, so server keeps them
+	textpad.Tags = nil
+	_, err = textpad.Update("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Tags != nil {
+		t.Fatalf("expected tags not to be sent, got %v", updated.Tags)
+	}
+}
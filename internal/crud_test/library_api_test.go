@@ -0,0 +1,98 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPILibraryCreateNested(t *testing.T) {
+	user := &crud.PrivateUser{UUID: "user-uuid", FirstName: "Bezhan", LastName: "Mukhidinov"}
+	folder := crud.NewFolder("Course", "", user)
+
+	// fake server stores created items and serves them back
+	items := map[string]*crud.LibraryItem{folder.UUID: folder}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/v1/library/user-uuid":
+			item := &crud.LibraryItem{}
+			if err := json.NewDecoder(r.Body).Decode(item); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			items[item.UUID] = item
+			json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: item})
+		case r.Method == "GET" && r.URL.Path == "/v1/library/items/"+folder.UUID:
+			json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: folder})
+		case r.Method == "GET" && r.URL.Path == "/v1/library/user-uuid":
+			resp := &crud.ResponseLibraryItems{}
+			for _, item := range items {
+				if r.URL.Query().Get("type") == item.Type {
+					resp.Data = append(resp.Data, item)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// get parent folder and nest a link under it
+	parentResp, err := crud.GetLibraryItem(folder.UUID, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := crud.NewLink("Go", "", "https://golang.org", user)
+	if err := link.SetParent(parentResp.Data); err != nil {
+		t.Fatal(err)
+	}
+
+	linkResp, err := link.Create("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// compare results from response to expected results
+	if linkResp.Data.Link == nil || linkResp.Data.Link.URL != "https://golang.org" {
+		t.Fatalf("expected link url https://golang.org, got %+v", linkResp.Data.Link)
+	}
+	if len(linkResp.Data.Path) != 1 || linkResp.Data.Path[0].UUID != folder.UUID {
+		t.Fatalf("expected link to be nested under %s, got path %+v", folder.UUID, linkResp.Data.Path)
+	}
+
+	// link can't be a parent of another item
+	tasks := crud.NewTaskList("Homework", "", []string{"Read chapter 1"}, user)
+	if err := tasks.SetParent(linkResp.Data); err != crud.ErrLibraryParent {
+		t.Fatalf("expected %v, got %v", crud.ErrLibraryParent, err)
+	}
+
+	// list only links
+	listResp, err := crud.ListLibrary("user-uuid", "token", &crud.LibraryFilter{Type: crud.LibraryLink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listResp.Data) != 1 || listResp.Data[0].UUID != link.UUID {
+		t.Fatalf("expected only link %s to be listed, got %d items", link.UUID, len(listResp.Data))
+	}
+}
+
+func TestAPILibraryUnsupportedType(t *testing.T) {
+	user := &crud.PrivateUser{UUID: "user-uuid"}
+
+	_, err := crud.NewLibraryItem(crud.LibraryCanvas, "Canvas", "", user)
+	if err == nil {
+		t.Fatalf("expected error for %s library item", crud.LibraryCanvas)
+	}
+}
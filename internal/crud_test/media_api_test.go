@@ -0,0 +1,97 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPIUploadMedia(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lesson.pdf")
+	if err := ioutil.WriteFile(path, []byte("%PDF-1.4 photosynthesis"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// fake server stores uploaded file as a media item
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method+" "+r.URL.Path != "POST /v1/media" || r.Header.Get("X-Auth-Token") != "token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := ioutil.ReadAll(file)
+
+		item := &crud.MediaItem{
+			UUID: "media-uuid",
+			Type: r.FormValue("type"),
+			Data: &crud.JSONMediaDataJSONMediaDataSource{
+				Type: r.FormValue("type"),
+				Source: &crud.JSONMediaDataSource{
+					URL:              "https://example.com/media-uuid",
+					FileSize:         len(data),
+					OriginalFileName: header.Filename,
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(&crud.ResponseMediaItem{Data: item})
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	resp, err := crud.UploadMedia(path, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Type != crud.MediaDocument {
+		t.Fatalf("expected type %s, got %s", crud.MediaDocument, resp.Data.Type)
+	}
+	if resp.Data.FileName() != "lesson.pdf" || resp.Data.FileSize() != 23 {
+		t.Fatalf("expected lesson.pdf of 23 bytes, got %s of %d bytes", resp.Data.FileName(), resp.Data.FileSize())
+	}
+
+	// uploaded media is attached to a media message
+	msg := crud.NewMediaMessage("Homework", []*crud.MediaItem{resp.Data}, &crud.PrivateUser{UUID: "user-uuid"})
+	if msg.Type != "USER_MEDIA" || len(msg.Attachments) != 1 || msg.Attachments[0].UUID != "media-uuid" {
+		t.Fatalf("expected media message with media-uuid attachment, got %+v", msg)
+	}
+
+	// missing file isn't uploaded
+	if _, err := crud.UploadMedia(filepath.Join(dir, "missing.pdf"), "token"); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	cases := map[string]string{
+		"photo.JPG":  crud.MediaImage,
+		"song.mp3":   crud.MediaAudio,
+		"clip.mp4":   crud.MediaVideo,
+		"lesson.pdf": crud.MediaDocument,
+		"notes":      crud.MediaDocument,
+	}
+
+	for name, exp := range cases {
+		if got := crud.MediaType(name); got != exp {
+			t.Errorf("%s: expected %s, got %s", name, exp, got)
+		}
+	}
+}
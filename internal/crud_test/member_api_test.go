@@ -0,0 +1,149 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+func TestAPIMemberRoles(t *testing.T) {
+	members := map[string]*crud.Member{
+		"admin-uuid": {User: &crud.PublicUser{UUID: "admin-uuid", UserName: "admin"}, Roles: []*crud.Role{{Type: crud.RoleWorkspaceAdmin}}},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/v1/workspaces/workspace-uuid/members"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/"), "/")
+
+		switch {
+		case r.Method == "GET" && parts[0] == "":
+			resp := &crud.ResponseMembers{}
+			for _, m := range members {
+				resp.Data = append(resp.Data, m)
+			}
+			json.NewEncoder(w).Encode(resp)
+		case r.Method == "POST" && parts[0] == "invite":
+			invitation := &crud.Invitation{}
+			json.NewDecoder(r.Body).Decode(invitation)
+			name := invitation.UserName
+			if name == "" {
+				name = strings.Split(invitation.Email, "@")[0]
+			}
+			role := invitation.Role
+			if role == "" {
+				role = crud.RoleWorkspaceMember
+			}
+			m := &crud.Member{User: &crud.PublicUser{UUID: name + "-uuid", UserName: name}, Roles: []*crud.Role{{Type: role}}}
+			members[m.User.UUID] = m
+			json.NewEncoder(w).Encode(&crud.ResponseMember{Data: m})
+		case members[parts[0]] == nil:
+			w.WriteHeader(http.StatusNotFound)
+		case parts[0] == "admin-uuid":
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == "DELETE" && len(parts) == 1:
+			delete(members, parts[0])
+		case r.Method == "POST" && len(parts) == 2:
+			role := &crud.Role{}
+			json.NewDecoder(r.Body).Decode(role)
+			members[parts[0]].Roles = append(members[parts[0]].Roles, role)
+			json.NewEncoder(w).Encode(&crud.ResponseMember{Data: members[parts[0]]})
+		case r.Method == "DELETE" && len(parts) == 3:
+			m := members[parts[0]]
+			roles := m.Roles[:0]
+			for _, role := range m.Roles {
+				if role.Type != parts[2] {
+					roles = append(roles, role)
+				}
+			}
+			m.Roles = roles
+			json.NewEncoder(w).Encode(&crud.ResponseMember{Data: m})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// invitations by email and username
+	if _, err := crud.NewInvitation("@", ""); err != crud.ErrInvitationEmpty {
+		t.Fatalf("expected ErrInvitationEmpty, got %v", err)
+	}
+	if _, err := crud.NewInvitation("student", "OWNER"); err != crud.ErrRoleType {
+		t.Fatalf("expected ErrRoleType, got %v", err)
+	}
+
+	byEmail, err := crud.NewInvitation("student@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byEmail.Email != "student@example.com" || byEmail.UserName != "" {
+		t.Fatalf("expected invitation by email, got %+v", byEmail)
+	}
+	byUsername, err := crud.NewInvitation("@tutor", crud.RoleWorkspaceAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byUsername.UserName != "tutor" || byUsername.Email != "" {
+		t.Fatalf("expected invitation by username, got %+v", byUsername)
+	}
+
+	for _, invitation := range []*crud.Invitation{byEmail, byUsername} {
+		if _, err := crud.InviteMember("workspace-uuid", invitation, "token"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := crud.ListMembers("workspace-uuid", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 3 {
+		t.Fatalf("expected 3 members, got %d", len(list.Data))
+	}
+	if !members["tutor-uuid"].HasRole(crud.RoleWorkspaceAdmin) || !members["student-uuid"].HasRole(crud.RoleWorkspaceMember) {
+		t.Fatal("expected invited users to have roles from invitations")
+	}
+
+	// grant and revoke roles
+	if _, err := crud.GrantRole("workspace-uuid", "student-uuid", "OWNER", "token"); err != crud.ErrRoleType {
+		t.Fatalf("expected ErrRoleType, got %v", err)
+	}
+	granted, err := crud.GrantRole("workspace-uuid", "student-uuid", crud.RoleModerator, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !granted.Data.HasRole(crud.RoleModerator) || len(granted.Data.RoleTypes()) != 2 {
+		t.Fatalf("expected student to be moderator, got %v", granted.Data.RoleTypes())
+	}
+	revoked, err := crud.RevokeRole("workspace-uuid", "student-uuid", crud.RoleModerator, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked.Data.HasRole(crud.RoleModerator) {
+		t.Fatalf("expected moderator role to be revoked, got %v", revoked.Data.RoleTypes())
+	}
+
+	// remove members
+	if err := crud.RemoveMember("workspace-uuid", "admin-uuid", "token"); !errors.Is(err, crud.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if err := crud.RemoveMember("workspace-uuid", "missing-uuid", "token"); !errors.Is(err, crud.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := crud.RemoveMember("workspace-uuid", "student-uuid", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := members["student-uuid"]; ok {
+		t.Fatal("expected student to be removed")
+	}
+}
@@ -1,8 +1,15 @@
 package crud_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bejaneps/acroplia/internal/crud"
 	"github.com/google/uuid"
@@ -27,3 +34,152 @@ func TestAPISendMessage(t *testing.T) {
 
 	_ = respMsg
 }
+
+func TestAPIMessageIterator(t *testing.T) {
+	// chat history of 7 messages, one message per minute, newest first
+	now := time.Now()
+	history := make([]*crud.UserMessageEntity, 0)
+	for i := 7; i >= 1; i-- {
+		history = append(history, &crud.UserMessageEntity{
+			UUID:      fmt.Sprintf("message-%d", i),
+			Type:      "USER_TEXT",
+			Text:      fmt.Sprintf("Message %d", i),
+			CreatedAt: int(now.Add(-time.Duration(8-i)*time.Minute).UnixNano() / int64(time.Millisecond)),
+		})
+	}
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1/workspaces/chat-uuid/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+
+		// find start of a page by before cursor
+		start := 0
+		if before := r.URL.Query().Get("before"); before != "" {
+			for i, msg := range history {
+				if msg.UUID == before {
+					start = i + 1
+				}
+			}
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := start + limit
+		if end > len(history) {
+			end = len(history)
+		}
+
+		json.NewEncoder(w).Encode(&crud.ResponseUserMessageEntities{Data: history[start:end]})
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// iterate through whole history
+	it := crud.NewMessageIterator("chat-uuid", "token")
+	it.PageSize = 3
+
+	var texts []string
+	for it.Next() {
+		texts = append(texts, it.Message().Text)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 7 || texts[0] != "Message 7" || texts[6] != "Message 1" {
+		t.Fatalf("expected messages from 7 to 1, got %v", texts)
+	}
+	if requests != 3 {
+		t.Fatalf("expected 3 page requests, got %d", requests)
+	}
+
+	// iterate only through last 3.5 minutes, skipping the newest message
+	it = crud.NewMessageIterator("chat-uuid", "token")
+	it.PageSize = 2
+	it.Since = now.Add(-4*time.Minute - 30*time.Second)
+	it.Until = now.Add(-90 * time.Second)
+
+	texts = nil
+	for it.Next() {
+		texts = append(texts, it.Message().Text)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(texts, ",") != "Message 6,Message 5,Message 4" {
+		t.Fatalf("expected messages from 6 to 4, got %v", texts)
+	}
+}
+
+func TestAPIMessageEditDeleteReply(t *testing.T) {
+	// messages of a chat by their uuid, only their authors can change them
+	messages := map[string]*crud.UserMessageEntity{
+		"own-uuid":   {UUID: "own-uuid", Type: "USER_TEXT", Text: "Helo", User: &crud.PublicUser{UUID: "user-uuid"}},
+		"other-uuid": {UUID: "other-uuid", Type: "USER_TEXT", Text: "Hi", User: &crud.PublicUser{UUID: "other-user-uuid"}},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg, ok := messages[strings.TrimPrefix(r.URL.Path, "/v1/workspaces/chat-uuid/chat/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if msg.User.UUID != "user-uuid" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case "PUT":
+			edited := &crud.UserMessageEntity{}
+			json.NewDecoder(r.Body).Decode(edited)
+			msg.Text = edited.Text
+			msg.UpdatedAt = 1
+		case "DELETE":
+			msg.Type, msg.Text = "DELETED", ""
+			return
+		}
+		json.NewEncoder(w).Encode(&crud.RespUserMessageEntity{Data: msg})
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// own message can be edited and deleted
+	resp, err := crud.EditMessage("chat-uuid", "own-uuid", "Hello", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Text != "Hello" {
+		t.Fatalf("expected text Hello, got %s", resp.Data.Text)
+	}
+
+	if err := crud.DeleteMessage("chat-uuid", "own-uuid", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if messages["own-uuid"].Type != "DELETED" {
+		t.Fatalf("expected message to be deleted, got type %s", messages["own-uuid"].Type)
+	}
+
+	// message of another user can't be changed
+	_, err = crud.EditMessage("chat-uuid", "other-uuid", "Bye", "token")
+	if !errors.Is(err, crud.ErrForbidden) {
+		t.Fatalf("expected %v, got %v", crud.ErrForbidden, err)
+	}
+	err = crud.DeleteMessage("chat-uuid", "other-uuid", "token")
+	if !errors.Is(err, crud.ErrForbidden) {
+		t.Fatalf("expected %v, got %v", crud.ErrForbidden, err)
+	}
+
+	err = crud.DeleteMessage("chat-uuid", "missing-uuid", "token")
+	if !errors.Is(err, crud.ErrNotFound) {
+		t.Fatalf("expected %v, got %v", crud.ErrNotFound, err)
+	}
+
+	// reply refers to original message
+	reply := crud.NewReply("Thanks", "other-uuid", &crud.PrivateUser{UUID: "user-uuid"})
+	if reply.ReplyToMessage != "other-uuid" || reply.Type != "USER_TEXT" {
+		t.Fatalf("expected text reply to other-uuid, got %+v", reply)
+	}
+}
@@ -0,0 +1,116 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPIMessageBroadcastDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-broadcast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	recipientsPath := filepath.Join(dir, "students.csv")
+	data := "username,fullname,chat_uuid,first_name,grade\n" +
+		"ekaterina,,,Ekaterina,A\n" +
+		",,chat-uuid,Bezhan,B\n" +
+		"nobody,,,Nobody,C\n"
+	if err := ioutil.WriteFile(recipientsPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templatePath := filepath.Join(dir, "grade.tmpl")
+	if err := ioutil.WriteFile(templatePath, []byte("Hello {{ .first_name }}, your grade is {{ .grade }}."), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// fake server only knows ekaterina, chats mustn't be opened in dry run
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/users/search":
+			resp := &crud.ResponsePublicUsers{Data: make([]*crud.PublicUser, 0)}
+			if r.URL.Query().Get("query") == "ekaterina" {
+				resp.Data = append(resp.Data, &crud.PublicUser{UUID: "ekaterina-uuid", UserName: "ekaterina"})
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	recipients, err := crud.ReadBroadcastRecipients(recipientsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 3 || recipients[1].Name() != "chat-uuid" {
+		t.Fatalf("expected 3 recipients with second identified by chat uuid, got %d", len(recipients))
+	}
+
+	tmpl, err := crud.ParseBroadcastTemplate(templatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broadcast := crud.NewBroadcast(&crud.PrivateUser{UUID: "user-uuid"}, "token", tmpl, 2)
+	broadcast.DryRun = true
+	results := broadcast.Send(recipients)
+
+	if results[0].Status != crud.BroadcastDryRun || results[0].Text != "Hello Ekaterina, your grade is A." {
+		t.Fatalf("expected rendered message for ekaterina, got %+v", results[0])
+	}
+	if results[1].Status != crud.BroadcastDryRun || results[1].ChatUUID != "chat-uuid" {
+		t.Fatalf("expected message to chat-uuid, got %+v", results[1])
+	}
+	if results[2].Status != crud.BroadcastFailed || results[2].Error == "" {
+		t.Fatalf("expected unknown recipient to fail, got %+v", results[2])
+	}
+
+	// template with missing column fails for each recipient
+	if err := ioutil.WriteFile(templatePath, []byte("Hello {{ .last_name }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err = crud.ParseBroadcastTemplate(templatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broadcast.Template = tmpl
+	for _, result := range broadcast.Send(recipients[1:2]) {
+		if result.Status != crud.BroadcastFailed {
+			t.Fatalf("expected missing column to fail rendering, got %+v", result)
+		}
+	}
+
+	// report has a row per recipient
+	reportPath := filepath.Join(dir, "report.csv")
+	rw, err := crud.NewBroadcastReportWriter(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if err := rw.Write(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rw.Close()
+
+	report, err := ioutil.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "row,recipient,chat_uuid,message_uuid,status,error\n"; string(report[:len(exp)]) != exp {
+		t.Fatalf("expected report header %q, got %q", exp, report)
+	}
+}
@@ -0,0 +1,133 @@
+package crud_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestMessageExport(t *testing.T) {
+	token := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Auth-Token")
+		if r.URL.Path != "/media/media-uuid" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer srv.Close()
+
+	author := &crud.PublicUser{UUID: "user-uuid", UserName: "ekaterina", FirstName: "Ekaterina", LastName: "Gorbunova"}
+	messages := []*crud.UserMessageEntity{
+		{UUID: "first-uuid", Type: "USER_TEXT", Text: "Hello <b>class</b>", User: author, CreatedAt: 1593590400000},
+		{UUID: "second-uuid", Type: "USER_MEDIA", Text: "Homework", User: author, CreatedAt: 1593590460000, ReplyToMessage: "first-uuid",
+			Attachments: []*crud.MediaItem{{
+				UUID: "media-uuid",
+				Type: crud.MediaDocument,
+				Data: &crud.JSONMediaDataJSONMediaDataSource{Source: &crud.JSONMediaDataSource{URL: srv.URL + "/media/media-uuid", FileSize: 8, OriginalFileName: "lesson.pdf"}},
+			}},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "acroplia-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exported := make([]*crud.ExportMessage, 0, len(messages))
+	for _, msg := range messages {
+		exported = append(exported, crud.NewExportMessage(msg))
+	}
+
+	// attachments are downloaded into sidecar folder
+	a := exported[1].Attachments[0]
+	if err := a.Download(dir, "token"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(a.Path); err != nil || string(data) != "%PDF-1.4" {
+		t.Fatalf("expected downloaded attachment, got %q, %v", data, err)
+	}
+
+	// token is sent only to Acroplia API, not to media hosts
+	if token != "" {
+		t.Fatalf("expected token not to be sent to media host, got %q", token)
+	}
+	crud.SetAPIURL(srv.URL)
+	err = a.Download(dir, "token")
+	crud.SetAPIURL(crud.DefaultAPIURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token" {
+		t.Fatalf("expected token to be sent to Acroplia API, got %q", token)
+	}
+
+	// uuid can't point outside of sidecar folder
+	hostile := &crud.ExportAttachment{UUID: "../media-uuid", URL: a.URL, Name: "lesson.pdf"}
+	if err := hostile.Download(dir, "token"); err == nil {
+		t.Fatalf("expected attachment with uuid %s to be rejected", hostile.UUID)
+	}
+
+	export := func(format string) string {
+		buf := &bytes.Buffer{}
+		ew, err := crud.NewMessageExportWriter(buf, format, "Biology class")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range exported {
+			if err := ew.Write(msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ew.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.String()
+	}
+
+	// json lines
+	lines := strings.Split(strings.TrimSpace(export(crud.ExportJSONL)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 json lines, got %d", len(lines))
+	}
+	msg := &crud.ExportMessage{}
+	if err := json.Unmarshal([]byte(lines[1]), msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Author != "Ekaterina Gorbunova" || msg.ReplyTo != "first-uuid" || msg.CreatedAt != "2020-07-01T08:01:00Z" || msg.Attachments[0].Path != a.Path {
+		t.Fatalf("unexpected exported message %+v", msg)
+	}
+
+	// csv
+	records, err := csv.NewReader(strings.NewReader(export(crud.ExportCSV))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "uuid" {
+		t.Fatalf("expected header and 2 records, got %v", records)
+	}
+	if last := records[2][len(records[2])-1]; last != a.Path {
+		t.Fatalf("expected attachment column to link downloaded file, got %q", last)
+	}
+
+	// html transcript escapes message text
+	html := export(crud.ExportHTML)
+	if !strings.Contains(html, "<title>Biology class</title>") || !strings.Contains(html, "Hello &lt;b&gt;class&lt;/b&gt;") || !strings.HasSuffix(html, "</html>\n") {
+		t.Fatalf("unexpected html transcript:\n%s", html)
+	}
+
+	if _, err := crud.NewMessageExportWriter(&bytes.Buffer{}, "xml", ""); err != crud.ErrExportFormat {
+		t.Fatalf("expected %v, got %v", crud.ErrExportFormat, err)
+	}
+}
@@ -0,0 +1,205 @@
+package crud_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestMessageScheduler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 11, 1, 9, 0, 0, 0, time.UTC)
+	path := filepath.Join(dir, "queue.json")
+
+	// schedule messages in one process
+	reminder := crud.NewScheduledMessage("chat-uuid", "Lesson starts now", now)
+	later := crud.NewScheduledMessage("chat-uuid", "Lesson ends now", now.Add(time.Hour))
+	flaky := crud.NewScheduledMessage("flaky-chat-uuid", "Homework", now)
+	broken := crud.NewScheduledMessage("broken-chat-uuid", "Grades", now)
+	if err := crud.NewMessageQueue(path).Add(later, reminder, flaky, broken); err != nil {
+		t.Fatal(err)
+	}
+
+	// deliver them in another one, flaky chat fails once, broken chat always fails
+	sent := make(map[string]int)
+	failures := make(map[string]int)
+	newScheduler := func() *crud.Scheduler {
+		s := crud.NewScheduler(crud.NewMessageQueue(path), &crud.PrivateUser{UUID: "user-uuid"}, "token")
+		s.MaxAttempts = 3
+		s.RetryDelay = time.Minute
+		s.Send = func(msg *crud.ScheduledMessage) (string, error) {
+			if msg.ChatUUID == "broken-chat-uuid" || (msg.ChatUUID == "flaky-chat-uuid" && failures[msg.ID] == 0) {
+				failures[msg.ID]++
+				return "", errors.New("request failed with status code 502 Bad Gateway")
+			}
+
+			// message is saved as sending before it's sent
+			queued, err := crud.NewMessageQueue(path).List()
+			if err != nil {
+				return "", err
+			}
+			for _, m := range queued {
+				if m.ID == msg.ID && m.Status != crud.ScheduleSending {
+					t.Errorf("expected message %q to be saved as %s while sending, got %s", m.Text, crud.ScheduleSending, m.Status)
+				}
+			}
+
+			sent[msg.ID]++
+			return "message-" + msg.ID, nil
+		}
+
+		return s
+	}
+
+	zero := newScheduler()
+	zero.Interval = 0
+	if err := zero.Run(context.Background()); !errors.Is(err, crud.ErrScheduleInterval) {
+		t.Fatalf("expected ErrScheduleInterval, got %v", err)
+	}
+
+	if err := newScheduler().RunOnce(now); err != nil {
+		t.Fatal(err)
+	}
+	if sent[reminder.ID] != 1 || sent[later.ID] != 0 || sent[flaky.ID] != 0 {
+		t.Fatalf("expected only reminder to be sent, got %v", sent)
+	}
+
+	// scheduler is restarted, retries aren't due yet and sent messages aren't sent again
+	if err := newScheduler().RunOnce(now.Add(30 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if sent[reminder.ID] != 1 || sent[flaky.ID] != 0 {
+		t.Fatalf("expected nothing to be sent before retry delay, got %v", sent)
+	}
+
+	for _, at := range []time.Duration{time.Minute, 3 * time.Minute, 2 * time.Hour} {
+		if err := newScheduler().RunOnce(now.Add(at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages, err := crud.NewMessageQueue(path).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := make(map[string]*crud.ScheduledMessage)
+	for _, msg := range messages {
+		statuses[msg.ID] = msg
+	}
+
+	for _, id := range []string{reminder.ID, later.ID, flaky.ID} {
+		if msg := statuses[id]; msg.Status != crud.ScheduleSent || msg.MessageUUID != "message-"+id || sent[id] != 1 {
+			t.Fatalf("expected message %q to be sent once, got %+v sent %d times", msg.Text, msg, sent[id])
+		}
+	}
+	if msg := statuses[broken.ID]; msg.Status != crud.ScheduleFailed || msg.Attempts != 3 || msg.LastError == "" {
+		t.Fatalf("expected broken message to fail after 3 attempts, got %+v", msg)
+	}
+	if messages[len(messages)-1].ID != later.ID {
+		t.Fatalf("expected messages to be sorted by due time")
+	}
+}
+
+func TestMessageQueueStaleLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// lock file is left by a killed process
+	path := filepath.Join(dir, "queue.json")
+	if err := ioutil.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := crud.NewMessageQueue(path)
+	if err := queue.Add(crud.NewScheduledMessage("chat-uuid", "Lesson starts now", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected lock file to be released, got %v", err)
+	}
+
+	// fresh lock file is respected
+	if err := ioutil.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queue.List(); !errors.Is(err, crud.ErrQueueLocked) {
+		t.Fatalf("expected ErrQueueLocked, got %v", err)
+	}
+
+}
+
+func TestMessageSchedulerInterrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// scheduler was killed while sending a message
+	now := time.Now()
+	path := filepath.Join(dir, "queue.json")
+	interrupted := crud.NewScheduledMessage("chat-uuid", "Lesson starts now", now)
+	interrupted.Status = crud.ScheduleSending
+	interrupted.Attempts = 1
+	if err := crud.NewMessageQueue(path).Add(interrupted); err != nil {
+		t.Fatal(err)
+	}
+
+	s := crud.NewScheduler(crud.NewMessageQueue(path), &crud.PrivateUser{UUID: "user-uuid"}, "token")
+	s.Send = func(msg *crud.ScheduledMessage) (string, error) {
+		t.Fatalf("message %q could be delivered already and mustn't be sent again", msg.Text)
+		return "", nil
+	}
+	if err := s.RunOnce(now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMessageSchedulerKeepsRunning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// queue can't be read, but scheduler checks it again on next tick
+	path := filepath.Join(dir, "queue.json")
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var failures int
+	s := crud.NewScheduler(crud.NewMessageQueue(path), &crud.PrivateUser{UUID: "user-uuid"}, "token")
+	s.Interval = time.Millisecond
+	s.OnError = func(err error) {
+		failures++
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if failures < 2 {
+		t.Fatalf("expected queue to be checked again after failure, got %d checks", failures)
+	}
+}
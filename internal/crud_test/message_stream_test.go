@@ -0,0 +1,210 @@
+package crud_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+// pushServer is a fake Acroplia push channel, it fails every third request and
+// sometimes repeats already sent events, like a flaky proxy in front of real one
+type pushServer struct {
+	mu       sync.Mutex
+	events   []*crud.MessageEvent
+	requests int
+}
+
+func (s *pushServer) publish(eventType, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursor := len(s.events) + 1
+	s.events = append(s.events, &crud.MessageEvent{
+		Cursor:  cursor,
+		Type:    eventType,
+		Message: &crud.UserMessageEntity{UUID: "message-" + strconv.Itoa(cursor), Type: "USER_TEXT", Text: text},
+	})
+}
+
+func (s *pushServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method+" "+r.URL.Path != "GET /v1/workspaces/chat-uuid/chat/events" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+
+	s.mu.Lock()
+	s.requests++
+	request := s.requests
+	s.mu.Unlock()
+
+	if request%3 == 0 {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	// zero cursor subscribes to new events only
+	if after == 0 {
+		s.mu.Lock()
+		json.NewEncoder(w).Encode(&crud.ResponseMessageEvents{Data: make([]*crud.MessageEvent, 0), Cursor: len(s.events)})
+		s.mu.Unlock()
+		return
+	}
+
+	// long poll for a short time
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for {
+		s.mu.Lock()
+		if len(s.events) > after || time.Now().After(deadline) {
+			resp := &crud.ResponseMessageEvents{Data: make([]*crud.MessageEvent, 0), Cursor: len(s.events)}
+
+			from := after
+			if request%2 == 0 && from > 0 {
+				from-- // repeat last seen event
+			}
+			if len(s.events) > after {
+				resp.Data = append(resp.Data, s.events[from:]...)
+			}
+
+			json.NewEncoder(w).Encode(resp)
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAPIMessageStreamResumes(t *testing.T) {
+	server := &pushServer{}
+	server.publish(crud.MessageEventNew, "sent before watching")
+
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mu := &sync.Mutex{}
+	received := make([]*crud.MessageEvent, 0)
+	stream := crud.NewMessageStream("chat-uuid", "token", func(event *crud.MessageEvent) {
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	})
+	stream.Backoff = 5 * time.Millisecond
+
+	done := make(chan error)
+	go func() {
+		done <- stream.Run(ctx)
+	}()
+
+	// wait until stream starts following chat
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		server.publish(crud.MessageEventNew, "message "+strconv.Itoa(i))
+		time.Sleep(10 * time.Millisecond)
+	}
+	server.publish(crud.MessageEventEdited, "message 0 (edited)")
+	server.publish(crud.MessageEventDeleted, "")
+
+	for {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n >= 12 {
+			break
+		}
+
+		if ctx.Err() != nil {
+			t.Fatalf("expected 12 events, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// events before watching aren't streamed, others come in order without gaps or duplicates
+	if len(received) != 12 {
+		t.Fatalf("expected 12 events, got %d", len(received))
+	}
+	for i, event := range received {
+		if event.Cursor != i+2 {
+			t.Fatalf("expected event %d to have cursor %d, got %d", i, i+2, event.Cursor)
+		}
+	}
+	if received[10].Type != crud.MessageEventEdited || received[11].Type != crud.MessageEventDeleted {
+		t.Fatalf("expected edited and deleted events at the end, got %s and %s", received[10].Type, received[11].Type)
+	}
+	if stream.Cursor != 13 {
+		t.Fatalf("expected stream to stop at cursor 13, got %d", stream.Cursor)
+	}
+}
+
+func TestAPIMessageStreamEmptyChat(t *testing.T) {
+	// server doesn't long poll and chat has no events yet
+	mu := &sync.Mutex{}
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(&crud.ResponseMessageEvents{Data: make([]*crud.MessageEvent, 0)})
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	stream := crud.NewMessageStream("chat-uuid", "token", nil)
+	stream.Backoff = 50 * time.Millisecond
+	if err := stream.Run(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected stream to run until deadline, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests > 5 {
+		t.Fatalf("expected empty chat to be polled with backoff, got %d requests in 200ms", requests)
+	}
+}
+
+func TestAPIMessageStreamExpiredToken(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// stream retries forever by default, but expired token isn't retried
+	stream := crud.NewMessageStream("chat-uuid", "expired-token", nil)
+	stream.Backoff = time.Millisecond
+	if err := stream.Run(ctx); !errors.Is(err, crud.ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected expired token not to be retried, got %d requests", requests)
+	}
+}
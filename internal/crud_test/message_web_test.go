@@ -1,11 +1,13 @@
 package crud_test
 
 import (
+	"fmt"
 	"log"
 	"testing"
 
 	"github.com/bejaneps/acroplia/internal/crud"
 	"github.com/bejaneps/acroplia/internal/services"
+	"github.com/google/uuid"
 )
 
 func TestWebSendMessageByFullname(t *testing.T) {
@@ -20,7 +22,7 @@ func TestWebSendMessageByFullname(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	err = crud.SendMessage("Bezhan Mukhidinov", "", fmt.Sprintf("Test Message %s", uuid.New().String(), wd)
+	err = crud.SendMessage("Bezhan Mukhidinov", "", fmt.Sprintf("Test Message %s", uuid.New().String()), wd)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -38,7 +40,7 @@ func TestWebSendMessageByUsername(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	err = crud.SendMessage("", "bejaneps", fmt.Sprintf("Test Message %s", uuid.New().String(), wd)
+	err = crud.SendMessage("", "bejaneps", fmt.Sprintf("Test Message %s", uuid.New().String()), wd)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -56,7 +58,7 @@ func TestWebSendMessageToInvalidUser(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	err = crud.SendMessage("Jonn Doe", "", fmt.Sprintf("Test Message %s", uuid.New().String(), wd)
+	err = crud.SendMessage("Jonn Doe", "", fmt.Sprintf("Test Message %s", uuid.New().String()), wd)
 	if err != crud.ErrUserNotFound {
 		log.Fatal(err)
 	}
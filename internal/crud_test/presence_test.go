@@ -0,0 +1,140 @@
+package crud_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+func TestAPIPresence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/users/presence" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		resp := &crud.ResponsePublicUsers{}
+		for _, uuid := range r.URL.Query()["uuid"] {
+			resp.Data = append(resp.Data, &crud.PublicUser{UUID: uuid, Online: strings.HasPrefix(uuid, "online")})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	resp, err := crud.GetPresence(context.Background(), []string{"online-uuid", "offline-uuid"}, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || !resp.Data[0].Online || resp.Data[1].Online {
+		t.Fatalf("expected online-uuid to be online and offline-uuid offline, got %d users", len(resp.Data))
+	}
+}
+
+func TestPresenceWatcherTransitions(t *testing.T) {
+	student := &crud.PublicUser{UUID: "student-uuid", UserName: "student"}
+	tutor := &crud.PublicUser{UUID: "tutor-uuid", UserName: "tutor", Tutor: true, Online: true}
+
+	// each poll returns the next snapshot of statuses
+	snapshots := [][]crud.PublicUser{
+		{*student, *tutor},
+		{*student, *tutor},
+		{{UUID: "student-uuid", UserName: "student", Online: true}, {UUID: "tutor-uuid", UserName: "tutor", Tutor: true, Online: true, TutorOnline: true}},
+		{{UUID: "student-uuid", UserName: "student", Online: true}, {UUID: "tutor-uuid", UserName: "tutor", Tutor: true}},
+	}
+
+	changes := make([]string, 0)
+	watcher := crud.NewPresenceWatcher([]string{"student-uuid", "tutor-uuid"}, "token", func(change *crud.PresenceChange) {
+		s := change.User.UserName + " " + change.Status
+		if change.Initial {
+			s += " initial"
+		}
+		changes = append(changes, s)
+	})
+
+	poll := 0
+	watcher.List = func(ctx context.Context, userUUIDs []string) ([]*crud.PublicUser, error) {
+		users := make([]*crud.PublicUser, 0, len(snapshots[poll]))
+		for i := range snapshots[poll] {
+			user := snapshots[poll][i]
+			users = append(users, &user)
+		}
+		poll++
+
+		return users, nil
+	}
+
+	for range snapshots {
+		if err := watcher.Poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{
+		"student OFFLINE initial",
+		"tutor ONLINE initial",
+		"tutor TUTOR_OFFLINE initial",
+		"student ONLINE",
+		"tutor TUTOR_ONLINE",
+		"tutor OFFLINE",
+		"tutor TUTOR_OFFLINE",
+	}
+	if strings.Join(changes, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected changes:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(changes, "\n"))
+	}
+}
+
+func TestPresenceWatcherRetries(t *testing.T) {
+	failure := errors.New("connection refused")
+
+	var polls int
+	watcher := crud.NewPresenceWatcher([]string{"user-uuid"}, "token", nil)
+	watcher.Backoff, watcher.MaxBackoff, watcher.Retries = time.Millisecond, 2*time.Millisecond, 3
+	watcher.List = func(ctx context.Context, userUUIDs []string) ([]*crud.PublicUser, error) {
+		polls++
+		return nil, failure
+	}
+
+	if err := watcher.Run(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("expected %v, got %v", failure, err)
+	}
+	if polls != 4 {
+		t.Fatalf("expected 4 polls, got %d", polls)
+	}
+
+	// watching stops when ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	watcher.Interval = time.Millisecond
+	watcher.List = func(ctx context.Context, userUUIDs []string) ([]*crud.PublicUser, error) {
+		return []*crud.PublicUser{{UUID: "user-uuid"}}, nil
+	}
+	if err := watcher.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// expired token isn't retried, even if retrying forever
+	polls = 0
+	watcher.Retries = 0
+	watcher.List = func(ctx context.Context, userUUIDs []string) ([]*crud.PublicUser, error) {
+		polls++
+		return nil, crud.ErrUnauthorized
+	}
+	if err := watcher.Run(context.Background()); !errors.Is(err, crud.ErrUnauthorized) || polls != 1 {
+		t.Fatalf("expected %v after 1 poll, got %v after %d polls", crud.ErrUnauthorized, err, polls)
+	}
+
+	// zero interval would poll in a busy loop
+	watcher.Interval = 0
+	if err := watcher.Run(context.Background()); !errors.Is(err, crud.ErrPresenceInterval) {
+		t.Fatalf("expected %v, got %v", crud.ErrPresenceInterval, err)
+	}
+}
@@ -0,0 +1,212 @@
+package crud_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+const quizDefinition = `quizzes:
+  - title: Photosynthesis
+    pass_score: 60
+    time_limit: 20m
+    questions:
+      - text: What do plants produce?
+        choices: [Oxygen, Nitrogen, Helium]
+        correct: [Oxygen]
+      - text: Which are gases?
+        choices: [Oxygen, Water, Nitrogen]
+        correct: [Oxygen, Nitrogen]
+      - text: Name the green pigment
+        answer: chlorophyll
+        points: 2
+`
+
+func writeQuizDefinition(t *testing.T, dir, name, text string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatalf("failed to write definition: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadQuizDefinitions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quiz")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defs, err := crud.LoadQuizDefinitions(writeQuizDefinition(t, dir, "quizzes.yaml", quizDefinition))
+	if err != nil {
+		t.Fatalf("failed to load definitions: %v", err)
+	}
+	if len(defs) != 1 {
+		t.Fatalf("expected 1 quiz, got %d", len(defs))
+	}
+
+	quiz, err := defs[0].Quiz()
+	if err != nil {
+		t.Fatalf("failed to convert definition: %v", err)
+	}
+	if quiz.PassScore != 60 || quiz.TimeLimit != 1200 {
+		t.Errorf("expected pass score 60 and time limit 1200, got %d and %d", quiz.PassScore, quiz.TimeLimit)
+	}
+	if quiz.MaxScore() != 4 {
+		t.Errorf("expected max score 4, got %d", quiz.MaxScore())
+	}
+
+	types := []string{crud.QuestionSingleChoice, crud.QuestionMultipleChoice, crud.QuestionText}
+	for i, q := range quiz.Questions {
+		if q.Type != types[i] {
+			t.Errorf("expected question %d to be %s, got %s", i+1, types[i], q.Type)
+		}
+	}
+	if !quiz.Questions[0].Choices[0].Correct || quiz.Questions[0].Choices[1].Correct {
+		t.Errorf("expected only first choice of question 1 to be correct")
+	}
+
+	invalid := map[string]string{
+		"empty":         "quizzes: []\n",
+		"no questions":  "quizzes:\n  - title: Empty\n",
+		"wrong correct": "quizzes:\n  - title: Wrong\n    questions:\n      - text: Pick\n        choices: [A, B]\n        correct: [C]\n",
+		"one choice":    "quizzes:\n  - title: Single\n    questions:\n      - text: Pick\n        choices: [A]\n        correct: [A]\n",
+		"no answer":     "quizzes:\n  - title: Text\n    questions:\n      - text: Type\n",
+		"pass score":    "quizzes:\n  - title: Score\n    pass_score: 120\n    questions:\n      - text: Type\n        answer: a\n",
+		"time limit":    "quizzes:\n  - title: Limit\n    time_limit: soon\n    questions:\n      - text: Type\n        answer: a\n",
+	}
+	for name, text := range invalid {
+		if _, err := crud.LoadQuizDefinitions(writeQuizDefinition(t, dir, "invalid.yaml", text)); err == nil {
+			t.Errorf("expected error for %s definition", name)
+		}
+	}
+}
+
+func TestAPIQuizResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quiz")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defs, err := crud.LoadQuizDefinitions(writeQuizDefinition(t, dir, "quizzes.yaml", quizDefinition))
+	if err != nil {
+		t.Fatalf("failed to load definitions: %v", err)
+	}
+
+	tutor := &crud.PrivateUser{UUID: "tutor-uuid", UserName: "tutor"}
+	item, err := crud.NewQuiz(defs[0], tutor)
+	if err != nil {
+		t.Fatalf("failed to build quiz: %v", err)
+	}
+	questions := item.Quiz.Questions
+
+	results := []*crud.QuizResult{
+		{
+			Student:     &crud.PublicUser{UUID: "student1-uuid", UserName: "anna"},
+			Score:       4,
+			MaxScore:    4,
+			Passed:      true,
+			SubmittedAt: 1598918400000,
+			Answers: []*crud.QuizAnswer{
+				{QuestionUUID: questions[0].UUID, Correct: true, Points: 1},
+				{QuestionUUID: questions[1].UUID, Correct: true, Points: 1},
+				{QuestionUUID: questions[2].UUID, Correct: true, Points: 2},
+			},
+		},
+		{
+			Student:  &crud.PublicUser{UUID: "student2-uuid", UserName: "boris"},
+			Score:    1,
+			MaxScore: 4,
+			Answers: []*crud.QuizAnswer{
+				{QuestionUUID: questions[0].UUID, Correct: true, Points: 1},
+			},
+		},
+	}
+
+	var assigned *crud.AssignmentRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/v1/library/items/"+item.UUID+"/assignments":
+			assigned = &crud.AssignmentRequest{}
+			json.NewDecoder(r.Body).Decode(assigned)
+
+			resp := &crud.ResponseAssignments{}
+			for _, a := range assigned.Assignees {
+				resp.Data = append(resp.Data, &crud.Assignment{UUID: a.UUID + "-assignment", Title: item.Title, Assignee: &crud.PublicUser{UUID: a.UUID}})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case r.Method == "GET" && r.URL.Path == "/v1/library/items/"+item.UUID+"/results":
+			if r.Header.Get("X-Auth-Token") != "tutor-token" {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+			json.NewEncoder(w).Encode(&crud.ResponseQuizResults{Data: results})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	if _, err := crud.AssignQuiz(item.UUID, &crud.AssignmentRequest{}, "tutor-token"); err != crud.ErrAssigneeEmpty {
+		t.Errorf("expected ErrAssigneeEmpty, got %v", err)
+	}
+
+	req := &crud.AssignmentRequest{Assignees: []*crud.Assignee{{Type: crud.AssigneeWorkspace, UUID: "class-uuid"}}}
+	resp, err := crud.AssignQuiz(item.UUID, req, "tutor-token")
+	if err != nil {
+		t.Fatalf("failed to assign quiz: %v", err)
+	}
+	if len(resp.Data) != 1 || assigned == nil || assigned.Assignees[0].UUID != "class-uuid" {
+		t.Errorf("expected quiz to be assigned to class-uuid")
+	}
+
+	if _, err := crud.ListQuizResults(item.UUID, "student-token"); !errors.Is(err, crud.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for student, got %v", err)
+	}
+
+	list, err := crud.ListQuizResults(item.UUID, "tutor-token")
+	if err != nil {
+		t.Fatalf("failed to list results: %v", err)
+	}
+	if len(list.Data) != 2 || list.Data[1].Percent() != 25 {
+		t.Fatalf("expected 2 results with second at 25 percent, got %d", len(list.Data))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := crud.WriteQuizResultsCSV(buf, item.Quiz, list.Data); err != nil {
+		t.Fatalf("failed to write csv: %v", err)
+	}
+
+	rows, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read csv: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d rows", len(rows))
+	}
+	if got := strings.Join(rows[0], ","); got != "student_uuid,username,name,score,max_score,percent,passed,submitted_at,q1,q2,q3" {
+		t.Errorf("unexpected header: %s", got)
+	}
+	if got := strings.Join(rows[1][3:], ","); got != "4,4,100.0,true,2020-09-01T00:00:00Z,1,1,2" {
+		t.Errorf("unexpected first row: %s", got)
+	}
+	if got := strings.Join(rows[2][3:], ","); got != "1,4,25.0,false,,1,," {
+		t.Errorf("unexpected second row: %s", got)
+	}
+}
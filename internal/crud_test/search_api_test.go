@@ -0,0 +1,120 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+func TestAPISearch(t *testing.T) {
+	results := []*crud.SearchResult{
+		{
+			Type:       crud.LibraryTextpad,
+			UUID:       "lesson-uuid",
+			Title:      "Lesson 1",
+			Snippet:    "Photosynthesis is a process used by plants",
+			Highlights: []*crud.Highlight{{Offset: 0, Length: 14}},
+			Path:       []*crud.PathItem{{UUID: "biology-uuid", Title: "Biology"}, {UUID: "week-uuid", Title: "Week 1"}},
+			CreatedAt:  1593590400000, // 2020-07-01
+		},
+		{
+			Type:       crud.SearchMessage,
+			UUID:       "message-uuid",
+			Title:      "Biology 101",
+			Snippet:    "Don't forget homework about photosynthesis",
+			Highlights: []*crud.Highlight{{Offset: 28, Length: 14}},
+			ChatUUID:   "chat-uuid",
+			CreatedAt:  1598918400000, // 2020-09-01
+		},
+	}
+
+	var lastQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		lastQuery = r.URL.RawQuery
+
+		q := r.URL.Query()
+		since, _ := strconv.Atoi(q.Get("since"))
+		until, _ := strconv.Atoi(q.Get("until"))
+
+		resp := &crud.ResponseSearch{Data: make([]*crud.SearchResult, 0)}
+		for _, result := range results {
+			if types := q["type"]; len(types) > 0 && !strings.Contains(strings.Join(types, ","), result.Type) {
+				continue
+			}
+			if (since != 0 && result.CreatedAt < since) || (until != 0 && result.CreatedAt > until) {
+				continue
+			}
+			resp.Data = append(resp.Data, result)
+		}
+		resp.Total = len(resp.Data)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// type filter and language
+	resp, err := crud.Search("photosynthesis", "token", &crud.SearchFilter{
+		Types: []string{"textpad"},
+		Lang:  &crud.Language{ISOCode: "en", SearchConfig: "english"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].UUID != "lesson-uuid" {
+		t.Fatalf("expected only lesson-uuid textpad, got %d results", len(resp.Data))
+	}
+	if !strings.Contains(lastQuery, "lang=english") || !strings.Contains(lastQuery, "type=TEXTPAD") {
+		t.Fatalf("expected language search config and upper case type in query, got %s", lastQuery)
+	}
+
+	lesson := resp.Data[0]
+	if crumbs := lesson.Breadcrumbs(" / "); crumbs != "Biology / Week 1 / Lesson 1" {
+		t.Fatalf("unexpected breadcrumbs: %s", crumbs)
+	}
+	if snippet := lesson.HighlightedSnippet("**", "**"); snippet != "**Photosynthesis** is a process used by plants" {
+		t.Fatalf("unexpected highlighted snippet: %s", snippet)
+	}
+
+	// date range
+	resp, err = crud.Search("photosynthesis", "token", &crud.SearchFilter{
+		Since: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Type != crud.SearchMessage || resp.Data[0].ChatUUID != "chat-uuid" {
+		t.Fatalf("expected only message from September, got %d results", len(resp.Data))
+	}
+
+	// snippet is shortened around highlight
+	message := resp.Data[0]
+	message.TruncateSnippet(20)
+	if snippet := message.HighlightedSnippet("[", "]"); snippet != "about [photosynthesis]" {
+		t.Fatalf("unexpected truncated snippet: %q", snippet)
+	}
+
+	// invalid filters aren't sent
+	if _, err := crud.Search("photosynthesis", "token", &crud.SearchFilter{Types: []string{"video"}}); !errors.Is(err, crud.ErrSearchType) {
+		t.Fatalf("expected ErrSearchType, got %v", err)
+	}
+	_, err = crud.Search("photosynthesis", "token", &crud.SearchFilter{
+		Since: time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != crud.ErrSearchRange {
+		t.Fatalf("expected ErrSearchRange, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+func TestAPITaskAssignments(t *testing.T) {
+	tutor := &crud.PrivateUser{UUID: "tutor-uuid", UserName: "tutor"}
+	taskList := crud.NewTaskList("Homework 1", "", []string{"Read chapter 1", "Solve exercises"}, tutor)
+
+	// members of workspace get own assignments
+	workspaceMembers := map[string][]string{"class-uuid": {"student1-uuid", "student2-uuid"}}
+	assignments := make(map[string]*crud.Assignment)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[1:]
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/v1/library/items/"+taskList.UUID+"/assignments":
+			req := &crud.AssignmentRequest{}
+			json.NewDecoder(r.Body).Decode(req)
+
+			resp := &crud.ResponseAssignments{}
+			for _, assignee := range req.Assignees {
+				users := []string{assignee.UUID}
+				if assignee.Type == crud.AssigneeWorkspace {
+					users = workspaceMembers[assignee.UUID]
+				}
+
+				for _, user := range users {
+					a := &crud.Assignment{
+						UUID:         user + "-assignment",
+						TaskListUUID: taskList.UUID,
+						Title:        taskList.Title,
+						Status:       crud.AssignmentOpen,
+						Assignee:     &crud.PublicUser{UUID: user},
+						DueAt:        req.DueAt,
+					}
+					for _, item := range taskList.TaskList.Items {
+						task := *item
+						a.Items = append(a.Items, &task)
+					}
+					assignments[a.UUID] = a
+					resp.Data = append(resp.Data, a)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case r.Method == "GET" && len(parts) == 3 && parts[0] == "users":
+			resp := &crud.ResponseAssignments{Data: make([]*crud.Assignment, 0)}
+			for _, a := range assignments {
+				if a.Assignee.UUID == parts[1] && (r.URL.Query().Get("status") == "" || r.URL.Query().Get("status") == a.Status) {
+					resp.Data = append(resp.Data, a)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case len(parts) >= 2 && parts[0] == "assignments" && assignments[parts[1]] != nil:
+			a := assignments[parts[1]]
+			if r.Method == "PUT" {
+				data := struct {
+					Done bool `json:"done"`
+				}{}
+				json.NewDecoder(r.Body).Decode(&data)
+
+				a.Status = crud.AssignmentDone
+				for _, item := range a.Items {
+					if item.UUID == parts[3] {
+						item.Done = data.Done
+					}
+					if !item.Done {
+						a.Status = crud.AssignmentOpen
+					}
+				}
+			}
+			json.NewEncoder(w).Encode(&crud.ResponseAssignment{Data: a})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	if _, err := crud.AssignTaskList(taskList.UUID, &crud.AssignmentRequest{}, "token"); err != crud.ErrAssigneeEmpty {
+		t.Fatalf("expected ErrAssigneeEmpty, got %v", err)
+	}
+
+	resp, err := crud.AssignTaskList(taskList.UUID, &crud.AssignmentRequest{
+		Assignees: []*crud.Assignee{
+			{Type: crud.AssigneeUser, UUID: "student3-uuid"},
+			{Type: crud.AssigneeWorkspace, UUID: "class-uuid"},
+		},
+		DueAt: 1598918400000,
+	}, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 3 {
+		t.Fatalf("expected 3 assignments, got %d", len(resp.Data))
+	}
+
+	// student completes tasks by number and by uuid
+	open, err := crud.ListAssignments("student1-uuid", "token", &crud.AssignmentFilter{Status: crud.AssignmentOpen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open.Data) != 1 || open.Data[0].DueAt != 1598918400000 {
+		t.Fatalf("expected 1 open assignment with due date, got %d", len(open.Data))
+	}
+
+	assignment := open.Data[0]
+	first, err := assignment.FindTask("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := assignment.FindTask(assignment.Items[1].UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := assignment.FindTask("3"); !errors.Is(err, crud.ErrTaskNotFound) {
+		t.Fatalf("expected ErrTaskNotFound, got %v", err)
+	}
+
+	changed, err := crud.SetTaskDone(assignment.UUID, first.UUID, true, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done, total := changed.Data.Progress(); done != 1 || total != 2 || changed.Data.Status != crud.AssignmentOpen {
+		t.Fatalf("expected 1 of 2 tasks done in open assignment, got %d of %d, %s", done, total, changed.Data.Status)
+	}
+
+	changed, err = crud.SetTaskDone(assignment.UUID, second.UUID, true, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Data.Status != crud.AssignmentDone {
+		t.Fatalf("expected assignment to be done, got %s", changed.Data.Status)
+	}
+
+	open, err = crud.ListAssignments("student1-uuid", "token", &crud.AssignmentFilter{Status: crud.AssignmentOpen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open.Data) != 0 {
+		t.Fatalf("expected no open assignments, got %d", len(open.Data))
+	}
+
+	all, err := crud.ListAssignments("student1-uuid", "token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Data) != 1 {
+		t.Fatalf("expected 1 assignment, got %d", len(all.Data))
+	}
+
+	// task can be marked as not done again
+	if _, err := crud.SetTaskDone(assignment.UUID, second.UUID, false, "token"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := crud.GetAssignment(assignment.UUID, "token"); err != nil || got.Data.Status != crud.AssignmentOpen {
+		t.Fatalf("expected reopened assignment, got %v", err)
+	}
+
+	if _, err := crud.GetAssignment("missing-assignment", "token"); !errors.Is(err, crud.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPITextpadBulkCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-bulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// prepare manifest and content file
+	contentFile := filepath.Join(dir, "lesson.txt")
+	if err := ioutil.WriteFile(contentFile, []byte("Photosynthesis"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := filepath.Join(dir, "manifest.csv")
+	// content file is relative to manifest, not to working directory
+	data := "title,subtitle,folder,lang,content_file\n" +
+		"Lesson 1,Intro,Biology/Week 1,en,lesson.txt\n" +
+		"Lesson 2,,Biology/Week 1,xx,\n" +
+		"Lesson 3,,,,\n" +
+		"Lesson 4,,Biology//Week 2,,\n"
+	if err := ioutil.WriteFile(manifest, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// fake server stores created items
+	mu := &sync.Mutex{}
+	created := make(map[string]*crud.LibraryItem)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/languages":
+			json.NewEncoder(w).Encode(&crud.ResponseLanguages{Data: []*crud.Language{{UUID: "en-uuid", ISOCode: "en"}}})
+		case "GET /v1/library/user-uuid":
+			resp := &crud.ResponseLibraryItems{}
+			for _, item := range created {
+				if item.Type == crud.LibraryFolder {
+					resp.Data = append(resp.Data, item)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "POST /v1/library/user-uuid":
+			item := &crud.LibraryItem{}
+			json.NewDecoder(r.Body).Decode(item)
+			created[item.UUID] = item
+			json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: item})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	rows, err := crud.ReadTextpadManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+	if rows[0].ContentFile != contentFile {
+		t.Fatalf("expected content file %s, got %s", contentFile, rows[0].ContentFile)
+	}
+
+	// first run skips the last row, as if it was created before
+	resultsPath := filepath.Join(dir, "results.csv")
+	rw, err := crud.NewTextpadBulkResultWriter(resultsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &crud.PrivateUser{UUID: "user-uuid"}
+	bulk := crud.NewTextpadBulk(user, "token", 2)
+	bulk.Skip[3] = true
+	bulk.OnResult = func(result *crud.TextpadBulkResult) {
+		if err := rw.Write(result); err != nil {
+			t.Error(err)
+		}
+	}
+
+	results, err := bulk.Create(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	// compare results from results file to expected results
+	saved, err := crud.ReadTextpadBulkResults(resultsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved[1] == nil || saved[1].UUID == "" || saved[1].Error != "" {
+		t.Fatalf("expected row 1 to be created, got %+v", saved[1])
+	}
+	if saved[2] == nil || saved[2].Error == "" {
+		t.Fatalf("expected row 2 to fail because of unsupported language, got %+v", saved[2])
+	}
+	if saved[4] == nil || saved[4].Error == "" {
+		t.Fatalf("expected row 4 to fail because of empty folder title, got %+v", saved[4])
+	}
+
+	textpad := created[saved[1].UUID]
+	if textpad.Delta.Text() != "Photosynthesis\n" {
+		t.Fatalf("expected content from content file, got %q", textpad.Delta.Text())
+	}
+	if textpad.Lang == nil || textpad.Lang.UUID != "en-uuid" {
+		t.Fatalf("expected english language, got %+v", textpad.Lang)
+	}
+	if len(textpad.Path) != 2 || textpad.Path[0].Title != "Biology" || textpad.Path[1].Title != "Week 1" {
+		t.Fatalf("expected textpad to be nested under Biology/Week 1, got %+v", textpad.Path)
+	}
+
+	// both folders and a textpad are created
+	if len(created) != 3 {
+		t.Fatalf("expected 3 created items, got %d", len(created))
+	}
+
+	// json manifest with a null row is rejected
+	manifest = filepath.Join(dir, "manifest.json")
+	if err := ioutil.WriteFile(manifest, []byte(`[{"title": "Lesson 1"}, null]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crud.ReadTextpadManifest(manifest); err == nil || err.Error() != "manifest row 2 is empty" {
+		t.Fatalf("expected empty manifest row 2 to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package crud_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+// otServer is a fake Acroplia server, that transforms submitted changes against it's history like real one
+type otServer struct {
+	mu      sync.Mutex
+	doc     *crud.Delta
+	history []*crud.TextpadChange
+	seen    map[string]bool
+}
+
+func (s *otServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method + " " + r.URL.Path {
+	case "GET /v1/library/items/textpad-uuid":
+		s.mu.Lock()
+		item := &crud.LibraryItem{UUID: "textpad-uuid", Type: crud.LibraryTextpad, Version: len(s.history), Delta: s.doc}
+		json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: item})
+		s.mu.Unlock()
+	case "POST /v1/textpads/textpad-uuid/changes":
+		change := &crud.TextpadChange{}
+		json.NewDecoder(r.Body).Decode(change)
+
+		s.mu.Lock()
+		if !s.seen[change.ID] {
+			s.seen[change.ID] = true
+
+			delta := change.Delta
+			for _, h := range s.history[change.Version:] {
+				delta = h.Delta.Transform(delta, true)
+			}
+			s.doc = s.doc.Compose(delta)
+			s.history = append(s.history, &crud.TextpadChange{ID: change.ID, Version: len(s.history) + 1, User: change.User, Delta: delta})
+		}
+		s.mu.Unlock()
+	case "GET /v1/textpads/textpad-uuid/changes":
+		since, _ := strconv.Atoi(r.URL.Query().Get("since"))
+
+		// long poll for a short time
+		deadline := time.Now().Add(100 * time.Millisecond)
+		for {
+			s.mu.Lock()
+			if len(s.history) > since || time.Now().After(deadline) {
+				changes := make([]*crud.TextpadChange, 0)
+				if len(s.history) > since {
+					changes = append(changes, s.history[since:]...)
+				}
+				json.NewEncoder(w).Encode(&crud.ResponseTextpadChanges{Data: changes})
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestAPILiveTextpadConcurrentEditing(t *testing.T) {
+	server := &otServer{
+		doc:  crud.NewDelta().Insert("Notes\n", nil),
+		seen: make(map[string]bool),
+	}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// open textpad by a bot and a human
+	bot, err := crud.OpenLiveTextpad("textpad-uuid", &crud.PrivateUser{UUID: "bot-uuid"}, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	human, err := crud.OpenLiveTextpad("textpad-uuid", &crud.PrivateUser{UUID: "human-uuid"}, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received int
+	mu := &sync.Mutex{}
+	human.OnChange = func(change *crud.TextpadChange) {
+		mu.Lock()
+		received++
+		mu.Unlock()
+	}
+
+	go bot.Run(ctx)
+	go human.Run(ctx)
+
+	// both edit the document at the same time
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			bot.Append(" bot" + strconv.Itoa(i))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			human.Submit(crud.NewDelta().Insert("h"+strconv.Itoa(i)+" ", nil))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	wg.Wait()
+
+	// wait until all changes are acknowledged and both documents are the same as on server
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		server.mu.Lock()
+		serverText := server.doc.Text()
+		server.mu.Unlock()
+
+		if bot.Text() == serverText && human.Text() == serverText && strings.Count(serverText, "bot") == 10 && strings.Count(serverText, "h") == 10 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("documents didn't converge:\nserver: %q\nbot:    %q\nhuman:  %q", serverText, bot.Text(), human.Text())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	text := human.Text()
+	if !strings.HasSuffix(text, "bot9\n") || !strings.Contains(text, "Notes") {
+		t.Fatalf("expected bot changes at the end of document, got %q", text)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received == 0 {
+		t.Fatal("expected human to receive changes of bot")
+	}
+}
@@ -0,0 +1,75 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPITextpadVersions(t *testing.T) {
+	author := &crud.PublicUser{UUID: "user-uuid", FirstName: "Bezhan", LastName: "Mukhidinov"}
+	versions := []*crud.TextpadVersion{
+		{Version: 1, User: author, CreatedAt: 1600000000000, Delta: &crud.Delta{Ops: []*crud.Op{{Insert: "first line\nsecond line\n"}}}},
+		{Version: 2, User: author, CreatedAt: 1600000060000, Delta: &crud.Delta{Ops: []*crud.Op{{Insert: "first line\nchanged line\nthird line\n"}}}},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/textpads/textpad-uuid/versions":
+			json.NewEncoder(w).Encode(&crud.ResponseTextpadVersions{Data: versions})
+		case "GET /v1/textpads/textpad-uuid/versions/1":
+			json.NewEncoder(w).Encode(&crud.ResponseTextpadVersion{Data: versions[0]})
+		case "GET /v1/textpads/textpad-uuid/versions/2":
+			json.NewEncoder(w).Encode(&crud.ResponseTextpadVersion{Data: versions[1]})
+		case "POST /v1/textpads/textpad-uuid/versions/1/restore":
+			json.NewEncoder(w).Encode(&crud.ResponseTextpad{Data: &crud.Textpad{UUID: "textpad-uuid", Version: 3, Delta: versions[0].Delta}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// list versions
+	listResp, err := crud.ListTextpadVersions("textpad-uuid", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listResp.Data) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(listResp.Data))
+	}
+
+	// get both versions and compare them
+	from, err := crud.GetTextpadVersion("textpad-uuid", 1, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := crud.GetTextpadVersion("textpad-uuid", 2, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expDiff := "--- version 1\n+++ version 2\n first line\n-second line\n+changed line\n+third line\n"
+	if diff := crud.DiffVersions(from.Data, to.Data); diff != expDiff {
+		t.Fatalf("expected diff:\n%s\ngot:\n%s", expDiff, diff)
+	}
+
+	// restore first version
+	restoreResp, err := crud.RestoreTextpadVersion("textpad-uuid", 1, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restoreResp.Data.Version != 3 || restoreResp.Data.Delta.Text() != "first line\nsecond line\n" {
+		t.Fatalf("expected version 3 with text of version 1, got version %d with text %q", restoreResp.Data.Version, restoreResp.Data.Delta.Text())
+	}
+
+	// missing version
+	_, err = crud.GetTextpadVersion("textpad-uuid", 5, "token")
+	if err == nil {
+		t.Fatal("expected error for missing version")
+	}
+}
@@ -0,0 +1,262 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+func TestAPIResolveDirectChat(t *testing.T) {
+	users := []*crud.PublicUser{
+		{UUID: "ekaterina-uuid", UserName: "ekaterina", FirstName: "Ekaterina", LastName: "Gorbunova"},
+		{UUID: "ivan1-uuid", UserName: "ivan", FirstName: "Ivan", LastName: "Petrov"},
+		{UUID: "ivan2-uuid", UserName: "ivan.petrov", FirstName: "Ivan", LastName: "Petrov"},
+	}
+
+	var createdChats int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/users/search":
+			query := strings.ToLower(r.URL.Query().Get("query"))
+			resp := &crud.ResponsePublicUsers{Data: make([]*crud.PublicUser, 0)}
+			for _, user := range users {
+				if strings.Contains(strings.ToLower(user.UserName+" "+user.FullName()), query) {
+					resp.Data = append(resp.Data, user)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "GET /v1/users/ivan1-uuid/chat":
+			json.NewEncoder(w).Encode(&crud.ResponseChat{Data: &crud.Chat{UUID: "chat-ivan1", Type: "PRIVATE"}})
+		case "POST /v1/users/ekaterina-uuid/chat":
+			createdChats++
+			json.NewEncoder(w).Encode(&crud.ResponseChat{Data: &crud.Chat{UUID: "chat-ekaterina", Type: "PRIVATE"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// username matches exactly, not just as a part of other username
+	user, err := crud.FindUserByUsername("@Ivan", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UUID != "ivan1-uuid" {
+		t.Fatalf("expected user ivan1-uuid, got %s", user.UUID)
+	}
+
+	_, err = crud.FindUserByUsername("nobody", "token")
+	if !errors.Is(err, crud.ErrUserNotFound) {
+		t.Fatalf("expected %v, got %v", crud.ErrUserNotFound, err)
+	}
+
+	// several users share the same full name
+	_, err = crud.FindUserByFullname("ivan  petrov", "token")
+	ambiguous := &crud.AmbiguousUserError{}
+	if !errors.As(err, &ambiguous) || !errors.Is(err, crud.ErrUserAmbiguous) {
+		t.Fatalf("expected %v, got %v", crud.ErrUserAmbiguous, err)
+	}
+	if len(ambiguous.Users) != 2 {
+		t.Fatalf("expected 2 matched users, got %d", len(ambiguous.Users))
+	}
+
+	// existing chat is reused
+	chat, err := crud.OpenDirectChat("ivan1-uuid", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chat.UUID != "chat-ivan1" {
+		t.Fatalf("expected chat chat-ivan1, got %s", chat.UUID)
+	}
+
+	// missing chat is created
+	user, err = crud.FindUserByFullname("Ekaterina Gorbunova", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chat, err = crud.OpenDirectChat(user.UUID, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chat.UUID != "chat-ekaterina" || createdChats != 1 {
+		t.Fatalf("expected chat chat-ekaterina to be created once, got %s created %d times", chat.UUID, createdChats)
+	}
+}
+
+func TestAPIUserSearch(t *testing.T) {
+	users := make([]*crud.PublicUser, 0, 25)
+	for i := 1; i <= 25; i++ {
+		users = append(users, &crud.PublicUser{
+			UUID:     "student" + strconv.Itoa(i) + "-uuid",
+			UserName: "student" + strconv.Itoa(i),
+			Online:   i%2 == 0,
+			Tutor:    i%5 == 0,
+		})
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/users/search" {
+			q := r.URL.Query()
+			matched := make([]*crud.PublicUser, 0)
+			for _, user := range users {
+				if !strings.Contains(user.UserName, q.Get("query")) {
+					continue
+				}
+				if v := q.Get("online"); v != "" && strconv.FormatBool(user.Online) != v {
+					continue
+				}
+				if v := q.Get("tutor"); v != "" && strconv.FormatBool(user.Tutor) != v {
+					continue
+				}
+				if v := q.Get("guest"); v != "" && strconv.FormatBool(user.Guest) != v {
+					continue
+				}
+				matched = append(matched, user)
+			}
+
+			page, _ := strconv.Atoi(q.Get("page"))
+			size, _ := strconv.Atoi(q.Get("size"))
+			if page == 0 {
+				page = 1
+			}
+			if size == 0 {
+				size = 10
+			}
+			start, end := (page-1)*size, page*size
+			if start > len(matched) {
+				start = len(matched)
+			}
+			if end > len(matched) {
+				end = len(matched)
+			}
+
+			json.NewEncoder(w).Encode(&crud.ResponsePublicUsers{Data: matched[start:end], Page: page, Size: size, Total: len(matched)})
+			return
+		}
+
+		for _, user := range users {
+			if r.URL.Path == "/v1/users/"+user.UUID {
+				json.NewEncoder(w).Encode(&crud.ResponsePublicUser{Data: user})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// paging
+	resp, err := crud.SearchUsers("student", "token", &crud.UserFilter{Page: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 5 || resp.Total != 25 || resp.Data[0].UserName != "student21" {
+		t.Fatalf("expected last 5 of 25 users starting from student21, got %d of %d", len(resp.Data), resp.Total)
+	}
+
+	// filters
+	online, tutor := true, true
+	resp, err = crud.SearchUsers("student", "token", &crud.UserFilter{Size: 50, Online: &online, Tutor: &tutor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 2 || resp.Data[0].UserName != "student10" || resp.Data[1].UserName != "student20" {
+		t.Fatalf("expected online tutors student10 and student20, got %d users", resp.Total)
+	}
+
+	online = false
+	resp, err = crud.SearchUsers("student", "token", &crud.UserFilter{Size: 50, Online: &online})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 13 {
+		t.Fatalf("expected 13 offline users, got %d", resp.Total)
+	}
+
+	// lookup by uuid or username
+	user, err := crud.FindUser("student7-uuid", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserName != "student7" {
+		t.Fatalf("expected student7, got %s", user.UserName)
+	}
+
+	user, err = crud.FindUser("@student12", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UUID != "student12-uuid" {
+		t.Fatalf("expected student12-uuid, got %s", user.UUID)
+	}
+
+	if _, err := crud.FindUser("missing-uuid", "token"); !errors.Is(err, crud.ErrUserNotFound) {
+		t.Fatalf("expected %v, got %v", crud.ErrUserNotFound, err)
+	}
+}
+
+func TestAPIFindUserPaging(t *testing.T) {
+	users := make([]*crud.PublicUser, 0, 120)
+	for i := 1; i <= 120; i++ {
+		users = append(users, &crud.PublicUser{UUID: "student" + strconv.Itoa(i) + "-uuid", UserName: "student" + strconv.Itoa(i)})
+	}
+	users[30].FirstName, users[30].LastName = "Ivan", "Petrov"
+	users[110].FirstName, users[110].LastName = "Ivan", "Petrov"
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/users/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+
+		// server searches loosely, exact matches can be on any page
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		start, end := (page-1)*size, page*size
+		if start > len(users) {
+			start = len(users)
+		}
+		if end > len(users) {
+			end = len(users)
+		}
+
+		json.NewEncoder(w).Encode(&crud.ResponsePublicUsers{Data: users[start:end], Page: page, Size: size, Total: len(users)})
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	// paging stops at the page with the user
+	user, err := crud.FindUserByUsername("@student75", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UUID != "student75-uuid" || requests != 2 {
+		t.Fatalf("expected student75-uuid on page 2, got %s after %d requests", user.UUID, requests)
+	}
+
+	// users with the same full name are found on all pages
+	requests = 0
+	_, err = crud.FindUserByFullname("Ivan Petrov", "token")
+	ambiguous := &crud.AmbiguousUserError{}
+	if !errors.As(err, &ambiguous) || len(ambiguous.Users) != 2 || requests != 3 {
+		t.Fatalf("expected 2 matched users after 3 requests, got %v after %d requests", err, requests)
+	}
+
+	requests = 0
+	_, err = crud.FindUserByUsername("@nobody", "token")
+	if !errors.Is(err, crud.ErrUserNotFound) || requests != 3 {
+		t.Fatalf("expected %v after 3 requests, got %v after %d requests", crud.ErrUserNotFound, err, requests)
+	}
+}
@@ -0,0 +1,144 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+	"github.com/pkg/errors"
+)
+
+func TestAPIWorkspaceManagement(t *testing.T) {
+	workspaces := map[string]*crud.Workspace{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/workspaces":
+			ws := &crud.Workspace{}
+			json.NewDecoder(r.Body).Decode(ws)
+			ws.UUID = "workspace-uuid"
+			ws.MembersCount = 1
+			workspaces[ws.UUID] = ws
+			json.NewEncoder(w).Encode(&crud.ResponseWorkspace{Data: ws})
+		case "GET /v1/workspaces":
+			resp := &crud.ResponseWorkspaces{}
+			archived := r.URL.Query().Get("archived") == "true"
+			for _, ws := range workspaces {
+				if typ := r.URL.Query().Get("type"); typ != "" && typ != ws.Type {
+					continue
+				}
+				if ws.Archived == archived {
+					resp.Data = append(resp.Data, ws)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "GET /v1/workspaces/workspace-uuid":
+			json.NewEncoder(w).Encode(&crud.ResponseWorkspace{Data: workspaces["workspace-uuid"]})
+		case "PUT /v1/workspaces/workspace-uuid":
+			data := struct {
+				Title string `json:"title"`
+			}{}
+			json.NewDecoder(r.Body).Decode(&data)
+			workspaces["workspace-uuid"].Title = data.Title
+			json.NewEncoder(w).Encode(&crud.ResponseWorkspace{Data: workspaces["workspace-uuid"]})
+		case "POST /v1/workspaces/workspace-uuid/archive":
+			workspaces["workspace-uuid"].Archived = true
+			json.NewEncoder(w).Encode(&crud.ResponseWorkspace{Data: workspaces["workspace-uuid"]})
+		case "PUT /v1/workspaces/community-uuid", "POST /v1/workspaces/community-uuid/archive":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	crud.SetAPIURL(srv.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	if _, err := crud.NewWorkspace("CLASS", "Biology 101", ""); err != crud.ErrWorkspaceType {
+		t.Fatalf("expected ErrWorkspaceType, got %v", err)
+	}
+
+	ws, err := crud.NewWorkspace(crud.WorkspaceTypeWorkspace, "Biology 101", "Autumn course")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := ws.Create("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Data.UUID != "workspace-uuid" || created.Data.Description != "Autumn course" {
+		t.Fatalf("unexpected created workspace: %+v", created.Data)
+	}
+
+	list, err := crud.ListWorkspaces("token", &crud.WorkspaceFilter{Type: crud.WorkspaceTypeWorkspace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 {
+		t.Fatalf("expected 1 workspace, got %d", len(list.Data))
+	}
+	list, err = crud.ListWorkspaces("token", &crud.WorkspaceFilter{Type: crud.WorkspaceTypeCommunity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 0 {
+		t.Fatalf("expected no communities, got %d", len(list.Data))
+	}
+
+	renamed, err := crud.RenameWorkspace("workspace-uuid", "Biology 102", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Data.Title != "Biology 102" {
+		t.Fatalf("expected title Biology 102, got %s", renamed.Data.Title)
+	}
+
+	if _, err := crud.ArchiveWorkspace("workspace-uuid", "token"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := crud.GetWorkspace("workspace-uuid", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Data.Archived || got.Data.Title != "Biology 102" {
+		t.Fatalf("expected archived workspace Biology 102, got %+v", got.Data)
+	}
+
+	list, err = crud.ListWorkspaces("token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 0 {
+		t.Fatalf("expected archived workspace to be hidden, got %d workspaces", len(list.Data))
+	}
+	list, err = crud.ListWorkspaces("token", &crud.WorkspaceFilter{Archived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 {
+		t.Fatalf("expected 1 archived workspace, got %d", len(list.Data))
+	}
+
+	// only admins can rename and archive
+	if _, err := crud.RenameWorkspace("community-uuid", "Mine", "token"); !errors.Is(err, crud.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := crud.ArchiveWorkspace("community-uuid", "token"); !errors.Is(err, crud.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := crud.GetWorkspace("missing-uuid", "token"); !errors.Is(err, crud.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// expired token isn't a missing permission
+	if _, err := crud.GetWorkspace("workspace-uuid", "expired-token"); !errors.Is(err, crud.ErrUnauthorized) || errors.Is(err, crud.ErrForbidden) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bejaneps/acroplia/internal/crud"
+)
+
+// specServer is a fake Acroplia, that stores workspaces, members and library items
+type specServer struct {
+	mu         sync.Mutex
+	workspaces map[string]*crud.Workspace
+	members    map[string][]*crud.Member // key is workspace uuid
+	library    map[string]*crud.LibraryItem
+	requests   int // number of requests, that change state
+}
+
+func newSpecServer() *specServer {
+	return &specServer{
+		workspaces: make(map[string]*crud.Workspace),
+		members:    make(map[string][]*crud.Member),
+		library:    make(map[string]*crud.LibraryItem),
+	}
+}
+
+func (s *specServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != "GET" {
+		s.requests++
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[1:]
+	switch {
+	case r.Method == "POST" && len(parts) == 1 && parts[0] == "workspaces":
+		ws := &crud.Workspace{}
+		json.NewDecoder(r.Body).Decode(ws)
+		ws.UUID = fmt.Sprintf("workspace-%d", len(s.workspaces)+1)
+		s.workspaces[ws.UUID] = ws
+		s.members[ws.UUID] = []*crud.Member{{User: &crud.PublicUser{UUID: "user-uuid", UserName: "tutor"}, Roles: []*crud.Role{{Type: crud.RoleWorkspaceMember}, {Type: crud.RoleWorkspaceAdmin}}}}
+		json.NewEncoder(w).Encode(&crud.ResponseWorkspace{Data: ws})
+	case r.Method == "GET" && len(parts) == 1 && parts[0] == "workspaces":
+		resp := &crud.ResponseWorkspaces{}
+		for _, ws := range s.workspaces {
+			resp.Data = append(resp.Data, ws)
+		}
+		json.NewEncoder(w).Encode(resp)
+	case r.Method == "PUT" && len(parts) == 2 && parts[0] == "workspaces":
+		json.NewDecoder(r.Body).Decode(s.workspaces[parts[1]])
+		json.NewEncoder(w).Encode(&crud.ResponseWorkspace{Data: s.workspaces[parts[1]]})
+	case len(parts) >= 3 && parts[0] == "workspaces" && parts[2] == "members":
+		s.serveMembers(w, r, parts[1], parts[3:])
+	case len(parts) == 2 && parts[0] == "library":
+		if r.Method == "POST" {
+			item := &crud.LibraryItem{}
+			json.NewDecoder(r.Body).Decode(item)
+			s.library[item.UUID] = item
+			json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: item})
+			return
+		}
+
+		resp := &crud.ResponseLibraryItems{}
+		for _, item := range s.library {
+			if item.Type == r.URL.Query().Get("type") {
+				listed := *item
+				listed.Delta = nil
+				resp.Data = append(resp.Data, &listed)
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	case len(parts) == 3 && parts[0] == "library" && parts[1] == "items":
+		if r.Method == "PUT" {
+			json.NewDecoder(r.Body).Decode(s.library[parts[2]])
+		}
+		json.NewEncoder(w).Encode(&crud.ResponseLibraryItem{Data: s.library[parts[2]]})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *specServer) serveMembers(w http.ResponseWriter, r *http.Request, workspaceUUID string, parts []string) {
+	members := s.members[workspaceUUID]
+
+	if len(parts) == 0 {
+		json.NewEncoder(w).Encode(&crud.ResponseMembers{Data: members})
+		return
+	}
+
+	if parts[0] == "invite" {
+		invitation := &crud.Invitation{}
+		json.NewDecoder(r.Body).Decode(invitation)
+		m := &crud.Member{User: &crud.PublicUser{}, Email: invitation.Email, Roles: []*crud.Role{{Type: invitation.Role}}}
+		m.User.UserName = invitation.UserName
+		if m.User.UserName == "" {
+			m.User.UserName = strings.Split(invitation.Email, "@")[0]
+		}
+		m.User.UUID = m.User.UserName + "-uuid"
+		s.members[workspaceUUID] = append(members, m)
+		json.NewEncoder(w).Encode(&crud.ResponseMember{Data: m})
+		return
+	}
+
+	for i, m := range members {
+		if m.User.UUID != parts[0] {
+			continue
+		}
+
+		switch {
+		case r.Method == "DELETE" && len(parts) == 1:
+			s.members[workspaceUUID] = append(members[:i], members[i+1:]...)
+		case r.Method == "POST":
+			role := &crud.Role{}
+			json.NewDecoder(r.Body).Decode(role)
+			m.Roles = append(m.Roles, role)
+		case r.Method == "DELETE":
+			roles := make([]*crud.Role, 0)
+			for _, role := range m.Roles {
+				if role.Type != parts[2] {
+					roles = append(roles, role)
+				}
+			}
+			m.Roles = roles
+		}
+		json.NewEncoder(w).Encode(&crud.ResponseMember{Data: m})
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func TestAPIWorkspaceSpecApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	specFile := filepath.Join(dir, "space.yaml")
+	data := `workspaces:
+  - title: Biology 101
+    description: Autumn course
+    prune: true
+    members:
+      - user: "@ekaterina"
+        roles: [WORKSPACE_ADMIN, MODERATOR]
+      - user: student@example.com
+folders:
+  - path: Biology 101/Week 1
+    subtitle: Photosynthesis
+textpads:
+  - title: Syllabus
+    folder: Biology 101
+    text: Welcome to the course
+    tags: [biology]
+`
+	if err := ioutil.WriteFile(specFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newSpecServer()
+	server := httptest.NewServer(srv)
+	defer server.Close()
+	crud.SetAPIURL(server.URL)
+	defer crud.SetAPIURL(crud.DefaultAPIURL)
+
+	spec, err := crud.LoadSpec(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &crud.PrivateUser{UUID: "user-uuid", UserName: "tutor"}
+	provisioner := crud.NewProvisioner(user, "token")
+
+	plan, err := provisioner.Plan(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.requests != 0 {
+		t.Fatalf("expected plan to change nothing, got %d changing requests", srv.requests)
+	}
+
+	// workspace, 2 members, 2 folders and a textpad
+	if len(plan.Actions) != 6 {
+		t.Fatalf("expected 6 actions, got %d", len(plan.Actions))
+	}
+	for _, action := range plan.Actions {
+		if action.Action != crud.PlanCreate {
+			t.Fatalf("expected only creations, got %s %s %s", action.Action, action.Kind, action.Target)
+		}
+	}
+
+	if err := provisioner.Apply(plan, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range plan.Actions {
+		if action.Status != crud.PlanStatusApplied {
+			t.Fatalf("expected %s %s to be applied, got %s", action.Kind, action.Target, action.Status)
+		}
+	}
+
+	members := srv.members["workspace-1"]
+	if len(members) != 3 || !members[0].HasRole(crud.RoleWorkspaceAdmin) || !members[1].HasRole(crud.RoleModerator) || !members[2].HasRole(crud.RoleWorkspaceMember) {
+		t.Fatalf("expected tutor as admin, ekaterina as moderator and student as member, got %d members", len(members))
+	}
+	if !members[1].HasRole(crud.RoleWorkspaceMember) {
+		t.Fatalf("expected ekaterina to keep member role, got %v", members[1].RoleTypes())
+	}
+	for _, item := range srv.library {
+		if item.Title == "Week 1" && (len(item.Path) != 1 || item.Path[0].Title != "Biology 101") {
+			t.Fatalf("expected Week 1 folder to be nested in Biology 101, got %v", item.Path)
+		}
+		if item.Title == "Syllabus" && item.Delta.Text() != "Welcome to the course\n" {
+			t.Fatalf("expected syllabus to be seeded with text, got %q", item.Delta.Text())
+		}
+	}
+
+	// applying the same spec again changes nothing
+	plan, err = crud.NewProvisioner(user, "token").Plan(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("expected empty plan, got %s %s %s", plan.Actions[0].Action, plan.Actions[0].Kind, plan.Actions[0].Target)
+	}
+
+	// drift on server is planned as updates and deletes
+	srv.members["workspace-1"] = append(srv.members["workspace-1"], &crud.Member{User: &crud.PublicUser{UUID: "stranger-uuid", UserName: "stranger"}})
+	srv.members["workspace-1"][1].Roles = srv.members["workspace-1"][1].Roles[:2]
+	srv.members["workspace-1"][2].Roles = append(srv.members["workspace-1"][2].Roles, &crud.Role{Type: crud.RoleWorkspaceAdmin})
+	srv.workspaces["workspace-1"].Description = "Old course"
+	for _, item := range srv.library {
+		if item.Title == "Syllabus" {
+			item.Delta = &crud.Delta{Ops: []*crud.Op{{Insert: "Old text\n"}}}
+		}
+	}
+
+	// tutor applying spec keeps it's admin role, though spec lists it without roles
+	spec.Workspaces[0].Members = append(spec.Workspaces[0].Members, &crud.SpecMember{User: "@tutor"})
+
+	provisioner = crud.NewProvisioner(user, "token")
+	plan, err = provisioner.Plan(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(plan.Actions))
+	for _, action := range plan.Actions {
+		got = append(got, fmt.Sprintf("%s %s %s %s", action.Action, action.Kind, action.Target, action.Details))
+	}
+	expected := []string{
+		"UPDATE WORKSPACE Biology 101 description",
+		"UPDATE MEMBER Biology 101/@ekaterina grant MODERATOR",
+		"UPDATE MEMBER Biology 101/student@example.com revoke WORKSPACE_ADMIN",
+		"DELETE MEMBER Biology 101/@stranger ",
+		"UPDATE TEXTPAD Biology 101/Syllabus text",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected plan:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	if err := provisioner.Apply(plan, nil); err != nil {
+		t.Fatal(err)
+	}
+	plan, err = crud.NewProvisioner(user, "token").Plan(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("expected empty plan after fixing drift, got %d actions", len(plan.Actions))
+	}
+}
+
+func TestWorkspaceSpecLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acroplia-spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	specFile := filepath.Join(dir, "space.toml")
+	data := `[[workspaces]]
+title = "Biology lovers"
+type = "community"
+
+[[workspaces.members]]
+user = "@ekaterina"
+roles = ["community_task_manager"]
+`
+	if err := ioutil.WriteFile(specFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := crud.LoadSpec(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws := spec.Workspaces[0]; ws.Type != crud.WorkspaceTypeCommunity || ws.Members[0].Roles[0] != crud.RoleCommunityTaskManager {
+		t.Fatalf("expected community with task manager, got %s with %v", ws.Type, ws.Members[0].Roles)
+	}
+
+	invalid := map[string]*crud.Spec{
+		"role":      {Workspaces: []*crud.SpecWorkspace{{Title: "A", Members: []*crud.SpecMember{{User: "@a", Roles: []string{"OWNER"}}}}}},
+		"type":      {Workspaces: []*crud.SpecWorkspace{{Title: "A", Type: "CLASS"}}},
+		"title":     {Workspaces: []*crud.SpecWorkspace{{}}},
+		"duplicate": {Workspaces: []*crud.SpecWorkspace{{Title: "A", Members: []*crud.SpecMember{{User: "@a"}, {User: "A"}}}}},
+		"textpad":   {Textpads: []*crud.SpecTextpad{{Folder: "A"}}},
+	}
+	for name, spec := range invalid {
+		if err := spec.Validate(); err == nil {
+			t.Fatalf("expected %s validation error", name)
+		}
+	}
+}